@@ -1,125 +1,565 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ikateclab/gorm-tracked-updates/pkg/clonegen"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/config"
 	"github.com/ikateclab/gorm-tracked-updates/pkg/diffgen"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/genfile"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/selection"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/typeregistry"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/workspace"
 )
 
+// Layouts of the generated files
+const (
+	layoutPackage = "package" // Clone and diff methods in -clone-file and -diff-file
+	layoutSource  = "source"  // Methods of the structs of each source file in <source>_gen.go
+	layoutSingle  = "single"  // Clone and diff methods in genfile.CombinedFileName
+)
+
+// options are the settings of the packages sharing a configuration file
+type options struct {
+	types             string
+	configPath        string // Configuration file the settings were read from, if any
+	generateClone     bool
+	generateDiff      bool
+	generateChanges   bool
+	generatePatches   bool
+	generateApply     bool
+	generateMerge     bool
+	jsonMerge         diffgen.JSONMergeMode
+	dialect           diffgen.Dialect
+	jsonLibrary       diffgen.JSONLibrary
+	replaceJSONArrays bool
+	typeComparisons   map[string]diffgen.Comparison
+	typeRegistry      *typeregistry.Registry
+	fieldTags         map[string]string
+	selector          selection.Selector
+	force             bool
+	check             bool
+	layout            string
+	cloneFile         string
+	diffFile          string
+
+	// Structs generated in each package, resolving fields of their types in other packages
+	cloneStructs *workspace.Structs
+	diffStructs  *workspace.Structs
+}
+
+// packageRun generates the code of one package. Its report is buffered so the reports of
+// packages generated in parallel are printed in order.
+type packageRun struct {
+	pkg       workspace.Package
+	outputDir string
+	opts      *options
+	clone     *clonegen.CloneGenerator
+	diff      *diffgen.DiffGenerator
+	report    strings.Builder
+	stale     []string // Output files that are out of date with -check
+	err       error
+}
+
+// reportWriter adds the lines written to it, one per Write, to the report of a run
+type reportWriter struct {
+	report *strings.Builder
+}
+
+func (w reportWriter) Write(p []byte) (int, error) {
+	w.report.WriteString("   ")
+	return w.report.Write(p)
+}
+
+// flags are the command line flags. They are defined on a new flag set per configuration
+// file, so that the file sets their defaults and the command line overrides them.
+type flags struct {
+	set        *flag.FlagSet
+	packageDir *string
+	types      *string
+	output     *string
+	configFile *string
+	jsonMerge  *string
+	dialect    *string
+	jsonLib    *string
+	replaceArr *bool
+	include    *string
+	exclude    *string
+	typeNames  *string
+	tracked    *bool
+	force      *bool
+	check      *bool
+	layout     *string
+	cloneFile  *string
+	diffFile   *string
+	help       *bool
+}
+
+// newFlags defines the command line flags on a new flag set
+func newFlags() *flags {
+	set := flag.NewFlagSet("gorm-gen", flag.ExitOnError)
+	f := &flags{
+		set:        set,
+		packageDir: set.String("package", ".", "Package directories or patterns such as ./internal/... to scan for structs (comma-separated)"),
+		types:      set.String("types", "clone,diff", "Types to generate (clone,diff,changes,patch,apply,merge)"),
+		output:     set.String("output", "", "Output directory (defaults to package directory, only with a single package)"),
+		configFile: set.String("config", "", "Configuration file (defaults to the closest "+strings.Join(config.FileNames, " or ")+" from the package directory up)"),
+		jsonMerge:  set.String("json-merge", "shallow", "How @jsonb struct columns are merged on update (shallow,deep)"),
+		dialect:    set.String("dialect", "postgres", "SQL dialect of JSON merge expressions (postgres,mysql,sqlite,auto)"),
+		jsonLib:    set.String("json", "sonic", "JSON library of the generated code (sonic,encoding/json)"),
+		replaceArr: set.Bool("replace-json-arrays", true, "Replace array JSON columns as a whole instead of concatenating them"),
+		include:    set.String("include", "", "Only generate for structs whose name matches this regular expression"),
+		exclude:    set.String("exclude", "", "Do not generate for structs whose name matches this regular expression"),
+		typeNames:  set.String("type", "", "Only generate for these structs (comma-separated)"),
+		tracked:    set.Bool("tracked", false, "Only generate for structs annotated with @track or declaring a TableName method"),
+		force:      set.Bool("force", false, "Overwrite output files that were not generated by gorm-gen"),
+		check:      set.Bool("check", false, "Check that the generated files are up to date, printing a diff of the drift, without writing them"),
+		layout:     set.String("layout", layoutPackage, "Output files (package: -clone-file and -diff-file, source: <source>_gen.go per source file, single: "+genfile.CombinedFileName+")"),
+		cloneFile:  set.String("clone-file", "clone.go", "File clone methods are written to with -layout=package"),
+		diffFile:   set.String("diff-file", "diff.go", "File diff methods are written to with -layout=package"),
+		help:       set.Bool("help", false, "Show help"),
+	}
+	set.Usage = func() { printUsage(set) }
+	return f
+}
+
 func main() {
-	var (
-		packageDir = flag.String("package", ".", "Package directory to scan for structs")
-		types      = flag.String("types", "clone,diff", "Types to generate (clone,diff)")
-		output     = flag.String("output", "", "Output directory (defaults to package directory)")
-		help       = flag.Bool("help", false, "Show help")
-	)
-	flag.Parse()
-
-	if *help {
-		printUsage()
+	cmdFlags := newFlags()
+	cmdFlags.set.Parse(os.Args[1:])
+
+	if *cmdFlags.help {
+		printUsage(cmdFlags.set)
 		return
 	}
 
-	// Default output to package directory if not specified
-	if *output == "" {
-		*output = *packageDir
+	// Packages are given by -package or as arguments
+	patterns := cmdFlags.set.Args()
+	if len(patterns) == 0 {
+		patterns = splitList(*cmdFlags.packageDir)
+	}
+	packages, err := workspace.Resolve(patterns)
+	if err != nil {
+		log.Fatalf("Error resolving packages: %v", err)
+	}
+	if len(packages) > 1 && *cmdFlags.output != "" {
+		log.Fatal("-output can only be used with a single package")
 	}
 
-	// Parse types to generate
-	generateTypes := strings.Split(*types, ",")
-	generateClone := contains(generateTypes, "clone")
-	generateDiff := contains(generateTypes, "diff")
+	// Packages sharing a configuration file share their options
+	cloneStructs, diffStructs := workspace.NewStructs(), workspace.NewStructs()
+	optionsByConfig := make(map[string]*options)
+	runs := make([]*packageRun, len(packages))
+	for i, pkg := range packages {
+		runs[i] = &packageRun{pkg: pkg, outputDir: pkg.Dir}
+		if *cmdFlags.output != "" {
+			if runs[i].outputDir, err = filepath.Abs(*cmdFlags.output); err != nil {
+				log.Fatalf("Error resolving output directory: %v", err)
+			}
+		}
 
-	if !generateClone && !generateDiff {
-		log.Fatal("At least one of 'clone' or 'diff' must be specified in -types")
+		cfg, err := loadConfig(*cmdFlags.configFile, pkg.Dir)
+		if err != nil {
+			log.Fatalf("Error reading configuration: %v", err)
+		}
+		opts, ok := optionsByConfig[cfg.Path]
+		if !ok {
+			if opts, err = newOptions(cfg); err != nil {
+				log.Fatalf("Invalid options: %v", err)
+			}
+			opts.cloneStructs, opts.diffStructs = cloneStructs, diffStructs
+			optionsByConfig[cfg.Path] = opts
+		}
+		runs[i].opts = opts
 	}
 
-	// Convert to absolute paths
-	absPackageDir, err := filepath.Abs(*packageDir)
-	if err != nil {
-		log.Fatalf("Error resolving package directory: %v", err)
+	fmt.Printf("🚀 GORM Code Generator\n")
+	if len(runs) == 1 {
+		fmt.Printf("📁 Package: %s\n", runs[0].pkg.Dir)
+		fmt.Printf("📤 Output: %s\n", runs[0].outputDir)
+		fmt.Printf("🔧 Types: %s\n", runs[0].opts.types)
+	} else {
+		fmt.Printf("📁 Packages: %d matching %s\n", len(runs), strings.Join(patterns, " "))
 	}
+	fmt.Println()
+
+	// All packages are parsed before generating any, so that the structs of every package
+	// are known when fields referring to them are generated
+	forEach(runs, func(run *packageRun) {
+		run.err = run.parse()
+	})
+	forEach(runs, func(run *packageRun) {
+		if run.err == nil {
+			run.err = run.generate()
+		}
+	})
+
+	// Report the outcome of each package
+	var failed, stale int
+	for _, run := range runs {
+		fmt.Printf("📦 %s\n", run.name())
+		fmt.Print(run.report.String())
+		if run.err != nil {
+			fmt.Printf("   ❌ %v%s\n", run.err, forceHint(run.err))
+			failed++
+		} else if len(run.stale) > 0 {
+			fmt.Printf("   ❌ Generated code is out of date: %s\n", strings.Join(run.stale, ", "))
+			stale++
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n❌ Code generation failed for %d of %d packages\n", failed, len(runs))
+		os.Exit(1)
+	}
+	if *cmdFlags.check {
+		if stale > 0 {
+			fmt.Printf("\n❌ Generated code is out of date in %d of %d packages\n", stale, len(runs))
+			fmt.Println("   Run gorm-gen without -check to regenerate it")
+			os.Exit(1)
+		}
+		fmt.Println("\n✅ Generated code is up to date")
+		return
+	}
+
+	fmt.Println("\n🎯 Code generation completed successfully!")
+}
 
-	absOutputDir, err := filepath.Abs(*output)
+// loadConfig reads the configuration file given by -config, or else the one closest to
+// the package directory
+func loadConfig(configFile, dir string) (*config.Config, error) {
+	if configFile != "" {
+		return config.Load(configFile)
+	}
+	return config.ForDir(dir)
+}
+
+// newOptions builds the options of the packages using a configuration: the settings of the
+// configuration file, overridden by the command line flags
+func newOptions(cfg *config.Config) (*options, error) {
+	f := newFlags()
+	f.set.Parse(os.Args[1:])
+	settings, err := applyConfig(f.set, cfg)
 	if err != nil {
-		log.Fatalf("Error resolving output directory: %v", err)
+		return nil, err
 	}
 
-	fmt.Printf("🚀 GORM Code Generator\n")
-	fmt.Printf("📁 Package: %s\n", absPackageDir)
-	fmt.Printf("📤 Output: %s\n", absOutputDir)
-	fmt.Printf("🔧 Types: %s\n", *types)
-	fmt.Println()
+	opts, err := buildOptions(f, cfg)
+	var flagErr *flagError
+	if errors.As(err, &flagErr) && settings[flagErr.name] != "" {
+		// The invalid value comes from the configuration file
+		return nil, fmt.Errorf("%s: %s: %v", cfg.Path, settings[flagErr.name], flagErr.err)
+	}
+	return opts, err
+}
 
-	// Generate clone methods
-	if generateClone {
-		fmt.Println("🔧 Generating clone methods...")
-		cloneGenerator := clonegen.New()
+// flagError reports an invalid flag value
+type flagError struct {
+	name string // Flag name, without the leading -
+	err  error
+}
 
-		err := cloneGenerator.ParseDirectory(absPackageDir)
-		if err != nil {
-			log.Fatalf("Error parsing directory for clone generation: %v", err)
+func (e *flagError) Error() string {
+	return fmt.Sprintf("invalid -%s: %v", e.name, e.err)
+}
+
+// buildOptions builds the options of the parsed flags
+func buildOptions(f *flags, cfg *config.Config) (*options, error) {
+	// Parse types to generate
+	generateTypes := strings.Split(*f.types, ",")
+	opts := &options{
+		types:             *f.types,
+		configPath:        cfg.Path,
+		generateClone:     contains(generateTypes, "clone"),
+		generateChanges:   contains(generateTypes, "changes"),
+		generatePatches:   contains(generateTypes, "patch"),
+		generateApply:     contains(generateTypes, "apply"),
+		generateMerge:     contains(generateTypes, "merge"),
+		replaceJSONArrays: *f.replaceArr,
+		fieldTags:         cfg.Fields,
+		force:             *f.force,
+		check:             *f.check,
+		layout:            *f.layout,
+		cloneFile:         *f.cloneFile,
+		diffFile:          *f.diffFile,
+	}
+	opts.generateDiff = contains(generateTypes, "diff") || opts.generateChanges || opts.generatePatches || opts.generateApply || opts.generateMerge
+
+	if !opts.generateClone && !opts.generateDiff {
+		return nil, &flagError{"types", errors.New("at least one of 'clone', 'diff', 'changes', 'patch', 'apply' or 'merge' must be specified")}
+	}
+
+	var err error
+	if opts.jsonMerge, err = diffgen.ParseJSONMergeMode(*f.jsonMerge); err != nil {
+		return nil, &flagError{"json-merge", err}
+	}
+	if opts.dialect, err = diffgen.ParseDialect(*f.dialect); err != nil {
+		return nil, &flagError{"dialect", err}
+	}
+	if opts.jsonLibrary, err = diffgen.ParseJSONLibrary(*f.jsonLib); err != nil {
+		return nil, &flagError{"json", err}
+	}
+	if *f.layout != layoutPackage && *f.layout != layoutSource && *f.layout != layoutSingle {
+		return nil, &flagError{"layout", fmt.Errorf("unknown layout %q", *f.layout)}
+	}
+
+	opts.typeComparisons = make(map[string]diffgen.Comparison)
+	for typeName, comparison := range cfg.Comparisons() {
+		if opts.typeComparisons[typeName], err = diffgen.ParseComparison(comparison); err != nil {
+			return nil, fmt.Errorf("%s: overrides: %s: %v", cfg.Path, typeName, err)
 		}
+	}
+
+	if opts.typeRegistry, err = cfg.Types(); err != nil {
+		return nil, err
+	}
+
+	if opts.selector, err = parseSelector(*f.include, *f.exclude, *f.typeNames, *f.tracked); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// applyConfig sets the flags of the settings of a configuration file that are not set on
+// the command line. It returns the configuration keys of the flags it set, by flag name.
+func applyConfig(set *flag.FlagSet, cfg *config.Config) (map[string]string, error) {
+	type setting struct {
+		key, flag, value string
+	}
+	settings := []setting{
+		{"generate", "types", strings.Join(cfg.Generate, ",")},
+		{"layout", "layout", cfg.Layout},
+		{"clone_file", "clone-file", cfg.CloneFile},
+		{"diff_file", "diff-file", cfg.DiffFile},
+		{"include", "include", cfg.Include},
+		{"exclude", "exclude", cfg.Exclude},
+		{"structs", "type", strings.Join(cfg.Structs, ",")},
+		{"dialect", "dialect", cfg.Dialect},
+		{"json_merge", "json-merge", cfg.JSONMerge},
+		{"json", "json", cfg.JSON},
+	}
+	if cfg.Tracked != nil {
+		settings = append(settings, setting{"tracked", "tracked", strconv.FormatBool(*cfg.Tracked)})
+	}
+	if cfg.ReplaceJSONArrays != nil {
+		settings = append(settings, setting{"replace_json_arrays", "replace-json-arrays", strconv.FormatBool(*cfg.ReplaceJSONArrays)})
+	}
 
-		if len(cloneGenerator.Structs) == 0 {
-			fmt.Println("⚠️  No structs found for clone generation")
+	// Flags given on the command line take precedence
+	onCommandLine := make(map[string]bool)
+	set.Visit(func(f *flag.Flag) {
+		onCommandLine[f.Name] = true
+	})
+
+	applied := make(map[string]string)
+	for _, s := range settings {
+		if s.value == "" || onCommandLine[s.flag] {
+			continue
+		}
+		if err := set.Set(s.flag, s.value); err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", cfg.Path, s.key, err)
+		}
+		applied[s.flag] = s.key
+	}
+	return applied, nil
+}
+
+// forEach calls fn for every run in parallel, running at most GOMAXPROCS at a time
+func forEach(runs []*packageRun, fn func(run *packageRun)) {
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for _, run := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			fn(run)
+		}()
+	}
+	wg.Wait()
+}
+
+// name identifies the package of the run in its report
+func (r *packageRun) name() string {
+	if r.pkg.Path != "" {
+		return r.pkg.Path
+	}
+	return r.pkg.Dir
+}
+
+// parse parses the structs of the package and selects those to generate methods for,
+// recording them in the structs of the run
+func (r *packageRun) parse() error {
+	opts := r.opts
+	if opts.configPath != "" {
+		fmt.Fprintf(&r.report, "   ⚙️  Config: %s\n", opts.configPath)
+	}
+
+	// Files skipped as input because they are about to be overwritten
+	var skipFiles []string
+	switch opts.layout {
+	case layoutPackage:
+		skipFiles = []string{opts.cloneFile, opts.diffFile}
+	case layoutSingle:
+		skipFiles = []string{genfile.CombinedFileName}
+	}
+
+	if opts.generateClone {
+		r.clone = clonegen.New()
+		r.clone.Force = opts.force
+		r.clone.FileName = opts.cloneFile
+		r.clone.SkipFiles = skipFiles
+		r.clone.External = opts.cloneStructs
+		r.clone.FieldTags = opts.fieldTags
+		r.clone.Types = opts.typeRegistry
+		r.clone.Warnings = reportWriter{&r.report}
+
+		if err := r.clone.ParseDirectory(r.pkg.Dir); err != nil {
+			return fmt.Errorf("error parsing directory for clone generation: %v", err)
+		}
+		if err := r.clone.Select(opts.selector); err != nil {
+			return fmt.Errorf("error selecting structs for clone generation: %v", err)
+		}
+
+		if len(r.clone.Structs) == 0 {
+			fmt.Fprintln(&r.report, "   ⚠️  No structs found for clone generation")
+			r.clone = nil
 		} else {
-			err = cloneGenerator.WriteToPackageDir(absOutputDir)
-			if err != nil {
-				log.Fatalf("Error writing clone methods: %v", err)
+			fmt.Fprintf(&r.report, "   ✅ Generated clone methods for %d structs\n", len(r.clone.Structs))
+			for _, structInfo := range r.clone.Structs {
+				opts.cloneStructs.Add(r.pkg.Path, structInfo.Name, structInfo.IsJSONB)
 			}
-
-			fmt.Printf("✅ Generated clone methods for %d structs\n", len(cloneGenerator.Structs))
-			fmt.Printf("   Written to: %s/clone.go\n", absOutputDir)
 		}
 	}
 
-	// Generate diff methods
-	if generateDiff {
-		fmt.Println("📝 Generating diff methods...")
-		diffGenerator := diffgen.New()
+	if opts.generateDiff {
+		r.diff = diffgen.New()
+		r.diff.Force = opts.force
+		r.diff.FileName = opts.diffFile
+		r.diff.SkipFiles = skipFiles
+		r.diff.External = opts.diffStructs
+		r.diff.JSONMerge = opts.jsonMerge
+		r.diff.Dialect = opts.dialect
+		r.diff.JSONLibrary = opts.jsonLibrary
+		r.diff.TypeComparisons = opts.typeComparisons
+		r.diff.FieldTags = opts.fieldTags
+		r.diff.Types = opts.typeRegistry
+		r.diff.Warnings = reportWriter{&r.report}
+		r.diff.ReplaceJSONArrays = opts.replaceJSONArrays
+		r.diff.GenerateChanges = opts.generateChanges
+		r.diff.GeneratePatches = opts.generatePatches
+		r.diff.GenerateApply = opts.generateApply
+		r.diff.GenerateMerge = opts.generateMerge
 
-		err := diffGenerator.ParseDirectory(absPackageDir)
-		if err != nil {
-			log.Fatalf("Error parsing directory for diff generation: %v", err)
+		if err := r.diff.ParseDirectory(r.pkg.Dir); err != nil {
+			return fmt.Errorf("error parsing directory for diff generation: %v", err)
+		}
+		if err := r.diff.Select(opts.selector); err != nil {
+			return fmt.Errorf("error selecting structs for diff generation: %v", err)
 		}
 
-		if len(diffGenerator.Structs) == 0 {
-			fmt.Println("⚠️  No structs found for diff generation")
+		if len(r.diff.Structs) == 0 {
+			fmt.Fprintln(&r.report, "   ⚠️  No structs found for diff generation")
+			r.diff = nil
 		} else {
-			err = diffGenerator.WriteToPackageDir(absOutputDir)
-			if err != nil {
-				log.Fatalf("Error writing diff methods: %v", err)
+			fmt.Fprintf(&r.report, "   ✅ Generated diff methods for %d structs\n", len(r.diff.Structs))
+			for _, structInfo := range r.diff.Structs {
+				opts.diffStructs.Add(r.pkg.Path, structInfo.Name, structInfo.IsJSONB)
 			}
+		}
+	}
+
+	return nil
+}
 
-			fmt.Printf("✅ Generated diff methods for %d structs\n", len(diffGenerator.Structs))
-			fmt.Printf("   Written to: %s/diff.go\n", absOutputDir)
+// generate generates the output files of the package and writes them, or with -check
+// compares them with the files on disk
+func (r *packageRun) generate() error {
+	opts := r.opts
+	files := make(map[string][]string)
+	if r.clone != nil {
+		if err := collectFiles(files, opts.layout, r.clone.FileName, r.clone.GenerateCode, r.clone.GenerateSourceFiles); err != nil {
+			return fmt.Errorf("error generating clone methods: %v", err)
+		}
+	}
+	if r.diff != nil {
+		if err := collectFiles(files, opts.layout, r.diff.FileName, r.diff.GenerateCode, r.diff.GenerateSourceFiles); err != nil {
+			return fmt.Errorf("error generating diff methods: %v", err)
 		}
 	}
 
-	fmt.Println("\n🎯 Code generation completed successfully!")
+	code, names, err := combineFiles(files)
+	if err != nil {
+		return fmt.Errorf("error combining generated code: %v", err)
+	}
+
+	// Compare with the files on disk instead of writing them
+	if opts.check {
+		r.stale, err = checkFiles(&r.report, r.outputDir, code, names, opts.ownsFile)
+		if err != nil {
+			return fmt.Errorf("error checking generated code: %v", err)
+		}
+		return nil
+	}
+
+	for _, name := range names {
+		if err := genfile.Write(filepath.Join(r.outputDir, name), []byte(code[name]), opts.force); err != nil {
+			return fmt.Errorf("error writing generated code: %w", err)
+		}
+		fmt.Fprintf(&r.report, "   Written to: %s\n", filepath.Join(r.outputDir, name))
+	}
+	return nil
 }
 
-func printUsage() {
+func printUsage(set *flag.FlagSet) {
 	fmt.Println("GORM Code Generator")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Println("  gorm-gen [flags]")
+	fmt.Println("  gorm-gen [flags] [packages]")
 	fmt.Println()
 	fmt.Println("Flags:")
-	flag.PrintDefaults()
+	set.SetOutput(os.Stdout)
+	set.PrintDefaults()
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  gorm-gen                                    # Generate both clone and diff in current directory")
 	fmt.Println("  gorm-gen -types=clone                       # Generate only clone methods")
 	fmt.Println("  gorm-gen -types=diff                        # Generate only diff methods")
+	fmt.Println("  gorm-gen -types=clone,diff,changes          # Also generate Changes methods with old and new values")
+	fmt.Println("  gorm-gen -types=clone,diff,patch            # Also generate MergePatch and JSONPatch methods")
+	fmt.Println("  gorm-gen -types=clone,diff,apply            # Also generate ApplyDiff methods replaying diffs")
+	fmt.Println("  gorm-gen -types=clone,diff,merge            # Also generate three-way Merge methods reporting conflicts")
 	fmt.Println("  gorm-gen -package=./models                  # Generate for models directory")
 	fmt.Println("  gorm-gen -package=./models -output=./gen    # Generate to different output directory")
+	fmt.Println("  gorm-gen ./internal/...                     # Generate for every package under internal, in parallel")
+	fmt.Println("  gorm-gen -json-merge=deep                   # Merge nested @jsonb objects with jsonb_set")
+	fmt.Println("  gorm-gen -dialect=auto                      # Pick JSON merge SQL from the dialector at runtime")
+	fmt.Println("  gorm-gen -replace-json-arrays=false         # Merge array JSON columns instead of writing them as a whole")
+	fmt.Println("  gorm-gen -type=Service,Account              # Generate only for these structs and their nested structs")
+	fmt.Println("  gorm-gen -exclude='(Request|Response)$'     # Skip request and response DTOs")
+	fmt.Println("  gorm-gen -tracked                           # Generate only for @track structs and models with TableName")
+	fmt.Println("  gorm-gen -force                             # Overwrite clone.go and diff.go even if written by hand")
+	fmt.Println("  gorm-gen -diff-file=diff_gen.go             # Write diff methods to diff_gen.go instead of diff.go")
+	fmt.Println("  gorm-gen -layout=source                     # Write account_gen.go for account.go, and so on")
+	fmt.Println("  gorm-gen -layout=single                     # Write clone and diff methods to " + genfile.CombinedFileName)
+	fmt.Println("  gorm-gen -check                             # Fail with a diff if the generated files are out of date")
+	fmt.Println("  gorm-gen -json=encoding/json                # Encode JSON with encoding/json instead of sonic")
+	fmt.Println("  gorm-gen -config=gorm-gen.ci.yaml           # Read settings from this file instead of the closest one")
+	fmt.Println()
+	fmt.Println("Settings are read from the closest " + strings.Join(config.FileNames, " or ") + " from the package")
+	fmt.Println("directory up. Flags given on the command line override them.")
 	fmt.Println()
 	fmt.Println("go:generate usage:")
 	fmt.Println("  //go:generate gorm-gen")
@@ -127,6 +567,161 @@ func printUsage() {
 	fmt.Println("  //go:generate gorm-gen -package=./models")
 }
 
+// parseSelector builds the struct selector of the -include, -exclude, -type and -tracked flags
+func parseSelector(include, exclude, typeNames string, tracked bool) (selection.Selector, error) {
+	selector := selection.Selector{TrackedOnly: tracked}
+
+	if include != "" {
+		pattern, err := regexp.Compile(include)
+		if err != nil {
+			return selector, &flagError{"include", err}
+		}
+		selector.Include = pattern
+	}
+	if exclude != "" {
+		pattern, err := regexp.Compile(exclude)
+		if err != nil {
+			return selector, &flagError{"exclude", err}
+		}
+		selector.Exclude = pattern
+	}
+	for _, name := range strings.Split(typeNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			selector.Types = append(selector.Types, name)
+		}
+	}
+
+	return selector, nil
+}
+
+// collectFiles adds the code of a generator to the files of the layout, by output file
+// name: packageFile in the package layout
+func collectFiles(files map[string][]string, layout, packageFile string, generateCode func() (string, error), generateSourceFiles func() (map[string]string, error)) error {
+	if layout == layoutPackage || layout == layoutSingle {
+		code, err := generateCode()
+		if err != nil {
+			return err
+		}
+		if layout == layoutSingle {
+			packageFile = genfile.CombinedFileName
+		}
+		files[packageFile] = append(files[packageFile], code)
+		return nil
+	}
+
+	sourceFiles, err := generateSourceFiles()
+	if err != nil {
+		return err
+	}
+	for name, code := range sourceFiles {
+		files[name] = append(files[name], code)
+	}
+	return nil
+}
+
+// combineFiles combines the code generated for each output file, returning the code by
+// file name and the sorted names of the files with code
+func combineFiles(files map[string][]string) (map[string]string, []string, error) {
+	combined := make(map[string]string, len(files))
+	var names []string
+	for name, parts := range files {
+		code := parts[0]
+		if len(parts) > 1 {
+			var err error
+			if code, err = genfile.Combine(parts...); err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", name, err)
+			}
+		}
+		if code != "" {
+			combined[name] = code
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return combined, names, nil
+}
+
+// checkFiles compares the generated code with the files in dir, printing a unified diff
+// of each file that differs to w, and returns the names of those files. Files that differ
+// only in the generator version are up to date, and generated files in dir that are owned
+// by the run but no longer produced, such as those of another layout, are out of date.
+func checkFiles(w io.Writer, dir string, code map[string]string, names []string, owns func(name string) bool) ([]string, error) {
+	var stale []string
+	for _, name := range names {
+		filePath := filepath.Join(dir, name)
+		oldName := filePath
+
+		content, err := os.ReadFile(filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			oldName = "/dev/null"
+		} else if err != nil {
+			return nil, err
+		}
+
+		if oldName != "/dev/null" && genfile.SameCode(string(content), code[name]) {
+			continue
+		}
+		if diff := genfile.UnifiedDiff(oldName, filePath+" (generated)", string(content), code[name]); diff != "" {
+			fmt.Fprint(w, diff)
+			stale = append(stale, name)
+		}
+	}
+
+	generated, err := genfile.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range generated {
+		if _, ok := code[name]; ok || !owns(name) {
+			continue
+		}
+		filePath := filepath.Join(dir, name)
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprint(w, genfile.UnifiedDiff(filePath, "/dev/null", string(content), ""))
+		stale = append(stale, name)
+	}
+	return stale, nil
+}
+
+// ownsFile reports whether a generated file in the output directory belongs to runs with
+// these options. Files of the package layout belong to the runs generating their methods,
+// so clone and diff methods can be generated into one directory by runs with different
+// -types, while the files of the other layouts are left over from a change of layout.
+func (o *options) ownsFile(name string) bool {
+	switch {
+	case o.layout == layoutPackage && (name == o.cloneFile || name == o.diffFile):
+		return (name == o.cloneFile && o.generateClone) || (name == o.diffFile && o.generateDiff)
+	case name == o.cloneFile || name == o.diffFile:
+		return true
+	case name == genfile.CombinedFileName || strings.HasSuffix(name, "_gen.go"):
+		// Files of the single and source layouts, including the helpers file
+		return true
+	}
+	return false
+}
+
+// forceHint explains how to overwrite a file that was not generated by gorm-gen
+func forceHint(err error) string {
+	if errors.Is(err, genfile.ErrNotGenerated) {
+		return " (use -force to overwrite it)"
+	}
+	return ""
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if strings.TrimSpace(s) == item {
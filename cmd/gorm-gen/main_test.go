@@ -0,0 +1,177 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/config"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/genfile"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/workspace"
+)
+
+// runGenerator generates the package in dir with the command line args
+func runGenerator(t *testing.T, dir string, args ...string) *packageRun {
+	t.Helper()
+
+	f := newFlags()
+	if err := f.set.Parse(args); err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+	opts, err := buildOptions(f, &config.Config{})
+	if err != nil {
+		t.Fatalf("Error building options: %v", err)
+	}
+	opts.cloneStructs, opts.diffStructs = workspace.NewStructs(), workspace.NewStructs()
+
+	run := &packageRun{pkg: workspace.Package{Dir: dir}, outputDir: dir, opts: opts}
+	if err := run.parse(); err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	if err := run.generate(); err != nil {
+		t.Fatalf("Error generating: %v", err)
+	}
+	return run
+}
+
+// packageDir creates a directory for a package of the module, so that generated code can be
+// compiled with the dependencies of the module, and removes it when the test ends
+func packageDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp(".", "_generated")
+	if err != nil {
+		t.Fatalf("Error creating package directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	if dir, err = filepath.Abs(dir); err != nil {
+		t.Fatalf("Error resolving package directory: %v", err)
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// goCommand runs the go command in dir, failing the test with its output if it fails
+func goCommand(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+}
+
+func TestDiffOfUnchangedCloneIsEmpty(t *testing.T) {
+	dir := packageDir(t, map[string]string{
+		"profile.go": `package models
+
+import "time"
+
+type Status string
+
+type Profile struct {
+	ID       uint
+	Nickname *string
+	Age      *int
+	Status   *Status
+	SeenAt   *time.Time
+	Referrer *string
+}
+`,
+		"profile_test.go": `package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffOfClone(t *testing.T) {
+	nickname, age, status, seenAt := "ann", 42, Status("active"), time.Now()
+	profile := &Profile{ID: 1, Nickname: &nickname, Age: &age, Status: &status, SeenAt: &seenAt}
+
+	// Clones point to copies of the values, which are equal
+	if diff := profile.Diff(profile.Clone()); len(diff) != 0 {
+		t.Errorf("Expected no diff for an unchanged clone, got %v", diff)
+	}
+
+	clone := profile.Clone()
+	*clone.Nickname = "bob"
+	clone.Age = nil
+	referrer := "carol"
+	clone.Referrer = &referrer
+	diff := clone.Diff(profile)
+	if len(diff) != 3 || diff["Nickname"] != clone.Nickname || diff["Age"] != clone.Age || diff["Referrer"] != clone.Referrer {
+		t.Errorf("Expected Nickname, Age and Referrer in diff, got %v", diff)
+	}
+}
+`,
+	})
+
+	runGenerator(t, dir)
+	goCommand(t, dir, "test", ".")
+}
+
+func TestExcludedEmbeddedFieldCompiles(t *testing.T) {
+	dir := packageDir(t, map[string]string{
+		"account.go": `package models
+
+import "gorm.io/gorm"
+
+type Account struct {
+	gorm.Model ` + "`gormtrack:\"-\"`" + `
+	Name  string
+	Roles []string
+}
+`,
+	})
+
+	// The comments of the generated code name the excluded field, which does not need gorm
+	runGenerator(t, dir)
+	goCommand(t, dir, "vet", ".")
+
+	code, err := os.ReadFile(filepath.Join(dir, "clone.go"))
+	if err != nil {
+		t.Fatalf("Error reading clone.go: %v", err)
+	}
+	if !strings.Contains(string(code), "shared with the original: Model\n") {
+		t.Errorf("Expected the Clone method to name the excluded field, got:\n%s", code)
+	}
+}
+
+func TestCheckSeparateTypesRuns(t *testing.T) {
+	dir := t.TempDir()
+	source := "package models\n\ntype Account struct {\n\tID   int\n\tName string\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "account.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Error writing account.go: %v", err)
+	}
+
+	// Clone and diff methods generated into one directory by separate runs
+	runGenerator(t, dir, "-types=clone")
+	runGenerator(t, dir, "-types=diff")
+
+	for _, types := range []string{"clone", "diff"} {
+		if run := runGenerator(t, dir, "-types="+types, "-check"); len(run.stale) > 0 {
+			t.Errorf("Expected the files of -types=%s to be up to date, got %v:\n%s", types, run.stale, run.report.String())
+		}
+	}
+
+	// A file left over from the single layout is out of date for both runs
+	leftover := genfile.Header() + "package models\n"
+	if err := os.WriteFile(filepath.Join(dir, genfile.CombinedFileName), []byte(leftover), 0644); err != nil {
+		t.Fatalf("Error writing %s: %v", genfile.CombinedFileName, err)
+	}
+	for _, types := range []string{"clone", "diff"} {
+		run := runGenerator(t, dir, "-types="+types, "-check")
+		if strings.Join(run.stale, ",") != genfile.CombinedFileName {
+			t.Errorf("Expected only %s to be out of date with -types=%s, got %v", genfile.CombinedFileName, types, run.stale)
+		}
+	}
+}
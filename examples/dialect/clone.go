@@ -0,0 +1,78 @@
+// Code generated by gorm-gen. DO NOT EDIT.
+// gorm-gen version: (devel)
+
+package dialect
+
+// Clone creates a deep copy of the DeviceStatus struct
+func (original *DeviceStatus) Clone() *DeviceStatus {
+	if original == nil {
+		return nil
+	}
+	// Create new instance - all fields are simple types
+	clone := *original
+	return &clone
+}
+
+// Clone creates a deep copy of the DeviceSettings struct
+func (original *DeviceSettings) Clone() *DeviceSettings {
+	if original == nil {
+		return nil
+	}
+	// Create new instance and copy all simple fields
+	clone := *original
+
+	// Only handle JSONB fields that need deep cloning
+
+	clone.Status = *(&original.Status).Clone()
+
+	if original.Alarms != nil {
+		clone.Alarms = make([]string, len(original.Alarms))
+		copy(clone.Alarms, original.Alarms)
+	}
+
+	return &clone
+}
+
+// Clone creates a deep copy of the Device struct
+func (original *Device) Clone() *Device {
+	if original == nil {
+		return nil
+	}
+	// Create new instance and copy all simple fields
+	clone := *original
+
+	// Only handle JSONB fields that need deep cloning
+
+	if original.Settings != nil {
+		clone.Settings = original.Settings.Clone()
+	}
+
+	return &clone
+}
+
+// Clone creates a deep copy of the DeviceEvent struct
+func (original *DeviceEvent) Clone() *DeviceEvent {
+	if original == nil {
+		return nil
+	}
+	// Create new instance and copy all simple fields
+	clone := *original
+
+	// Only handle JSONB fields that need deep cloning
+
+	if original.AckedAt != nil {
+		value := *original.AckedAt
+		clone.AckedAt = &value
+	}
+
+	if original.Retries != nil {
+		value := *original.Retries
+		clone.Retries = &value
+	}
+
+	if original.Status != nil {
+		clone.Status = original.Status.Clone()
+	}
+
+	return &clone
+}
@@ -0,0 +1,507 @@
+package dialect
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/diffapply"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// namedDialector reports another dialect name on top of SQLite, so the SQL each
+// dialect gets can be inspected with an in-memory database
+type namedDialector struct {
+	gorm.Dialector
+	name string
+}
+
+func (d namedDialector) Name() string {
+	return d.name
+}
+
+func openDB(t *testing.T, dialector gorm.Dialector) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	return db
+}
+
+func changedDevice() (*Device, *Device) {
+	old := &Device{
+		ID:   1,
+		Name: "Kitchen",
+		Settings: &DeviceSettings{
+			Theme:  "dark",
+			Volume: 3,
+			Status: DeviceStatus{Online: true, Firmware: "1.0"},
+		},
+	}
+
+	device := old.Clone()
+	device.Settings.Volume = 5
+	device.Settings.Status.Firmware = "1.1"
+	return device, old
+}
+
+func TestJSONMergeSQLPerDialect(t *testing.T) {
+	testCases := map[string][]string{
+		"postgres": {
+			"jsonb_set(COALESCE(`settings`, '{}'::jsonb) || ?::jsonb, ?::text[], COALESCE(`settings` #> ?::text[], '{}'::jsonb) || ?::jsonb, true)",
+		},
+		"mysql": {
+			"JSON_MERGE_PATCH(COALESCE(`settings`, '{}'), ?)",
+		},
+		"sqlite": {
+			"json_patch(COALESCE(`settings`, '{}'), ?)",
+		},
+	}
+
+	for name, expected := range testCases {
+		t.Run(name, func(t *testing.T) {
+			db := openDB(t, namedDialector{Dialector: sqlite.Open("file::memory:"), name: name})
+
+			device, old := changedDevice()
+			stmt := db.Session(&gorm.Session{DryRun: true}).
+				Model(&Device{ID: device.ID}).Updates(device.Diff(old)).Statement
+
+			sql := stmt.SQL.String()
+			for _, fragment := range expected {
+				if !strings.Contains(sql, fragment) {
+					t.Errorf("Expected SQL to contain %q, got %s", fragment, sql)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONMergeExecutesOnSQLite(t *testing.T) {
+	db := openDB(t, sqlite.Open("file::memory:"))
+	if err := db.AutoMigrate(&Device{}); err != nil {
+		t.Fatalf("Error migrating: %v", err)
+	}
+
+	device, old := changedDevice()
+	if err := db.Create(old).Error; err != nil {
+		t.Fatalf("Error creating device: %v", err)
+	}
+
+	// A concurrent writer changes keys this instance does not touch
+	db.Exec("UPDATE devices SET settings = json_set(settings, '$.theme', 'light', '$.status.online', json('false')) WHERE id = ?", device.ID)
+
+	if err := db.Model(&Device{ID: device.ID}).Updates(device.Diff(old)).Error; err != nil {
+		t.Fatalf("Error updating device: %v", err)
+	}
+
+	var stored Device
+	db.First(&stored, device.ID)
+
+	expected := DeviceSettings{
+		Theme:  "light",
+		Volume: 5,
+		Status: DeviceStatus{Online: false, Firmware: "1.1"},
+	}
+	if stored.Settings == nil || !reflect.DeepEqual(*stored.Settings, expected) {
+		t.Errorf("Expected merged settings %+v, got %+v", expected, stored.Settings)
+	}
+}
+
+func TestJSONRemoval(t *testing.T) {
+	old := &Device{ID: 1, Settings: &DeviceSettings{Theme: "dark", Volume: 3}}
+	device := old.Clone()
+	device.Settings.Theme = "" // omitempty: removed from the JSON
+
+	t.Run("postgres", func(t *testing.T) {
+		db := openDB(t, namedDialector{Dialector: sqlite.Open("file::memory:"), name: "postgres"})
+
+		stmt := db.Session(&gorm.Session{DryRun: true}).
+			Model(&Device{ID: device.ID}).Updates(device.Diff(old)).Statement
+
+		if sql := stmt.SQL.String(); !strings.Contains(sql, "(COALESCE(`settings`, '{}'::jsonb)) - ?::text[]") {
+			t.Errorf("Expected SQL to remove the key, got %s", sql)
+		}
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		db := openDB(t, sqlite.Open("file::memory:"))
+		if err := db.AutoMigrate(&Device{}); err != nil {
+			t.Fatalf("Error migrating: %v", err)
+		}
+		db.Create(old)
+
+		if err := db.Model(&Device{ID: device.ID}).Updates(device.Diff(old)).Error; err != nil {
+			t.Fatalf("Error updating device: %v", err)
+		}
+
+		var settings string
+		db.Raw("SELECT settings FROM devices WHERE id = ?", device.ID).Scan(&settings)
+		if strings.Contains(settings, "theme") || !strings.Contains(settings, `"volume":3`) {
+			t.Errorf("Expected theme to be removed and volume kept, got %s", settings)
+		}
+	})
+}
+
+func TestChangesReportsJSONPaths(t *testing.T) {
+	device, old := changedDevice()
+	device.Name = "Living room"
+
+	changes := device.Changes(old)
+
+	expected := []changeset.FieldChange{
+		{Field: "Name", Column: "name", Old: "Kitchen", New: "Living room"},
+		{Field: "Settings.Volume", Column: "settings", Path: []string{"volume"}, Old: 3, New: 5},
+		{Field: "Settings.Status.Firmware", Column: "settings", Path: []string{"status", "firmware"}, Old: "1.0", New: "1.1"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %+v, got %+v", expected, changes)
+	}
+
+	if changes := old.Changes(old.Clone()); changes != nil {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+}
+
+func TestChangesOfNewJSONColumn(t *testing.T) {
+	device, old := changedDevice()
+	old.Settings = nil
+
+	changes := device.Changes(old)
+	if len(changes) != 1 || changes[0].Field != "Settings" || changes[0].Column != "settings" {
+		t.Fatalf("Expected a single change of the whole Settings column, got %+v", changes)
+	}
+	if changes[0].Old != (*DeviceSettings)(nil) || changes[0].New != device.Settings {
+		t.Errorf("Expected old and new Settings values, got %+v", changes[0])
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	device, old := changedDevice()
+	device.Name = "Living room"
+	device.Settings.Theme = ""
+
+	patch, err := device.MergePatch(old)
+	if err != nil {
+		t.Fatalf("Error building merge patch: %v", err)
+	}
+
+	expected := `{"Name":"Living room","Settings":{"status":{"firmware":"1.1"},"theme":null,"volume":5}}`
+	if string(patch) != expected {
+		t.Errorf("Expected merge patch %s, got %s", expected, patch)
+	}
+
+	if patch, _ := old.MergePatch(old.Clone()); string(patch) != "{}" {
+		t.Errorf("Expected empty merge patch, got %s", patch)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	device, old := changedDevice()
+	device.Settings.Theme = ""
+
+	patch, err := device.JSONPatch(old)
+	if err != nil {
+		t.Fatalf("Error building JSON patch: %v", err)
+	}
+
+	expected := `[{"op":"remove","path":"/Settings/theme"},` +
+		`{"op":"replace","path":"/Settings/volume","value":5},` +
+		`{"op":"replace","path":"/Settings/status/firmware","value":"1.1"}]`
+	if string(patch) != expected {
+		t.Errorf("Expected JSON patch %s, got %s", expected, patch)
+	}
+
+	if patch, _ := old.JSONPatch(old.Clone()); string(patch) != "[]" {
+		t.Errorf("Expected empty JSON patch, got %s", patch)
+	}
+}
+
+func TestJSONPatchDiffsArraysByIndex(t *testing.T) {
+	old := &DeviceSettings{Alarms: []string{"07:00", "08:00", "09:00"}}
+
+	tests := []struct {
+		name     string
+		alarms   []string
+		expected string
+	}{
+		{
+			name:   "replace and remove",
+			alarms: []string{"07:00", "08:30"},
+			expected: `[{"op":"replace","path":"/alarms/1","value":"08:30"},` +
+				`{"op":"remove","path":"/alarms/2"}]`,
+		},
+		{
+			name:     "append",
+			alarms:   []string{"07:00", "08:00", "09:00", "10:00"},
+			expected: `[{"op":"add","path":"/alarms/-","value":"10:00"}]`,
+		},
+		{
+			name:     "clear",
+			alarms:   nil,
+			expected: `[{"op":"remove","path":"/alarms"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := old.Clone()
+			settings.Alarms = tt.alarms
+
+			patch, err := settings.JSONPatch(old)
+			if err != nil {
+				t.Fatalf("Error building JSON patch: %v", err)
+			}
+			if string(patch) != tt.expected {
+				t.Errorf("Expected JSON patch %s, got %s", tt.expected, patch)
+			}
+		})
+	}
+
+	// Merge patches replace arrays as a whole
+	settings := old.Clone()
+	settings.Alarms = []string{"07:00"}
+	if patch, _ := settings.MergePatch(old); string(patch) != `{"alarms":["07:00"]}` {
+		t.Errorf("Expected array to be replaced, got %s", patch)
+	}
+}
+
+func TestJSONPatchOfNewJSONColumn(t *testing.T) {
+	device, old := changedDevice()
+	old.Settings = nil
+
+	patch, err := device.JSONPatch(old)
+	if err != nil {
+		t.Fatalf("Error building JSON patch: %v", err)
+	}
+
+	expected := `[{"op":"replace","path":"/Settings","value":{"theme":"dark","volume":5,"status":{"online":true,"firmware":"1.1"}}}]`
+	if string(patch) != expected {
+		t.Errorf("Expected JSON patch %s, got %s", expected, patch)
+	}
+}
+
+// roundTrip returns diff as decoded from its JSON encoding, as received from a queue
+func roundTrip(t *testing.T, diff map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	message, err := json.Marshal(diff)
+	if err != nil {
+		t.Fatalf("Error encoding diff: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(message, &decoded); err != nil {
+		t.Fatalf("Error decoding diff: %v", err)
+	}
+	return decoded
+}
+
+func TestApplyDiffFromJSON(t *testing.T) {
+	old := &DeviceEvent{
+		ID:         uuid.New(),
+		DeviceID:   1,
+		Kind:       "offline",
+		OccurredAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	acked := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	retries := 3
+	event := old.Clone()
+	event.ID = uuid.New()
+	event.DeviceID = 2
+	event.Kind = "online"
+	event.OccurredAt = event.OccurredAt.Add(time.Minute)
+	event.AckedAt = &acked
+	event.Retries = &retries
+
+	applied := old.Clone()
+	if err := applied.ApplyDiff(roundTrip(t, event.Diff(old))); err != nil {
+		t.Fatalf("Error applying diff: %v", err)
+	}
+	if !reflect.DeepEqual(applied, event) {
+		t.Errorf("Expected %+v, got %+v", event, applied)
+	}
+
+	// Diffs applied without going through JSON keep their typed values
+	reverted := event.Clone()
+	if err := reverted.ApplyDiff(old.Diff(event)); err != nil {
+		t.Fatalf("Error applying diff: %v", err)
+	}
+	if !reflect.DeepEqual(reverted, old) {
+		t.Errorf("Expected %+v, got %+v", old, reverted)
+	}
+}
+
+func TestApplyNestedDiff(t *testing.T) {
+	device, old := changedDevice()
+	device.Settings.Theme = ""
+
+	settings := old.Settings.Clone()
+	if err := settings.ApplyDiff(roundTrip(t, device.Settings.Diff(old.Settings))); err != nil {
+		t.Fatalf("Error applying diff: %v", err)
+	}
+	if !reflect.DeepEqual(settings, device.Settings) {
+		t.Errorf("Expected %+v, got %+v", device.Settings, settings)
+	}
+
+	// Nested diffs of nil pointers apply to a new struct
+	event := &DeviceEvent{}
+	if err := event.ApplyDiff(map[string]interface{}{"Status": map[string]interface{}{"online": true}}); err != nil {
+		t.Fatalf("Error applying diff: %v", err)
+	}
+	if event.Status == nil || !event.Status.Online {
+		t.Errorf("Expected status to be allocated and set, got %+v", event.Status)
+	}
+}
+
+func TestApplyDiffErrors(t *testing.T) {
+	settings := &DeviceSettings{}
+
+	var unknown *diffapply.UnknownKeyError
+	err := settings.ApplyDiff(map[string]interface{}{"status": map[string]interface{}{"color": "red"}})
+	if !errors.As(err, &unknown) || unknown.Struct != "DeviceStatus" || unknown.PathString() != "status.color" {
+		t.Errorf("Expected unknown key status.color of DeviceStatus, got %v", err)
+	}
+
+	var mismatch *diffapply.TypeMismatchError
+	for _, value := range []interface{}{"loud", 2.5, true} {
+		err := settings.ApplyDiff(map[string]interface{}{"volume": value})
+		if !errors.As(err, &mismatch) || mismatch.PathString() != "volume" || mismatch.Type != "int" {
+			t.Errorf("Expected type mismatch of volume for %v, got %v", value, err)
+		}
+	}
+
+	// JSON merge expressions of JSON columns cannot be replayed, before or after JSON
+	device, old := changedDevice()
+	for _, diff := range []map[string]interface{}{device.Diff(old), roundTrip(t, device.Diff(old))} {
+		err = old.Clone().ApplyDiff(diff)
+		if !errors.As(err, &mismatch) || mismatch.PathString() != "Settings" || !strings.Contains(err.Error(), "ApplyMergePatch") {
+			t.Errorf("Expected type mismatch of Settings pointing to ApplyMergePatch, got %v", err)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	device, old := changedDevice()
+	device.Name = "Living room"
+	device.Settings.Theme = ""
+
+	// Merge patches are plain JSON, so they replay JSON columns that diffs merge in SQL
+	patch, err := device.MergePatch(old)
+	if err != nil {
+		t.Fatalf("Error building merge patch: %v", err)
+	}
+	applied := old.Clone()
+	if err := applied.ApplyMergePatch(patch); err != nil {
+		t.Fatalf("Error applying merge patch: %v", err)
+	}
+	if !reflect.DeepEqual(applied, device) {
+		t.Errorf("Expected %+v, got %+v", device, applied)
+	}
+
+	// A JSON column that was nil is patched as a whole, and null clears it again
+	old.Settings = nil
+	patch, _ = device.MergePatch(old)
+	applied = old.Clone()
+	if err := applied.ApplyMergePatch(patch); err != nil || !reflect.DeepEqual(applied, device) {
+		t.Errorf("Expected %+v, got %+v (%v)", device, applied, err)
+	}
+	if err := applied.ApplyMergePatch([]byte(`{"Settings":null}`)); err != nil || applied.Settings != nil {
+		t.Errorf("Expected null to clear Settings, got %+v (%v)", applied.Settings, err)
+	}
+
+	var unknown *diffapply.UnknownKeyError
+	err = applied.ApplyMergePatch([]byte(`{"Settings":{"status":{"color":"red"}}}`))
+	if !errors.As(err, &unknown) || unknown.PathString() != "Settings.status.color" {
+		t.Errorf("Expected unknown key Settings.status.color, got %v", err)
+	}
+	if err := applied.ApplyMergePatch([]byte(`[]`)); err == nil {
+		t.Error("Expected an error for a merge patch that is not an object")
+	}
+}
+
+func TestMergeConcurrentEdits(t *testing.T) {
+	base := &Device{
+		ID:   1,
+		Name: "Kitchen",
+		Settings: &DeviceSettings{
+			Theme:  "dark",
+			Volume: 3,
+			Status: DeviceStatus{Online: true, Firmware: "1.0"},
+		},
+	}
+
+	ours := base.Clone()
+	ours.Name = "Living room"
+	ours.Settings.Volume = 5
+
+	theirs := base.Clone()
+	theirs.Settings.Theme = "light"
+	theirs.Settings.Status.Firmware = "1.1"
+
+	merged, conflicts := ours.Merge(base, theirs)
+	if len(conflicts) != 0 {
+		t.Fatalf("Expected no conflicts, got %+v", conflicts)
+	}
+
+	expected := &Device{
+		ID:   1,
+		Name: "Living room",
+		Settings: &DeviceSettings{
+			Theme:  "light",
+			Volume: 5,
+			Status: DeviceStatus{Online: true, Firmware: "1.1"},
+		},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected merged device %+v, got %+v", expected, merged)
+	}
+	if !reflect.DeepEqual(base.Settings.Status, DeviceStatus{Online: true, Firmware: "1.0"}) {
+		t.Errorf("Expected base to be left unchanged, got %+v", base.Settings)
+	}
+}
+
+func TestMergeReportsConflicts(t *testing.T) {
+	base, _ := changedDevice()
+
+	ours := base.Clone()
+	ours.Name = "Living room"
+	ours.Settings.Status.Firmware = "2.0"
+	ours.Settings.Volume = 7
+
+	theirs := base.Clone()
+	theirs.Name = "Living room"
+	theirs.Settings.Status.Firmware = "2.1"
+	theirs.Settings.Volume = 8
+
+	merged, conflicts := ours.Merge(base, theirs)
+
+	// Changing a field to the same value on both sides is no conflict
+	expected := []changeset.Conflict{
+		{Field: "Settings.Volume", Column: "settings", Path: []string{"volume"}, Base: 5, Ours: 7, Theirs: 8},
+		{Field: "Settings.Status.Firmware", Column: "settings", Path: []string{"status", "firmware"}, Base: "1.1", Ours: "2.0", Theirs: "2.1"},
+	}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("Expected conflicts %+v, got %+v", expected, conflicts)
+	}
+
+	// Conflicting fields keep our value
+	if merged.Name != "Living room" || merged.Settings.Volume != 7 || merged.Settings.Status.Firmware != "2.0" {
+		t.Errorf("Expected conflicting fields to keep our values, got %+v", merged.Settings)
+	}
+
+	// A JSON column set on one side and cleared on the other conflicts as a whole
+	theirs.Settings = nil
+	_, conflicts = ours.Merge(base, theirs)
+	if len(conflicts) != 1 || conflicts[0].Field != "Settings" || conflicts[0].Column != "settings" {
+		t.Errorf("Expected a single conflict of the whole Settings column, got %+v", conflicts)
+	}
+}
@@ -0,0 +1,1452 @@
+// Code generated by gorm-gen. DO NOT EDIT.
+// gorm-gen version: (devel)
+
+package dialect
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/diffapply"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// isEmptyJSON checks if a JSON string represents an empty object or array
+func isEmptyJSON(jsonStr string) bool {
+	trimmed := strings.TrimSpace(jsonStr)
+	return trimmed == "{}" || trimmed == "[]" || trimmed == "null"
+}
+
+// jsonbPath formats path as a Postgres text[] literal
+func jsonbPath(path []string) string {
+	quoted := make([]string, len(path))
+	for i, key := range path {
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// jsonbDeepMerge builds an expression that merges patch into a jsonb column.
+// Nested objects in patch are written with jsonb_set at their full path, so keys
+// that did not change are preserved at every level, not only at the top level.
+func jsonbDeepMerge(column string, patch map[string]interface{}) (clause.Expr, error) {
+	sql, vars, err := jsonbMergeSQL(clause.Column{Name: column}, nil, patch)
+	if err != nil {
+		return clause.Expr{}, err
+	}
+	return gorm.Expr(sql, vars...), nil
+}
+
+// jsonbMergeSQL builds the merge of patch into the object found at path inside column
+func jsonbMergeSQL(column clause.Column, path []string, patch map[string]interface{}) (string, []interface{}, error) {
+	var sql string
+	var vars []interface{}
+	if len(path) == 0 {
+		sql = "COALESCE(?, '{}'::jsonb)"
+		vars = []interface{}{column}
+	} else {
+		sql = "COALESCE(? #> ?::text[], '{}'::jsonb)"
+		vars = []interface{}{column, jsonbPath(path)}
+	}
+
+	// Plain values are merged in one step, nested objects are merged recursively
+	// and removed keys (nil values) are deleted
+	leaves := make(map[string]interface{})
+	var nested, removed []string
+	for key, value := range patch {
+		switch value.(type) {
+		case nil:
+			removed = append(removed, key)
+		case map[string]interface{}:
+			nested = append(nested, key)
+		default:
+			leaves[key] = value
+		}
+	}
+
+	if len(leaves) > 0 {
+		jsonValue, err := sonic.Marshal(leaves)
+		if err != nil {
+			return "", nil, err
+		}
+		sql += " || ?::jsonb"
+		vars = append(vars, string(jsonValue))
+	}
+
+	if len(removed) > 0 {
+		sort.Strings(removed)
+		sql = "(" + sql + ") - ?::text[]"
+		vars = append(vars, jsonbPath(removed))
+	}
+
+	sort.Strings(nested)
+	for _, key := range nested {
+		childPath := append(append([]string{}, path...), key)
+		childSQL, childVars, err := jsonbMergeSQL(column, childPath, patch[key].(map[string]interface{}))
+		if err != nil {
+			return "", nil, err
+		}
+		sql = "jsonb_set(" + sql + ", ?::text[], " + childSQL + ", true)"
+		vars = append(append(vars, jsonbPath([]string{key})), childVars...)
+	}
+
+	return sql, vars, nil
+}
+
+// jsonMergeExpr merges a JSON value into a column. The SQL is chosen when the statement
+// is built, from the name of the dialector it is built for.
+type jsonMergeExpr struct {
+	Column   clause.Column
+	Patch    string            // Value to merge, marshaled as JSON
+	Postgres clause.Expression // Merge used on Postgres, defaults to a shallow ||
+}
+
+// Build implements clause.Expression
+func (e jsonMergeExpr) Build(builder clause.Builder) {
+	var dialect string
+	if stmt, ok := builder.(*gorm.Statement); ok && stmt.DB != nil && stmt.Dialector != nil {
+		dialect = stmt.Dialector.Name()
+	}
+
+	switch dialect {
+	case "mysql":
+		gorm.Expr("JSON_MERGE_PATCH(COALESCE(?, '{}'), ?)", e.Column, e.Patch).Build(builder)
+	case "sqlite":
+		gorm.Expr("json_patch(COALESCE(?, '{}'), ?)", e.Column, e.Patch).Build(builder)
+	default:
+		if e.Postgres != nil {
+			e.Postgres.Build(builder)
+			return
+		}
+		gorm.Expr("? || ?", e.Column, e.Patch).Build(builder)
+	}
+}
+
+// jsonDeepMerge builds a jsonMergeExpr for patch whose Postgres form is a jsonbDeepMerge.
+// MySQL and SQLite merge patches are deep already.
+func jsonDeepMerge(column string, patch map[string]interface{}) (clause.Expression, error) {
+	jsonValue, err := sonic.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	postgres, err := jsonbDeepMerge(column, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonMergeExpr{Column: clause.Column{Name: column}, Patch: string(jsonValue), Postgres: postgres}, nil
+}
+
+// Diff compares this DeviceStatus instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *DeviceStatus) Diff(old *DeviceStatus) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare Online
+
+	// Simple type comparison
+	if new.Online != old.Online {
+		diff["online"] = new.Online
+	}
+
+	if _, changed := diff["online"]; changed && !new.Online {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["online"] = nil
+	}
+
+	// Compare Firmware
+
+	// Simple type comparison
+	if new.Firmware != old.Firmware {
+		diff["firmware"] = new.Firmware
+	}
+
+	if _, changed := diff["firmware"]; changed && new.Firmware == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["firmware"] = nil
+	}
+
+	return diff
+}
+
+// Changes compares this DeviceStatus instance (new) with another (old) and returns the changed
+// fields with both their old and new values. Changes inside nested @jsonb structs are
+// reported per key, with their full JSON path.
+// Returns nil if either pointer is nil or nothing changed.
+func (new *DeviceStatus) Changes(old *DeviceStatus) []changeset.FieldChange {
+	// The fields reported by Diff are the changed ones
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var changes []changeset.FieldChange
+
+	if _, changed := diff["online"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Online", Path: []string{"online"}, Old: old.Online, New: new.Online})
+	}
+	if _, changed := diff["firmware"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Firmware", Path: []string{"firmware"}, Old: old.Firmware, New: new.Firmware})
+	}
+
+	return changes
+}
+
+// patchOps returns the changes turning old into new as operations on the JSON document of
+// DeviceStatus, addressed by the JSON keys of its fields.
+func (new *DeviceStatus) patchOps(old *DeviceStatus) []patchOp {
+	// The fields reported by Diff are the changed ones
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var ops []patchOp
+
+	if _, changed := diff["online"]; changed {
+		ops = append(ops, patchOp{Path: []string{"online"}, Old: old.Online, Value: new.Online, Removed: !new.Online, Added: !old.Online})
+	}
+	if _, changed := diff["firmware"]; changed {
+		ops = append(ops, patchOp{Path: []string{"firmware"}, Old: old.Firmware, Value: new.Firmware, Removed: new.Firmware == "", Added: old.Firmware == ""})
+	}
+
+	return ops
+}
+
+// MergePatch returns the RFC 7386 JSON merge patch turning old into new. Removed keys
+// are null and arrays are replaced as a whole.
+func (new *DeviceStatus) MergePatch(old *DeviceStatus) ([]byte, error) {
+	return mergePatch(new.patchOps(old))
+}
+
+// JSONPatch returns the RFC 6902 JSON patch turning old into new. Removed keys become
+// remove operations and arrays are diffed by index, unless tagged diff:"replace".
+func (new *DeviceStatus) JSONPatch(old *DeviceStatus) ([]byte, error) {
+	return jsonPatch(new.patchOps(old))
+}
+
+// ApplyDiff sets the fields of this DeviceStatus instance from a diff returned by Diff, the
+// inverse of Diff. Values decoded from JSON are converted back to the field types, and
+// nested struct diffs are applied field by field.
+// Returns a *diffapply.UnknownKeyError for keys matching no field and a
+// *diffapply.TypeMismatchError for values that cannot be converted.
+func (m *DeviceStatus) ApplyDiff(d map[string]interface{}) error {
+	for key, value := range d {
+		switch key {
+		case "online":
+			if err := diffapply.Bool(key, value, &m.Online); err != nil {
+				return err
+			}
+		case "firmware":
+			if err := diffapply.String(key, value, &m.Firmware); err != nil {
+				return err
+			}
+		default:
+			return &diffapply.UnknownKeyError{Struct: "DeviceStatus", Path: []string{key}}
+		}
+	}
+	return nil
+}
+
+// ApplyMergePatch sets the fields of this DeviceStatus instance from an RFC 7386 JSON merge
+// patch returned by MergePatch, the inverse of MergePatch. Unlike the diffs of JSON
+// columns, which are SQL expressions, merge patches are plain JSON and can be replayed.
+// Keys are the JSON keys of the fields, null clears a field, nested structs are patched
+// field by field and other values replace the field as a whole.
+// Returns the errors of ApplyDiff.
+func (m *DeviceStatus) ApplyMergePatch(patch []byte) error {
+	document, err := diffapply.ParseMergePatch(patch)
+	if err != nil {
+		return err
+	}
+	return m.applyMergePatch(document)
+}
+
+// applyMergePatch sets the fields of this DeviceStatus instance from a decoded merge patch
+func (m *DeviceStatus) applyMergePatch(patch map[string]interface{}) error {
+	for key, value := range patch {
+		switch key {
+		case "online":
+			if err := diffapply.Bool(key, value, &m.Online); err != nil {
+				return err
+			}
+		case "firmware":
+			if err := diffapply.String(key, value, &m.Firmware); err != nil {
+				return err
+			}
+		default:
+			return &diffapply.UnknownKeyError{Struct: "DeviceStatus", Path: []string{key}}
+		}
+	}
+	return nil
+}
+
+// Merge performs a three-way merge of this DeviceStatus instance (ours) and theirs, both edited
+// from base. Fields changed on one side only take the value of that side. Fields changed
+// on both sides to different values are reported as conflicts and keep our value.
+// Nested @jsonb structs are merged key by key.
+// The merged struct shares slices, maps and pointers with the merged values.
+// Returns nil if any pointer is nil.
+func (ours *DeviceStatus) Merge(base, theirs *DeviceStatus) (*DeviceStatus, []changeset.Conflict) {
+	if ours == nil || base == nil || theirs == nil {
+		return nil, nil
+	}
+
+	merged := *base
+	oursDiff := ours.Diff(base)
+	theirsDiff := theirs.Diff(base)
+	if len(oursDiff) == 0 && len(theirsDiff) == 0 {
+		return &merged, nil
+	}
+
+	var conflicts []changeset.Conflict
+
+	// Merge Online
+	if _, changed := oursDiff["online"]; !changed {
+		if _, changed := theirsDiff["online"]; changed {
+			merged.Online = theirs.Online
+		}
+	} else if _, changed := theirsDiff["online"]; !changed {
+		merged.Online = ours.Online
+	} else {
+		merged.Online = ours.Online
+		if _, differ := ours.Diff(theirs)["online"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Online", Path: []string{"online"}, Base: base.Online, Ours: ours.Online, Theirs: theirs.Online})
+		}
+	}
+
+	// Merge Firmware
+	if _, changed := oursDiff["firmware"]; !changed {
+		if _, changed := theirsDiff["firmware"]; changed {
+			merged.Firmware = theirs.Firmware
+		}
+	} else if _, changed := theirsDiff["firmware"]; !changed {
+		merged.Firmware = ours.Firmware
+	} else {
+		merged.Firmware = ours.Firmware
+		if _, differ := ours.Diff(theirs)["firmware"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Firmware", Path: []string{"firmware"}, Base: base.Firmware, Ours: ours.Firmware, Theirs: theirs.Firmware})
+		}
+	}
+
+	return &merged, conflicts
+}
+
+// Diff compares this DeviceSettings instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *DeviceSettings) Diff(old *DeviceSettings) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare Theme
+
+	// Simple type comparison
+	if new.Theme != old.Theme {
+		diff["theme"] = new.Theme
+	}
+
+	if _, changed := diff["theme"]; changed && new.Theme == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["theme"] = nil
+	}
+
+	// Compare Volume
+
+	// Simple type comparison
+	if new.Volume != old.Volume {
+		diff["volume"] = new.Volume
+	}
+
+	if _, changed := diff["volume"]; changed && new.Volume == 0 {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["volume"] = nil
+	}
+
+	// Compare Status
+
+	// Struct type comparison - call Diff method directly
+	nestedDiff := new.Status.Diff(&old.Status)
+	if len(nestedDiff) > 0 {
+		diff["status"] = nestedDiff
+	}
+
+	// Compare Alarms
+
+	// Complex type comparison (slice, map, interface, etc.)
+	if !reflect.DeepEqual(new.Alarms, old.Alarms) {
+		diff["alarms"] = new.Alarms
+	}
+
+	if _, changed := diff["alarms"]; changed && len(new.Alarms) == 0 {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["alarms"] = nil
+	}
+
+	return diff
+}
+
+// Changes compares this DeviceSettings instance (new) with another (old) and returns the changed
+// fields with both their old and new values. Changes inside nested @jsonb structs are
+// reported per key, with their full JSON path.
+// Returns nil if either pointer is nil or nothing changed.
+func (new *DeviceSettings) Changes(old *DeviceSettings) []changeset.FieldChange {
+	// The fields reported by Diff are the changed ones
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var changes []changeset.FieldChange
+
+	if _, changed := diff["theme"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Theme", Path: []string{"theme"}, Old: old.Theme, New: new.Theme})
+	}
+	if _, changed := diff["volume"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Volume", Path: []string{"volume"}, Old: old.Volume, New: new.Volume})
+	}
+	if _, changed := diff["status"]; changed {
+		for _, change := range new.Status.Changes(&old.Status) {
+			changes = append(changes, change.Nest("Status", "", "status"))
+		}
+	}
+	if _, changed := diff["alarms"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Alarms", Path: []string{"alarms"}, Old: old.Alarms, New: new.Alarms})
+	}
+
+	return changes
+}
+
+// patchOps returns the changes turning old into new as operations on the JSON document of
+// DeviceSettings, addressed by the JSON keys of its fields.
+func (new *DeviceSettings) patchOps(old *DeviceSettings) []patchOp {
+	// The fields reported by Diff are the changed ones
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var ops []patchOp
+
+	if _, changed := diff["theme"]; changed {
+		ops = append(ops, patchOp{Path: []string{"theme"}, Old: old.Theme, Value: new.Theme, Removed: new.Theme == "", Added: old.Theme == ""})
+	}
+	if _, changed := diff["volume"]; changed {
+		ops = append(ops, patchOp{Path: []string{"volume"}, Old: old.Volume, Value: new.Volume, Removed: new.Volume == 0, Added: old.Volume == 0})
+	}
+	if _, changed := diff["status"]; changed {
+		for _, op := range new.Status.patchOps(&old.Status) {
+			ops = append(ops, op.nest("status"))
+		}
+	}
+	if _, changed := diff["alarms"]; changed {
+		ops = append(ops, patchOp{Path: []string{"alarms"}, Old: old.Alarms, Value: new.Alarms, Removed: len(new.Alarms) == 0, Added: len(old.Alarms) == 0, Array: true})
+	}
+
+	return ops
+}
+
+// MergePatch returns the RFC 7386 JSON merge patch turning old into new. Removed keys
+// are null and arrays are replaced as a whole.
+func (new *DeviceSettings) MergePatch(old *DeviceSettings) ([]byte, error) {
+	return mergePatch(new.patchOps(old))
+}
+
+// JSONPatch returns the RFC 6902 JSON patch turning old into new. Removed keys become
+// remove operations and arrays are diffed by index, unless tagged diff:"replace".
+func (new *DeviceSettings) JSONPatch(old *DeviceSettings) ([]byte, error) {
+	return jsonPatch(new.patchOps(old))
+}
+
+// ApplyDiff sets the fields of this DeviceSettings instance from a diff returned by Diff, the
+// inverse of Diff. Values decoded from JSON are converted back to the field types, and
+// nested struct diffs are applied field by field.
+// Returns a *diffapply.UnknownKeyError for keys matching no field and a
+// *diffapply.TypeMismatchError for values that cannot be converted.
+func (m *DeviceSettings) ApplyDiff(d map[string]interface{}) error {
+	for key, value := range d {
+		switch key {
+		case "theme":
+			if err := diffapply.String(key, value, &m.Theme); err != nil {
+				return err
+			}
+		case "volume":
+			if err := diffapply.Number(key, value, &m.Volume); err != nil {
+				return err
+			}
+		case "status":
+			if nested, ok := value.(map[string]interface{}); ok && !diffapply.IsExpression(value) {
+				if err := m.Status.ApplyDiff(nested); err != nil {
+					return diffapply.Nest(err, key)
+				}
+			} else if err := diffapply.Decode(key, value, &m.Status); err != nil {
+				return err
+			}
+		case "alarms":
+			if err := diffapply.Decode(key, value, &m.Alarms); err != nil {
+				return err
+			}
+		default:
+			return &diffapply.UnknownKeyError{Struct: "DeviceSettings", Path: []string{key}}
+		}
+	}
+	return nil
+}
+
+// ApplyMergePatch sets the fields of this DeviceSettings instance from an RFC 7386 JSON merge
+// patch returned by MergePatch, the inverse of MergePatch. Unlike the diffs of JSON
+// columns, which are SQL expressions, merge patches are plain JSON and can be replayed.
+// Keys are the JSON keys of the fields, null clears a field, nested structs are patched
+// field by field and other values replace the field as a whole.
+// Returns the errors of ApplyDiff.
+func (m *DeviceSettings) ApplyMergePatch(patch []byte) error {
+	document, err := diffapply.ParseMergePatch(patch)
+	if err != nil {
+		return err
+	}
+	return m.applyMergePatch(document)
+}
+
+// applyMergePatch sets the fields of this DeviceSettings instance from a decoded merge patch
+func (m *DeviceSettings) applyMergePatch(patch map[string]interface{}) error {
+	for key, value := range patch {
+		switch key {
+		case "theme":
+			if err := diffapply.String(key, value, &m.Theme); err != nil {
+				return err
+			}
+		case "volume":
+			if err := diffapply.Number(key, value, &m.Volume); err != nil {
+				return err
+			}
+		case "status":
+			if nested, ok := value.(map[string]interface{}); ok {
+				if err := m.Status.applyMergePatch(nested); err != nil {
+					return diffapply.Nest(err, key)
+				}
+			} else if err := diffapply.Decode(key, value, &m.Status); err != nil {
+				return err
+			}
+		case "alarms":
+			if err := diffapply.Decode(key, value, &m.Alarms); err != nil {
+				return err
+			}
+		default:
+			return &diffapply.UnknownKeyError{Struct: "DeviceSettings", Path: []string{key}}
+		}
+	}
+	return nil
+}
+
+// Merge performs a three-way merge of this DeviceSettings instance (ours) and theirs, both edited
+// from base. Fields changed on one side only take the value of that side. Fields changed
+// on both sides to different values are reported as conflicts and keep our value.
+// Nested @jsonb structs are merged key by key.
+// The merged struct shares slices, maps and pointers with the merged values.
+// Returns nil if any pointer is nil.
+func (ours *DeviceSettings) Merge(base, theirs *DeviceSettings) (*DeviceSettings, []changeset.Conflict) {
+	if ours == nil || base == nil || theirs == nil {
+		return nil, nil
+	}
+
+	merged := *base
+	oursDiff := ours.Diff(base)
+	theirsDiff := theirs.Diff(base)
+	if len(oursDiff) == 0 && len(theirsDiff) == 0 {
+		return &merged, nil
+	}
+
+	var conflicts []changeset.Conflict
+
+	// Merge Theme
+	if _, changed := oursDiff["theme"]; !changed {
+		if _, changed := theirsDiff["theme"]; changed {
+			merged.Theme = theirs.Theme
+		}
+	} else if _, changed := theirsDiff["theme"]; !changed {
+		merged.Theme = ours.Theme
+	} else {
+		merged.Theme = ours.Theme
+		if _, differ := ours.Diff(theirs)["theme"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Theme", Path: []string{"theme"}, Base: base.Theme, Ours: ours.Theme, Theirs: theirs.Theme})
+		}
+	}
+
+	// Merge Volume
+	if _, changed := oursDiff["volume"]; !changed {
+		if _, changed := theirsDiff["volume"]; changed {
+			merged.Volume = theirs.Volume
+		}
+	} else if _, changed := theirsDiff["volume"]; !changed {
+		merged.Volume = ours.Volume
+	} else {
+		merged.Volume = ours.Volume
+		if _, differ := ours.Diff(theirs)["volume"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Volume", Path: []string{"volume"}, Base: base.Volume, Ours: ours.Volume, Theirs: theirs.Volume})
+		}
+	}
+
+	// Merge Status
+	if _, changed := oursDiff["status"]; !changed {
+		if _, changed := theirsDiff["status"]; changed {
+			merged.Status = theirs.Status
+		}
+	} else if _, changed := theirsDiff["status"]; !changed {
+		merged.Status = ours.Status
+	} else {
+		nestedMerged, nestedConflicts := ours.Status.Merge(&base.Status, &theirs.Status)
+		merged.Status = *nestedMerged
+		for _, conflict := range nestedConflicts {
+			conflicts = append(conflicts, conflict.Nest("Status", "", "status"))
+		}
+	}
+
+	// Merge Alarms
+	if _, changed := oursDiff["alarms"]; !changed {
+		if _, changed := theirsDiff["alarms"]; changed {
+			merged.Alarms = theirs.Alarms
+		}
+	} else if _, changed := theirsDiff["alarms"]; !changed {
+		merged.Alarms = ours.Alarms
+	} else {
+		merged.Alarms = ours.Alarms
+		if _, differ := ours.Diff(theirs)["alarms"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Alarms", Path: []string{"alarms"}, Base: base.Alarms, Ours: ours.Alarms, Theirs: theirs.Alarms})
+		}
+	}
+
+	return &merged, conflicts
+}
+
+// Diff compares this Device instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *Device) Diff(old *Device) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare ID
+
+	// Simple type comparison
+	if new.ID != old.ID {
+		diff["ID"] = new.ID
+	}
+
+	// Compare Name
+
+	// Simple type comparison
+	if new.Name != old.Name {
+		diff["Name"] = new.Name
+	}
+
+	// Compare Settings
+
+	// JSON field comparison - handle both datatypes.JSON and struct types with jsonb storage
+
+	// JSON field comparison - attribute-by-attribute diff for struct types
+
+	// Handle pointer to struct
+	if new.Settings == nil && old.Settings != nil {
+		// new is nil, old is not nil - set to null
+		diff["Settings"] = nil
+	} else if new.Settings != nil && old.Settings == nil {
+		// new is not nil, old is nil - use entire new
+		jsonValue, err := sonic.Marshal(new.Settings)
+		if err == nil && !isEmptyJSON(string(jsonValue)) {
+			diff["Settings"] = jsonMergeExpr{Column: clause.Column{Name: "settings"}, Patch: string(jsonValue)}
+		} else if err != nil {
+			diff["Settings"] = new.Settings
+		}
+	} else if new.Settings != nil && old.Settings != nil {
+		// Both are not nil - use attribute-by-attribute diff
+		SettingsDiff := new.Settings.Diff(old.Settings)
+		if len(SettingsDiff) > 0 {
+			// Deep merge so nested objects keep their unchanged keys
+			if expr, err := jsonDeepMerge("settings", SettingsDiff); err == nil {
+				diff["Settings"] = expr
+			} else {
+				// Fallback to regular assignment if JSON marshaling fails
+				diff["Settings"] = new.Settings
+			}
+		}
+	}
+
+	return diff
+}
+
+// Changes compares this Device instance (new) with another (old) and returns the changed
+// fields with both their old and new values. Changes inside nested @jsonb structs are
+// reported per key, with their full JSON path.
+// Returns nil if either pointer is nil or nothing changed.
+func (new *Device) Changes(old *Device) []changeset.FieldChange {
+	// The fields reported by Diff are the changed ones
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var changes []changeset.FieldChange
+
+	if _, changed := diff["ID"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "ID", Column: "id", Old: old.ID, New: new.ID})
+	}
+	if _, changed := diff["Name"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Name", Column: "name", Old: old.Name, New: new.Name})
+	}
+	if _, changed := diff["Settings"]; changed {
+		if new.Settings != nil && old.Settings != nil {
+			for _, change := range new.Settings.Changes(old.Settings) {
+				changes = append(changes, change.Nest("Settings", "settings"))
+			}
+		} else {
+			changes = append(changes, changeset.FieldChange{Field: "Settings", Column: "settings", Old: old.Settings, New: new.Settings})
+		}
+	}
+
+	return changes
+}
+
+// patchOps returns the changes turning old into new as operations on the JSON document of
+// Device, addressed by the JSON keys of its fields.
+func (new *Device) patchOps(old *Device) []patchOp {
+	// The fields reported by Diff are the changed ones
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var ops []patchOp
+
+	if _, changed := diff["ID"]; changed {
+		ops = append(ops, patchOp{Path: []string{"ID"}, Old: old.ID, Value: new.ID, Removed: false, Added: false})
+	}
+	if _, changed := diff["Name"]; changed {
+		ops = append(ops, patchOp{Path: []string{"Name"}, Old: old.Name, Value: new.Name, Removed: false, Added: false})
+	}
+	if _, changed := diff["Settings"]; changed {
+		if new.Settings != nil && old.Settings != nil {
+			for _, op := range new.Settings.patchOps(old.Settings) {
+				ops = append(ops, op.nest("Settings"))
+			}
+		} else {
+			ops = append(ops, patchOp{Path: []string{"Settings"}, Value: new.Settings, Removed: false, Added: false})
+		}
+	}
+
+	return ops
+}
+
+// MergePatch returns the RFC 7386 JSON merge patch turning old into new. Removed keys
+// are null and arrays are replaced as a whole.
+func (new *Device) MergePatch(old *Device) ([]byte, error) {
+	return mergePatch(new.patchOps(old))
+}
+
+// JSONPatch returns the RFC 6902 JSON patch turning old into new. Removed keys become
+// remove operations and arrays are diffed by index, unless tagged diff:"replace".
+func (new *Device) JSONPatch(old *Device) ([]byte, error) {
+	return jsonPatch(new.patchOps(old))
+}
+
+// ApplyDiff sets the fields of this Device instance from a diff returned by Diff, the
+// inverse of Diff. Values decoded from JSON are converted back to the field types, and
+// nested struct diffs are applied field by field.
+// Returns a *diffapply.UnknownKeyError for keys matching no field and a
+// *diffapply.TypeMismatchError for values that cannot be converted.
+func (m *Device) ApplyDiff(d map[string]interface{}) error {
+	for key, value := range d {
+		switch key {
+		case "ID":
+			if err := diffapply.Number(key, value, &m.ID); err != nil {
+				return err
+			}
+		case "Name":
+			if err := diffapply.String(key, value, &m.Name); err != nil {
+				return err
+			}
+		case "Settings":
+			if nested, ok := value.(map[string]interface{}); ok && !diffapply.IsExpression(value) {
+				if err := diffapply.Alloc(&m.Settings).ApplyDiff(nested); err != nil {
+					return diffapply.Nest(err, key)
+				}
+			} else if err := diffapply.Decode(key, value, &m.Settings); err != nil {
+				return err
+			}
+		default:
+			return &diffapply.UnknownKeyError{Struct: "Device", Path: []string{key}}
+		}
+	}
+	return nil
+}
+
+// ApplyMergePatch sets the fields of this Device instance from an RFC 7386 JSON merge
+// patch returned by MergePatch, the inverse of MergePatch. Unlike the diffs of JSON
+// columns, which are SQL expressions, merge patches are plain JSON and can be replayed.
+// Keys are the JSON keys of the fields, null clears a field, nested structs are patched
+// field by field and other values replace the field as a whole.
+// Returns the errors of ApplyDiff.
+func (m *Device) ApplyMergePatch(patch []byte) error {
+	document, err := diffapply.ParseMergePatch(patch)
+	if err != nil {
+		return err
+	}
+	return m.applyMergePatch(document)
+}
+
+// applyMergePatch sets the fields of this Device instance from a decoded merge patch
+func (m *Device) applyMergePatch(patch map[string]interface{}) error {
+	for key, value := range patch {
+		switch key {
+		case "ID":
+			if err := diffapply.Number(key, value, &m.ID); err != nil {
+				return err
+			}
+		case "Name":
+			if err := diffapply.String(key, value, &m.Name); err != nil {
+				return err
+			}
+		case "Settings":
+			if nested, ok := value.(map[string]interface{}); ok {
+				if err := diffapply.Alloc(&m.Settings).applyMergePatch(nested); err != nil {
+					return diffapply.Nest(err, key)
+				}
+			} else if err := diffapply.Decode(key, value, &m.Settings); err != nil {
+				return err
+			}
+		default:
+			return &diffapply.UnknownKeyError{Struct: "Device", Path: []string{key}}
+		}
+	}
+	return nil
+}
+
+// Merge performs a three-way merge of this Device instance (ours) and theirs, both edited
+// from base. Fields changed on one side only take the value of that side. Fields changed
+// on both sides to different values are reported as conflicts and keep our value.
+// Nested @jsonb structs are merged key by key.
+// The merged struct shares slices, maps and pointers with the merged values.
+// Returns nil if any pointer is nil.
+func (ours *Device) Merge(base, theirs *Device) (*Device, []changeset.Conflict) {
+	if ours == nil || base == nil || theirs == nil {
+		return nil, nil
+	}
+
+	merged := *base
+	oursDiff := ours.Diff(base)
+	theirsDiff := theirs.Diff(base)
+	if len(oursDiff) == 0 && len(theirsDiff) == 0 {
+		return &merged, nil
+	}
+
+	var conflicts []changeset.Conflict
+
+	// Merge ID
+	if _, changed := oursDiff["ID"]; !changed {
+		if _, changed := theirsDiff["ID"]; changed {
+			merged.ID = theirs.ID
+		}
+	} else if _, changed := theirsDiff["ID"]; !changed {
+		merged.ID = ours.ID
+	} else {
+		merged.ID = ours.ID
+		if _, differ := ours.Diff(theirs)["ID"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "ID", Column: "id", Base: base.ID, Ours: ours.ID, Theirs: theirs.ID})
+		}
+	}
+
+	// Merge Name
+	if _, changed := oursDiff["Name"]; !changed {
+		if _, changed := theirsDiff["Name"]; changed {
+			merged.Name = theirs.Name
+		}
+	} else if _, changed := theirsDiff["Name"]; !changed {
+		merged.Name = ours.Name
+	} else {
+		merged.Name = ours.Name
+		if _, differ := ours.Diff(theirs)["Name"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Name", Column: "name", Base: base.Name, Ours: ours.Name, Theirs: theirs.Name})
+		}
+	}
+
+	// Merge Settings
+	if _, changed := oursDiff["Settings"]; !changed {
+		if _, changed := theirsDiff["Settings"]; changed {
+			merged.Settings = theirs.Settings
+		}
+	} else if _, changed := theirsDiff["Settings"]; !changed {
+		merged.Settings = ours.Settings
+	} else if ours.Settings != nil && base.Settings != nil && theirs.Settings != nil {
+		nestedMerged, nestedConflicts := ours.Settings.Merge(base.Settings, theirs.Settings)
+		merged.Settings = nestedMerged
+		for _, conflict := range nestedConflicts {
+			conflicts = append(conflicts, conflict.Nest("Settings", "settings"))
+		}
+	} else {
+		merged.Settings = ours.Settings
+		if _, differ := ours.Diff(theirs)["Settings"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Settings", Column: "settings", Base: base.Settings, Ours: ours.Settings, Theirs: theirs.Settings})
+		}
+	}
+
+	return &merged, conflicts
+}
+
+// Diff compares this DeviceEvent instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *DeviceEvent) Diff(old *DeviceEvent) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare ID
+
+	// UUID comparison
+
+	// Direct UUID comparison
+	if new.ID != old.ID {
+		diff["ID"] = new.ID
+	}
+
+	// Compare DeviceID
+
+	// Simple type comparison
+	if new.DeviceID != old.DeviceID {
+		diff["DeviceID"] = new.DeviceID
+	}
+
+	// Compare Kind
+
+	// Simple type comparison
+	if new.Kind != old.Kind {
+		diff["Kind"] = new.Kind
+	}
+
+	// Compare OccurredAt
+
+	// Time comparison
+
+	// Direct time comparison
+	if !new.OccurredAt.Equal(old.OccurredAt) {
+		diff["OccurredAt"] = new.OccurredAt
+
+	}
+
+	// Compare AckedAt
+
+	// Time comparison
+
+	// Pointer to time comparison
+	if (new.AckedAt == nil) != (old.AckedAt == nil) || (new.AckedAt != nil && !new.AckedAt.Equal(*old.AckedAt)) {
+		diff["AckedAt"] = new.AckedAt
+	}
+
+	// Compare Retries
+
+	// Pointer to value comparison - the values pointed to, since clones point to copies
+	if (new.Retries == nil) != (old.Retries == nil) || (new.Retries != nil && *new.Retries != *old.Retries) {
+		diff["Retries"] = new.Retries
+	}
+
+	// Compare Status
+
+	// JSON field comparison - handle both datatypes.JSON and struct types with jsonb storage
+
+	// JSON field comparison - attribute-by-attribute diff for struct types
+
+	// Handle pointer to struct
+	if new.Status == nil && old.Status != nil {
+		// new is nil, old is not nil - set to null
+		diff["Status"] = nil
+	} else if new.Status != nil && old.Status == nil {
+		// new is not nil, old is nil - use entire new
+		jsonValue, err := sonic.Marshal(new.Status)
+		if err == nil && !isEmptyJSON(string(jsonValue)) {
+			diff["Status"] = jsonMergeExpr{Column: clause.Column{Name: "status"}, Patch: string(jsonValue)}
+		} else if err != nil {
+			diff["Status"] = new.Status
+		}
+	} else if new.Status != nil && old.Status != nil {
+		// Both are not nil - use attribute-by-attribute diff
+		StatusDiff := new.Status.Diff(old.Status)
+		if len(StatusDiff) > 0 {
+			// Deep merge so nested objects keep their unchanged keys
+			if expr, err := jsonDeepMerge("status", StatusDiff); err == nil {
+				diff["Status"] = expr
+			} else {
+				// Fallback to regular assignment if JSON marshaling fails
+				diff["Status"] = new.Status
+			}
+		}
+	}
+
+	return diff
+}
+
+// Changes compares this DeviceEvent instance (new) with another (old) and returns the changed
+// fields with both their old and new values. Changes inside nested @jsonb structs are
+// reported per key, with their full JSON path.
+// Returns nil if either pointer is nil or nothing changed.
+func (new *DeviceEvent) Changes(old *DeviceEvent) []changeset.FieldChange {
+	// The fields reported by Diff are the changed ones
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var changes []changeset.FieldChange
+
+	if _, changed := diff["ID"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "ID", Column: "id", Old: old.ID, New: new.ID})
+	}
+	if _, changed := diff["DeviceID"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "DeviceID", Column: "device_id", Old: old.DeviceID, New: new.DeviceID})
+	}
+	if _, changed := diff["Kind"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Kind", Column: "kind", Old: old.Kind, New: new.Kind})
+	}
+	if _, changed := diff["OccurredAt"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "OccurredAt", Column: "occurred_at", Old: old.OccurredAt, New: new.OccurredAt})
+	}
+	if _, changed := diff["AckedAt"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "AckedAt", Column: "acked_at", Old: old.AckedAt, New: new.AckedAt})
+	}
+	if _, changed := diff["Retries"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Retries", Column: "retries", Old: old.Retries, New: new.Retries})
+	}
+	if _, changed := diff["Status"]; changed {
+		if new.Status != nil && old.Status != nil {
+			for _, change := range new.Status.Changes(old.Status) {
+				changes = append(changes, change.Nest("Status", "status"))
+			}
+		} else {
+			changes = append(changes, changeset.FieldChange{Field: "Status", Column: "status", Old: old.Status, New: new.Status})
+		}
+	}
+
+	return changes
+}
+
+// patchOps returns the changes turning old into new as operations on the JSON document of
+// DeviceEvent, addressed by the JSON keys of its fields.
+func (new *DeviceEvent) patchOps(old *DeviceEvent) []patchOp {
+	// The fields reported by Diff are the changed ones
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var ops []patchOp
+
+	if _, changed := diff["ID"]; changed {
+		ops = append(ops, patchOp{Path: []string{"ID"}, Old: old.ID, Value: new.ID, Removed: false, Added: false})
+	}
+	if _, changed := diff["DeviceID"]; changed {
+		ops = append(ops, patchOp{Path: []string{"DeviceID"}, Old: old.DeviceID, Value: new.DeviceID, Removed: false, Added: false})
+	}
+	if _, changed := diff["Kind"]; changed {
+		ops = append(ops, patchOp{Path: []string{"Kind"}, Old: old.Kind, Value: new.Kind, Removed: false, Added: false})
+	}
+	if _, changed := diff["OccurredAt"]; changed {
+		ops = append(ops, patchOp{Path: []string{"OccurredAt"}, Old: old.OccurredAt, Value: new.OccurredAt, Removed: false, Added: false})
+	}
+	if _, changed := diff["AckedAt"]; changed {
+		ops = append(ops, patchOp{Path: []string{"AckedAt"}, Old: old.AckedAt, Value: new.AckedAt, Removed: false, Added: false})
+	}
+	if _, changed := diff["Retries"]; changed {
+		ops = append(ops, patchOp{Path: []string{"Retries"}, Old: old.Retries, Value: new.Retries, Removed: false, Added: false})
+	}
+	if _, changed := diff["Status"]; changed {
+		if new.Status != nil && old.Status != nil {
+			for _, op := range new.Status.patchOps(old.Status) {
+				ops = append(ops, op.nest("Status"))
+			}
+		} else {
+			ops = append(ops, patchOp{Path: []string{"Status"}, Value: new.Status, Removed: false, Added: false})
+		}
+	}
+
+	return ops
+}
+
+// MergePatch returns the RFC 7386 JSON merge patch turning old into new. Removed keys
+// are null and arrays are replaced as a whole.
+func (new *DeviceEvent) MergePatch(old *DeviceEvent) ([]byte, error) {
+	return mergePatch(new.patchOps(old))
+}
+
+// JSONPatch returns the RFC 6902 JSON patch turning old into new. Removed keys become
+// remove operations and arrays are diffed by index, unless tagged diff:"replace".
+func (new *DeviceEvent) JSONPatch(old *DeviceEvent) ([]byte, error) {
+	return jsonPatch(new.patchOps(old))
+}
+
+// ApplyDiff sets the fields of this DeviceEvent instance from a diff returned by Diff, the
+// inverse of Diff. Values decoded from JSON are converted back to the field types, and
+// nested struct diffs are applied field by field.
+// Returns a *diffapply.UnknownKeyError for keys matching no field and a
+// *diffapply.TypeMismatchError for values that cannot be converted.
+func (m *DeviceEvent) ApplyDiff(d map[string]interface{}) error {
+	for key, value := range d {
+		switch key {
+		case "ID":
+			if err := diffapply.UUID(key, value, &m.ID); err != nil {
+				return err
+			}
+		case "DeviceID":
+			if err := diffapply.Number(key, value, &m.DeviceID); err != nil {
+				return err
+			}
+		case "Kind":
+			if err := diffapply.String(key, value, &m.Kind); err != nil {
+				return err
+			}
+		case "OccurredAt":
+			if err := diffapply.Time(key, value, &m.OccurredAt); err != nil {
+				return err
+			}
+		case "AckedAt":
+			if err := diffapply.TimePtr(key, value, &m.AckedAt); err != nil {
+				return err
+			}
+		case "Retries":
+			if err := diffapply.NumberPtr(key, value, &m.Retries); err != nil {
+				return err
+			}
+		case "Status":
+			if nested, ok := value.(map[string]interface{}); ok && !diffapply.IsExpression(value) {
+				if err := diffapply.Alloc(&m.Status).ApplyDiff(nested); err != nil {
+					return diffapply.Nest(err, key)
+				}
+			} else if err := diffapply.Decode(key, value, &m.Status); err != nil {
+				return err
+			}
+		default:
+			return &diffapply.UnknownKeyError{Struct: "DeviceEvent", Path: []string{key}}
+		}
+	}
+	return nil
+}
+
+// ApplyMergePatch sets the fields of this DeviceEvent instance from an RFC 7386 JSON merge
+// patch returned by MergePatch, the inverse of MergePatch. Unlike the diffs of JSON
+// columns, which are SQL expressions, merge patches are plain JSON and can be replayed.
+// Keys are the JSON keys of the fields, null clears a field, nested structs are patched
+// field by field and other values replace the field as a whole.
+// Returns the errors of ApplyDiff.
+func (m *DeviceEvent) ApplyMergePatch(patch []byte) error {
+	document, err := diffapply.ParseMergePatch(patch)
+	if err != nil {
+		return err
+	}
+	return m.applyMergePatch(document)
+}
+
+// applyMergePatch sets the fields of this DeviceEvent instance from a decoded merge patch
+func (m *DeviceEvent) applyMergePatch(patch map[string]interface{}) error {
+	for key, value := range patch {
+		switch key {
+		case "ID":
+			if err := diffapply.UUID(key, value, &m.ID); err != nil {
+				return err
+			}
+		case "DeviceID":
+			if err := diffapply.Number(key, value, &m.DeviceID); err != nil {
+				return err
+			}
+		case "Kind":
+			if err := diffapply.String(key, value, &m.Kind); err != nil {
+				return err
+			}
+		case "OccurredAt":
+			if err := diffapply.Time(key, value, &m.OccurredAt); err != nil {
+				return err
+			}
+		case "AckedAt":
+			if err := diffapply.TimePtr(key, value, &m.AckedAt); err != nil {
+				return err
+			}
+		case "Retries":
+			if err := diffapply.NumberPtr(key, value, &m.Retries); err != nil {
+				return err
+			}
+		case "Status":
+			if nested, ok := value.(map[string]interface{}); ok {
+				if err := diffapply.Alloc(&m.Status).applyMergePatch(nested); err != nil {
+					return diffapply.Nest(err, key)
+				}
+			} else if err := diffapply.Decode(key, value, &m.Status); err != nil {
+				return err
+			}
+		default:
+			return &diffapply.UnknownKeyError{Struct: "DeviceEvent", Path: []string{key}}
+		}
+	}
+	return nil
+}
+
+// Merge performs a three-way merge of this DeviceEvent instance (ours) and theirs, both edited
+// from base. Fields changed on one side only take the value of that side. Fields changed
+// on both sides to different values are reported as conflicts and keep our value.
+// Nested @jsonb structs are merged key by key.
+// The merged struct shares slices, maps and pointers with the merged values.
+// Returns nil if any pointer is nil.
+func (ours *DeviceEvent) Merge(base, theirs *DeviceEvent) (*DeviceEvent, []changeset.Conflict) {
+	if ours == nil || base == nil || theirs == nil {
+		return nil, nil
+	}
+
+	merged := *base
+	oursDiff := ours.Diff(base)
+	theirsDiff := theirs.Diff(base)
+	if len(oursDiff) == 0 && len(theirsDiff) == 0 {
+		return &merged, nil
+	}
+
+	var conflicts []changeset.Conflict
+
+	// Merge ID
+	if _, changed := oursDiff["ID"]; !changed {
+		if _, changed := theirsDiff["ID"]; changed {
+			merged.ID = theirs.ID
+		}
+	} else if _, changed := theirsDiff["ID"]; !changed {
+		merged.ID = ours.ID
+	} else {
+		merged.ID = ours.ID
+		if _, differ := ours.Diff(theirs)["ID"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "ID", Column: "id", Base: base.ID, Ours: ours.ID, Theirs: theirs.ID})
+		}
+	}
+
+	// Merge DeviceID
+	if _, changed := oursDiff["DeviceID"]; !changed {
+		if _, changed := theirsDiff["DeviceID"]; changed {
+			merged.DeviceID = theirs.DeviceID
+		}
+	} else if _, changed := theirsDiff["DeviceID"]; !changed {
+		merged.DeviceID = ours.DeviceID
+	} else {
+		merged.DeviceID = ours.DeviceID
+		if _, differ := ours.Diff(theirs)["DeviceID"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "DeviceID", Column: "device_id", Base: base.DeviceID, Ours: ours.DeviceID, Theirs: theirs.DeviceID})
+		}
+	}
+
+	// Merge Kind
+	if _, changed := oursDiff["Kind"]; !changed {
+		if _, changed := theirsDiff["Kind"]; changed {
+			merged.Kind = theirs.Kind
+		}
+	} else if _, changed := theirsDiff["Kind"]; !changed {
+		merged.Kind = ours.Kind
+	} else {
+		merged.Kind = ours.Kind
+		if _, differ := ours.Diff(theirs)["Kind"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Kind", Column: "kind", Base: base.Kind, Ours: ours.Kind, Theirs: theirs.Kind})
+		}
+	}
+
+	// Merge OccurredAt
+	if _, changed := oursDiff["OccurredAt"]; !changed {
+		if _, changed := theirsDiff["OccurredAt"]; changed {
+			merged.OccurredAt = theirs.OccurredAt
+		}
+	} else if _, changed := theirsDiff["OccurredAt"]; !changed {
+		merged.OccurredAt = ours.OccurredAt
+	} else {
+		merged.OccurredAt = ours.OccurredAt
+		if _, differ := ours.Diff(theirs)["OccurredAt"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "OccurredAt", Column: "occurred_at", Base: base.OccurredAt, Ours: ours.OccurredAt, Theirs: theirs.OccurredAt})
+		}
+	}
+
+	// Merge AckedAt
+	if _, changed := oursDiff["AckedAt"]; !changed {
+		if _, changed := theirsDiff["AckedAt"]; changed {
+			merged.AckedAt = theirs.AckedAt
+		}
+	} else if _, changed := theirsDiff["AckedAt"]; !changed {
+		merged.AckedAt = ours.AckedAt
+	} else {
+		merged.AckedAt = ours.AckedAt
+		if _, differ := ours.Diff(theirs)["AckedAt"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "AckedAt", Column: "acked_at", Base: base.AckedAt, Ours: ours.AckedAt, Theirs: theirs.AckedAt})
+		}
+	}
+
+	// Merge Retries
+	if _, changed := oursDiff["Retries"]; !changed {
+		if _, changed := theirsDiff["Retries"]; changed {
+			merged.Retries = theirs.Retries
+		}
+	} else if _, changed := theirsDiff["Retries"]; !changed {
+		merged.Retries = ours.Retries
+	} else {
+		merged.Retries = ours.Retries
+		if _, differ := ours.Diff(theirs)["Retries"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Retries", Column: "retries", Base: base.Retries, Ours: ours.Retries, Theirs: theirs.Retries})
+		}
+	}
+
+	// Merge Status
+	if _, changed := oursDiff["Status"]; !changed {
+		if _, changed := theirsDiff["Status"]; changed {
+			merged.Status = theirs.Status
+		}
+	} else if _, changed := theirsDiff["Status"]; !changed {
+		merged.Status = ours.Status
+	} else if ours.Status != nil && base.Status != nil && theirs.Status != nil {
+		nestedMerged, nestedConflicts := ours.Status.Merge(base.Status, theirs.Status)
+		merged.Status = nestedMerged
+		for _, conflict := range nestedConflicts {
+			conflicts = append(conflicts, conflict.Nest("Status", "status"))
+		}
+	} else {
+		merged.Status = ours.Status
+		if _, differ := ours.Diff(theirs)["Status"]; differ {
+			conflicts = append(conflicts, changeset.Conflict{Field: "Status", Column: "status", Base: base.Status, Ours: ours.Status, Theirs: theirs.Status})
+		}
+	}
+
+	return &merged, conflicts
+}
+
+// patchOp is a change of the JSON document of a struct, from which merge patches and
+// JSON patches are built
+type patchOp struct {
+	Path    []string    // JSON keys leading to the changed value
+	Old     interface{} // Previous value, used to diff arrays by index
+	Value   interface{} // New value
+	Removed bool        // The key is no longer present in the document
+	Added   bool        // The key was not present in the document before
+	Array   bool        // Old and Value are slices, diffed by index in JSON patches
+}
+
+// nest returns the operation as seen from the document containing its own under key
+func (op patchOp) nest(key string) patchOp {
+	op.Path = append([]string{key}, op.Path...)
+	return op
+}
+
+// mergePatch encodes ops as an RFC 7386 JSON merge patch
+func mergePatch(ops []patchOp) ([]byte, error) {
+	patch := make(map[string]interface{})
+	for _, op := range ops {
+		if op.Removed && op.Added {
+			continue // Omitted before and after the change
+		}
+
+		object := patch
+		for _, key := range op.Path[:len(op.Path)-1] {
+			child, ok := object[key].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				object[key] = child
+			}
+			object = child
+		}
+
+		key := op.Path[len(op.Path)-1]
+		if op.Removed {
+			object[key] = nil
+		} else {
+			object[key] = op.Value
+		}
+	}
+	return sonic.ConfigStd.Marshal(patch)
+}
+
+// jsonPatchOperation is an add or replace operation of an RFC 6902 JSON patch
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// jsonPatchRemoval is a remove operation of an RFC 6902 JSON patch
+type jsonPatchRemoval struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+}
+
+// jsonPatch encodes ops as an RFC 6902 JSON patch
+func jsonPatch(ops []patchOp) ([]byte, error) {
+	patch := make([]interface{}, 0, len(ops))
+	for _, op := range ops {
+		path := jsonPointer(op.Path)
+		switch {
+		case op.Removed && op.Added:
+			// Omitted before and after the change
+		case op.Removed:
+			patch = append(patch, jsonPatchRemoval{Op: "remove", Path: path})
+		case op.Added:
+			patch = append(patch, jsonPatchOperation{Op: "add", Path: path, Value: op.Value})
+		case op.Array:
+			patch = appendArrayPatch(patch, path, op.Old, op.Value)
+		default:
+			patch = append(patch, jsonPatchOperation{Op: "replace", Path: path, Value: op.Value})
+		}
+	}
+	return sonic.ConfigStd.Marshal(patch)
+}
+
+// appendArrayPatch appends the operations turning the old slice into the new one index
+// by index. A nil slice is encoded as null, so changes from or to nil replace it whole.
+func appendArrayPatch(patch []interface{}, path string, old, new interface{}) []interface{} {
+	oldValue, newValue := reflect.ValueOf(old), reflect.ValueOf(new)
+	if oldValue.Kind() != reflect.Slice || newValue.Kind() != reflect.Slice || oldValue.IsNil() || newValue.IsNil() {
+		return append(patch, jsonPatchOperation{Op: "replace", Path: path, Value: new})
+	}
+
+	common := min(oldValue.Len(), newValue.Len())
+	for i := 0; i < common; i++ {
+		if !reflect.DeepEqual(oldValue.Index(i).Interface(), newValue.Index(i).Interface()) {
+			patch = append(patch, jsonPatchOperation{Op: "replace", Path: path + "/" + strconv.Itoa(i), Value: newValue.Index(i).Interface()})
+		}
+	}
+	for i := common; i < newValue.Len(); i++ {
+		patch = append(patch, jsonPatchOperation{Op: "add", Path: path + "/-", Value: newValue.Index(i).Interface()})
+	}
+	// Remove from the end so the indexes of the remaining elements do not shift
+	for i := oldValue.Len() - 1; i >= common; i-- {
+		patch = append(patch, jsonPatchRemoval{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+	return patch
+}
+
+// jsonPointer returns the RFC 6901 JSON pointer of path
+func jsonPointer(path []string) string {
+	var pointer strings.Builder
+	for _, key := range path {
+		pointer.WriteString("/")
+		pointer.WriteString(strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1"))
+	}
+	return pointer.String()
+}
@@ -0,0 +1,46 @@
+// Package dialect contains models generated with -dialect=auto, so the same Diff
+// works on Postgres, MySQL and SQLite: the JSON merge SQL is picked from the
+// dialector when the update statement is built. Changes, MergePatch, JSONPatch and
+// ApplyDiff methods are generated too.
+package dialect
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//go:generate go run ../../cmd/gorm-gen -types=clone,diff,changes,patch,apply,merge -dialect=auto -json-merge=deep
+
+// DeviceStatus represents the reported state of a device
+// @jsonb
+type DeviceStatus struct {
+	Online   bool   `json:"online,omitempty"`
+	Firmware string `json:"firmware,omitempty"`
+}
+
+// DeviceSettings represents device settings stored in a JSON column
+// @jsonb
+type DeviceSettings struct {
+	Theme  string       `json:"theme,omitempty"`
+	Volume int          `json:"volume,omitempty"`
+	Status DeviceStatus `json:"status"`
+	Alarms []string     `json:"alarms,omitempty"`
+}
+
+type Device struct {
+	ID       uint
+	Name     string
+	Settings *DeviceSettings `gorm:"type:json;serializer:json"`
+}
+
+// DeviceEvent is a device event whose diffs are sent over a queue as JSON
+type DeviceEvent struct {
+	ID         uuid.UUID
+	DeviceID   uint
+	Kind       string
+	OccurredAt time.Time
+	AckedAt    *time.Time
+	Retries    *int
+	Status     *DeviceStatus `gorm:"type:json;serializer:json"`
+}
@@ -0,0 +1,87 @@
+// Code generated by gorm-gen. DO NOT EDIT.
+// gorm-gen version: (devel)
+
+package generics
+
+// Clone creates a deep copy of the Page struct
+func (original *Page[T]) Clone() *Page[T] {
+	if original == nil {
+		return nil
+	}
+	// Create new instance and copy all simple fields
+	clone := *original
+
+	// Only handle JSONB fields that need deep cloning
+
+	if original.Items != nil {
+		clone.Items = make([]T, len(original.Items))
+		copy(clone.Items, original.Items)
+	}
+
+	if original.Cursor != nil {
+		value := *original.Cursor
+		clone.Cursor = &value
+	}
+
+	return &clone
+}
+
+// Clone creates a deep copy of the Pair struct
+func (original *Pair[K, V]) Clone() *Pair[K, V] {
+	if original == nil {
+		return nil
+	}
+	// Create new instance - all fields are simple types
+	clone := *original
+	return &clone
+}
+
+// Clone creates a deep copy of the JSONField struct
+func (original *JSONField[T]) Clone() *JSONField[T] {
+	if original == nil {
+		return nil
+	}
+	// Create new instance - all fields are simple types
+	clone := *original
+	return &clone
+}
+
+// Clone creates a deep copy of the Profile struct
+func (original *Profile) Clone() *Profile {
+	if original == nil {
+		return nil
+	}
+	// Create new instance and copy all simple fields
+	clone := *original
+
+	// Only handle JSONB fields that need deep cloning
+
+	if original.Languages != nil {
+		clone.Languages = make([]string, len(original.Languages))
+		copy(clone.Languages, original.Languages)
+	}
+
+	return &clone
+}
+
+// Clone creates a deep copy of the Account struct
+func (original *Account) Clone() *Account {
+	if original == nil {
+		return nil
+	}
+	// Create new instance and copy all simple fields
+	clone := *original
+
+	// Only handle JSONB fields that need deep cloning
+
+	clone.Profile = *(&original.Profile).Clone()
+
+	if original.Labels != nil {
+		clone.Labels = make([]Pair[string, string], len(original.Labels))
+		for i0, v0 := range original.Labels {
+			clone.Labels[i0] = *v0.Clone()
+		}
+	}
+
+	return &clone
+}
@@ -0,0 +1,242 @@
+// Code generated by gorm-gen. DO NOT EDIT.
+// gorm-gen version: (devel)
+
+package generics
+
+import (
+	"github.com/bytedance/sonic"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// isEmptyJSON checks if a JSON string represents an empty object or array
+func isEmptyJSON(jsonStr string) bool {
+	trimmed := strings.TrimSpace(jsonStr)
+	return trimmed == "{}" || trimmed == "[]" || trimmed == "null"
+}
+
+// jsonbPath formats path as a Postgres text[] literal
+func jsonbPath(path []string) string {
+	quoted := make([]string, len(path))
+	for i, key := range path {
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// jsonbRemove deletes the keys removed from patch (nil values) from the result of merge.
+// The merge writes them as null, so they are deleted again at their full path with #-.
+func jsonbRemove(merge clause.Expr, patch map[string]interface{}) clause.Expr {
+	for _, path := range jsonbRemovedPaths(nil, patch) {
+		merge = gorm.Expr("(?) #- ?::text[]", merge, jsonbPath(path))
+	}
+	return merge
+}
+
+// jsonbRemovedPaths returns the paths of the keys removed from patch, in key order
+func jsonbRemovedPaths(path []string, patch map[string]interface{}) [][]string {
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var removed [][]string
+	for _, key := range keys {
+		keyPath := append(append([]string{}, path...), key)
+		switch value := patch[key].(type) {
+		case nil:
+			removed = append(removed, keyPath)
+		case map[string]interface{}:
+			removed = append(removed, jsonbRemovedPaths(keyPath, value)...)
+		}
+	}
+	return removed
+}
+
+// Diff compares this Page instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *Page[T]) Diff(old *Page[T]) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare Items
+
+	// Complex type comparison (slice, map, interface, etc.)
+	if !reflect.DeepEqual(new.Items, old.Items) {
+		diff["Items"] = new.Items
+	}
+
+	// Compare Total
+
+	// Simple type comparison
+	if new.Total != old.Total {
+		diff["Total"] = new.Total
+	}
+
+	// Compare Cursor
+
+	// Pointer to value comparison - the values pointed to, since clones point to copies
+	if (new.Cursor == nil) != (old.Cursor == nil) || (new.Cursor != nil && *new.Cursor != *old.Cursor) {
+		diff["Cursor"] = new.Cursor
+	}
+
+	return diff
+}
+
+// Diff compares this Pair instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *Pair[K, V]) Diff(old *Pair[K, V]) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare Key
+
+	// Comparable type comparison
+	if new.Key != old.Key {
+		diff["Key"] = new.Key
+	}
+
+	// Compare Value
+
+	// Complex type comparison (slice, map, interface, etc.)
+	if !reflect.DeepEqual(new.Value, old.Value) {
+		diff["Value"] = new.Value
+	}
+
+	return diff
+}
+
+// Diff compares this JSONField instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *JSONField[T]) Diff(old *JSONField[T]) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare Version
+
+	// Simple type comparison
+	if new.Version != old.Version {
+		diff["version"] = new.Version
+	}
+
+	// Compare Data
+
+	// Complex type comparison (slice, map, interface, etc.)
+	if !reflect.DeepEqual(new.Data, old.Data) {
+		diff["data"] = new.Data
+	}
+
+	return diff
+}
+
+// Diff compares this Profile instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *Profile) Diff(old *Profile) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare DisplayName
+
+	// Simple type comparison
+	if new.DisplayName != old.DisplayName {
+		diff["DisplayName"] = new.DisplayName
+	}
+
+	// Compare Languages
+
+	// Complex type comparison (slice, map, interface, etc.)
+	if !reflect.DeepEqual(new.Languages, old.Languages) {
+		diff["Languages"] = new.Languages
+	}
+
+	return diff
+}
+
+// Diff compares this Account instance (new) with another (old) and returns a map of differences
+// with only the new values for fields that have changed.
+// Usage: newValues = new.Diff(old)
+// Returns nil if either pointer is nil.
+func (new *Account) Diff(old *Account) map[string]interface{} {
+	// Handle nil pointers
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+
+	// Compare ID
+
+	// Simple type comparison
+	if new.ID != old.ID {
+		diff["ID"] = new.ID
+	}
+
+	// Compare Email
+
+	// Simple type comparison
+	if new.Email != old.Email {
+		diff["Email"] = new.Email
+	}
+
+	// Compare Profile
+
+	// JSON field comparison - handle both datatypes.JSON and struct types with jsonb storage
+
+	// JSON field comparison - attribute-by-attribute diff for struct types
+
+	// Handle direct struct (not pointer) - use attribute-by-attribute diff
+	ProfileDiff := new.Profile.Diff(&old.Profile)
+	if len(ProfileDiff) > 0 {
+		jsonValue, err := sonic.Marshal(ProfileDiff)
+		if err == nil && !isEmptyJSON(string(jsonValue)) {
+			diff["Profile"] = jsonbRemove(gorm.Expr("? || ?", clause.Column{Name: "profile"}, string(jsonValue)), ProfileDiff)
+		} else if err != nil {
+			// Fallback to regular assignment if JSON marshaling fails
+			diff["Profile"] = new.Profile
+		}
+	}
+
+	// Compare Labels
+
+	// Complex type comparison (slice, map, interface, etc.)
+	if !reflect.DeepEqual(new.Labels, old.Labels) {
+		diff["Labels"] = new.Labels
+	}
+
+	// Compare Recent
+
+	// Comparable type comparison
+	if new.Recent != old.Recent {
+		diff["Recent"] = new.Recent
+	}
+
+	return diff
+}
@@ -0,0 +1,62 @@
+package generics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenericDiff(t *testing.T) {
+	old := &Pair[string, []int]{Key: "a", Value: []int{1, 2}}
+	new := &Pair[string, []int]{Key: "b", Value: []int{1, 2, 3}}
+
+	diff := new.Diff(old)
+	if diff["Key"] != "b" {
+		t.Errorf("Expected Key change, got %v", diff)
+	}
+	if !reflect.DeepEqual(diff["Value"], []int{1, 2, 3}) {
+		t.Errorf("Expected Value change compared with reflect.DeepEqual, got %v", diff)
+	}
+
+	// A type argument that is not comparable with == must not panic
+	same := &Pair[int, map[string]int]{Key: 1, Value: map[string]int{"x": 1}}
+	if diff := same.Diff(&Pair[int, map[string]int]{Key: 1, Value: map[string]int{"x": 1}}); len(diff) != 0 {
+		t.Errorf("Expected no changes, got %v", diff)
+	}
+}
+
+func TestGenericJSONBDiff(t *testing.T) {
+	old := &Account{ID: 1, Profile: JSONField[Profile]{Version: 1, Data: Profile{DisplayName: "Ann"}}}
+	new := old.Clone()
+	new.Profile.Data.DisplayName = "Anna"
+
+	diff := new.Diff(old)
+	if _, ok := diff["Profile"]; !ok {
+		t.Fatalf("Expected Profile change, got %v", diff)
+	}
+	if len(diff) != 1 {
+		t.Errorf("Expected only Profile to change, got %v", diff)
+	}
+}
+
+func TestGenericClone(t *testing.T) {
+	cursor := "next"
+	original := &Page[string]{Items: []string{"a", "b"}, Total: 2, Cursor: &cursor}
+
+	clone := original.Clone()
+	clone.Items[0] = "z"
+	*clone.Cursor = "other"
+
+	if original.Items[0] != "a" || *original.Cursor != "next" {
+		t.Errorf("Expected original to be unchanged, got %v and %q", original.Items, *original.Cursor)
+	}
+	if diff := clone.Diff(original); len(diff) != 2 {
+		t.Errorf("Expected Items and Cursor changes, got %v", diff)
+	}
+
+	account := &Account{Labels: []Pair[string, string]{{Key: "team", Value: "core"}}}
+	accountClone := account.Clone()
+	accountClone.Labels[0].Value = "infra"
+	if account.Labels[0].Value != "core" {
+		t.Errorf("Expected cloned labels to be independent, got %v", account.Labels)
+	}
+}
@@ -0,0 +1,39 @@
+// Package generics contains generic models, whose generated Diff and Clone methods
+// are declared on the generic types and work for every instantiation.
+package generics
+
+//go:generate go run ../../cmd/gorm-gen
+
+// Page is a page of results of any element type
+type Page[T any] struct {
+	Items  []T
+	Total  int
+	Cursor *string
+}
+
+// Pair holds a comparable key and a value of any type
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// JSONField wraps a value stored in a JSONB column together with its schema version
+// @jsonb
+type JSONField[T any] struct {
+	Version int `json:"version"`
+	Data    T   `json:"data"`
+}
+
+// Profile is stored as the data of a JSONField
+type Profile struct {
+	DisplayName string
+	Languages   []string
+}
+
+type Account struct {
+	ID      uint
+	Email   string
+	Profile JSONField[Profile] `gorm:"type:jsonb;serializer:json"`
+	Labels  []Pair[string, string]
+	Recent  *Page[string]
+}
@@ -1,3 +1,6 @@
+// Code generated by gorm-gen. DO NOT EDIT.
+// gorm-gen version: (devel)
+
 package models
 
 import (
@@ -87,9 +90,15 @@ func (original *ServerPodType) Clone() *ServerPodType {
 		clone.Version = original.Version.Clone()
 	}
 
-	// TODO: AccountIdWhitelist (JsonbStringSlice) may need manual deep copy handling
+	if original.AccountIdWhitelist != nil {
+		clone.AccountIdWhitelist = make(JsonbStringSlice, len(original.AccountIdWhitelist))
+		copy(clone.AccountIdWhitelist, original.AccountIdWhitelist)
+	}
 
-	// TODO: ServiceIdWhitelist (JsonbStringSlice) may need manual deep copy handling
+	if original.ServiceIdWhitelist != nil {
+		clone.ServiceIdWhitelist = make(JsonbStringSlice, len(original.ServiceIdWhitelist))
+		copy(clone.ServiceIdWhitelist, original.ServiceIdWhitelist)
+	}
 
 	return &clone
 }
@@ -114,8 +123,23 @@ func (original *ServiceData) Clone() *ServiceData {
 
 	// Only handle JSONB fields that need deep cloning
 
+	if original.LastSyncAt != nil {
+		value := *original.LastSyncAt
+		clone.LastSyncAt = &value
+	}
+
+	if original.LastMessageTimestamp != nil {
+		value := *original.LastMessageTimestamp
+		clone.LastMessageTimestamp = &value
+	}
+
 	clone.Status = *(&original.Status).Clone()
 
+	if original.StatusTimestamp != nil {
+		value := *original.StatusTimestamp
+		clone.StatusTimestamp = &value
+	}
+
 	return &clone
 }
 
@@ -147,6 +171,11 @@ func (original *Service) Clone() *Service {
 		clone.Settings = original.Settings.Clone()
 	}
 
+	if original.ServerPodId != nil {
+		value := *original.ServerPodId
+		clone.ServerPodId = &value
+	}
+
 	return &clone
 }
 
@@ -180,9 +209,19 @@ func (original *SimpleModel) Clone() *SimpleModel {
 
 	// Only handle JSONB fields that need deep cloning
 
-	// TODO: Tags ([]*Tag) may need manual deep copy handling
+	if original.Tags != nil {
+		clone.Tags = make([]*Tag, len(original.Tags))
+		for i0, v0 := range original.Tags {
+			clone.Tags[i0] = v0.Clone()
+		}
+	}
 
-	// TODO: Items ([]*Item) may need manual deep copy handling
+	if original.Items != nil {
+		clone.Items = make([]*Item, len(original.Items))
+		for i0, v0 := range original.Items {
+			clone.Items[i0] = v0.Clone()
+		}
+	}
 
 	return &clone
 }
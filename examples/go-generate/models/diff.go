@@ -1,3 +1,6 @@
+// Code generated by gorm-gen. DO NOT EDIT.
+// gorm-gen version: (devel)
+
 package models
 
 import (
@@ -6,6 +9,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -15,6 +19,45 @@ func isEmptyJSON(jsonStr string) bool {
 	return trimmed == "{}" || trimmed == "[]" || trimmed == "null"
 }
 
+// jsonbPath formats path as a Postgres text[] literal
+func jsonbPath(path []string) string {
+	quoted := make([]string, len(path))
+	for i, key := range path {
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// jsonbRemove deletes the keys removed from patch (nil values) from the result of merge.
+// The merge writes them as null, so they are deleted again at their full path with #-.
+func jsonbRemove(merge clause.Expr, patch map[string]interface{}) clause.Expr {
+	for _, path := range jsonbRemovedPaths(nil, patch) {
+		merge = gorm.Expr("(?) #- ?::text[]", merge, jsonbPath(path))
+	}
+	return merge
+}
+
+// jsonbRemovedPaths returns the paths of the keys removed from patch, in key order
+func jsonbRemovedPaths(path []string, patch map[string]interface{}) [][]string {
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var removed [][]string
+	for _, key := range keys {
+		keyPath := append(append([]string{}, path...), key)
+		switch value := patch[key].(type) {
+		case nil:
+			removed = append(removed, keyPath)
+		case map[string]interface{}:
+			removed = append(removed, jsonbRemovedPaths(keyPath, value)...)
+		}
+	}
+	return removed
+}
+
 // Diff compares this AccountSettings instance (new) with another (old) and returns a map of differences
 // with only the new values for fields that have changed.
 // Usage: newValues = new.Diff(old)
@@ -97,7 +140,7 @@ func (new *Account) Diff(old *Account) map[string]interface{} {
 		if len(SettingsDiff) > 0 {
 			jsonValue, err := sonic.Marshal(SettingsDiff)
 			if err == nil && !isEmptyJSON(string(jsonValue)) {
-				diff["Settings"] = gorm.Expr("? || ?", clause.Column{Name: "settings"}, string(jsonValue))
+				diff["Settings"] = jsonbRemove(gorm.Expr("? || ?", clause.Column{Name: "settings"}, string(jsonValue)), SettingsDiff)
 			} else if err != nil {
 				// Fallback to regular assignment if JSON marshaling fails
 				diff["Settings"] = new.Settings
@@ -129,7 +172,7 @@ func (new *Account) Diff(old *Account) map[string]interface{} {
 		if len(DataDiff) > 0 {
 			jsonValue, err := sonic.Marshal(DataDiff)
 			if err == nil && !isEmptyJSON(string(jsonValue)) {
-				diff["Data"] = gorm.Expr("? || ?", clause.Column{Name: "data"}, string(jsonValue))
+				diff["Data"] = jsonbRemove(gorm.Expr("? || ?", clause.Column{Name: "data"}, string(jsonValue)), DataDiff)
 			} else if err != nil {
 				// Fallback to regular assignment if JSON marshaling fails
 				diff["Data"] = new.Data
@@ -390,7 +433,7 @@ func (new *ServerPodType) Diff(old *ServerPodType) map[string]interface{} {
 		if len(VersionDiff) > 0 {
 			jsonValue, err := sonic.Marshal(VersionDiff)
 			if err == nil && !isEmptyJSON(string(jsonValue)) {
-				diff["Version"] = gorm.Expr("? || ?", clause.Column{Name: "version"}, string(jsonValue))
+				diff["Version"] = jsonbRemove(gorm.Expr("? || ?", clause.Column{Name: "version"}, string(jsonValue)), VersionDiff)
 			} else if err != nil {
 				// Fallback to regular assignment if JSON marshaling fails
 				diff["Version"] = new.Version
@@ -451,32 +494,38 @@ func (new *ServerPodType) Diff(old *ServerPodType) map[string]interface{} {
 
 	// JSON field comparison - handle both datatypes.JSON and struct types with jsonb storage
 
-	// JSON field comparison - custom slice types with jsonb storage (not comparable with !=)
+	// Replace the whole JSON value, so clearing it to empty is persisted
 	if !reflect.DeepEqual(new.AccountIdWhitelist, old.AccountIdWhitelist) {
 		jsonValue, err := sonic.Marshal(new.AccountIdWhitelist)
-		if err == nil && !isEmptyJSON(string(jsonValue)) {
-			diff["AccountIdWhitelist"] = gorm.Expr("? || ?", clause.Column{Name: "account_id_whitelist"}, string(jsonValue))
-		} else if err != nil {
+		if err == nil {
+			if string(jsonValue) == "null" {
+				// A nil slice clears the column to an empty array
+				jsonValue = []byte("[]")
+			}
+			diff["AccountIdWhitelist"] = string(jsonValue)
+		} else {
 			// Fallback to regular assignment if JSON marshaling fails
 			diff["AccountIdWhitelist"] = new.AccountIdWhitelist
 		}
-		// Skip adding to diff if JSON is empty (no-op update)
 	}
 
 	// Compare ServiceIdWhitelist
 
 	// JSON field comparison - handle both datatypes.JSON and struct types with jsonb storage
 
-	// JSON field comparison - custom slice types with jsonb storage (not comparable with !=)
+	// Replace the whole JSON value, so clearing it to empty is persisted
 	if !reflect.DeepEqual(new.ServiceIdWhitelist, old.ServiceIdWhitelist) {
 		jsonValue, err := sonic.Marshal(new.ServiceIdWhitelist)
-		if err == nil && !isEmptyJSON(string(jsonValue)) {
-			diff["ServiceIdWhitelist"] = gorm.Expr("? || ?", clause.Column{Name: "service_id_whitelist"}, string(jsonValue))
-		} else if err != nil {
+		if err == nil {
+			if string(jsonValue) == "null" {
+				// A nil slice clears the column to an empty array
+				jsonValue = []byte("[]")
+			}
+			diff["ServiceIdWhitelist"] = string(jsonValue)
+		} else {
 			// Fallback to regular assignment if JSON marshaling fails
 			diff["ServiceIdWhitelist"] = new.ServiceIdWhitelist
 		}
-		// Skip adding to diff if JSON is empty (no-op update)
 	}
 
 	// Compare CreatedAt
@@ -528,6 +577,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isSyncing"] = new.IsSyncing
 	}
 
+	if _, changed := diff["isSyncing"]; changed && !new.IsSyncing {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isSyncing"] = nil
+	}
+
 	// Compare IsConnected
 
 	// Simple type comparison
@@ -535,6 +589,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isConnected"] = new.IsConnected
 	}
 
+	if _, changed := diff["isConnected"]; changed && !new.IsConnected {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isConnected"] = nil
+	}
+
 	// Compare IsStarting
 
 	// Simple type comparison
@@ -542,6 +601,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isStarting"] = new.IsStarting
 	}
 
+	if _, changed := diff["isStarting"]; changed && !new.IsStarting {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isStarting"] = nil
+	}
+
 	// Compare IsStarted
 
 	// Simple type comparison
@@ -549,6 +613,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isStarted"] = new.IsStarted
 	}
 
+	if _, changed := diff["isStarted"]; changed && !new.IsStarted {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isStarted"] = nil
+	}
+
 	// Compare IsConflicted
 
 	// Simple type comparison
@@ -556,6 +625,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isConflicted"] = new.IsConflicted
 	}
 
+	if _, changed := diff["isConflicted"]; changed && !new.IsConflicted {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isConflicted"] = nil
+	}
+
 	// Compare IsLoading
 
 	// Simple type comparison
@@ -563,6 +637,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isLoading"] = new.IsLoading
 	}
 
+	if _, changed := diff["isLoading"]; changed && !new.IsLoading {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isLoading"] = nil
+	}
+
 	// Compare IsOnChatPage
 
 	// Simple type comparison
@@ -570,6 +649,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isOnChatPage"] = new.IsOnChatPage
 	}
 
+	if _, changed := diff["isOnChatPage"]; changed && !new.IsOnChatPage {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isOnChatPage"] = nil
+	}
+
 	// Compare EnteredQrCodePageAt
 
 	// Simple type comparison
@@ -577,6 +661,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["enteredQrCodePageAt"] = new.EnteredQrCodePageAt
 	}
 
+	if _, changed := diff["enteredQrCodePageAt"]; changed && new.EnteredQrCodePageAt == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["enteredQrCodePageAt"] = nil
+	}
+
 	// Compare DisconnectedAt
 
 	// Simple type comparison
@@ -584,6 +673,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["disconnectedAt"] = new.DisconnectedAt
 	}
 
+	if _, changed := diff["disconnectedAt"]; changed && new.DisconnectedAt == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["disconnectedAt"] = nil
+	}
+
 	// Compare IsOnQrPage
 
 	// Simple type comparison
@@ -591,6 +685,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isOnQrPage"] = new.IsOnQrPage
 	}
 
+	if _, changed := diff["isOnQrPage"]; changed && !new.IsOnQrPage {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isOnQrPage"] = nil
+	}
+
 	// Compare IsQrCodeExpired
 
 	// Simple type comparison
@@ -598,6 +697,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isQrCodeExpired"] = new.IsQrCodeExpired
 	}
 
+	if _, changed := diff["isQrCodeExpired"]; changed && !new.IsQrCodeExpired {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isQrCodeExpired"] = nil
+	}
+
 	// Compare IsWebConnected
 
 	// Simple type comparison
@@ -605,6 +709,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isWebConnected"] = new.IsWebConnected
 	}
 
+	if _, changed := diff["isWebConnected"]; changed && !new.IsWebConnected {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isWebConnected"] = nil
+	}
+
 	// Compare IsWebSyncing
 
 	// Simple type comparison
@@ -612,6 +721,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["isWebSyncing"] = new.IsWebSyncing
 	}
 
+	if _, changed := diff["isWebSyncing"]; changed && !new.IsWebSyncing {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["isWebSyncing"] = nil
+	}
+
 	// Compare Mode
 
 	// Simple type comparison
@@ -619,6 +733,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["mode"] = new.Mode
 	}
 
+	if _, changed := diff["mode"]; changed && new.Mode == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["mode"] = nil
+	}
+
 	// Compare MyId
 
 	// Simple type comparison
@@ -626,6 +745,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["myId"] = new.MyId
 	}
 
+	if _, changed := diff["myId"]; changed && new.MyId == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["myId"] = nil
+	}
+
 	// Compare MyName
 
 	// Simple type comparison
@@ -633,6 +757,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["myName"] = new.MyName
 	}
 
+	if _, changed := diff["myName"]; changed && new.MyName == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["myName"] = nil
+	}
+
 	// Compare MyNumber
 
 	// Simple type comparison
@@ -640,6 +769,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["myNumber"] = new.MyNumber
 	}
 
+	if _, changed := diff["myNumber"]; changed && new.MyNumber == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["myNumber"] = nil
+	}
+
 	// Compare QrCodeExpiresAt
 
 	// Simple type comparison
@@ -647,6 +781,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["qrCodeExpiresAt"] = new.QrCodeExpiresAt
 	}
 
+	if _, changed := diff["qrCodeExpiresAt"]; changed && new.QrCodeExpiresAt == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["qrCodeExpiresAt"] = nil
+	}
+
 	// Compare QrCodeUrl
 
 	// Simple type comparison
@@ -654,6 +793,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["qrCodeUrl"] = new.QrCodeUrl
 	}
 
+	if _, changed := diff["qrCodeUrl"]; changed && new.QrCodeUrl == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["qrCodeUrl"] = nil
+	}
+
 	// Compare State
 
 	// Simple type comparison
@@ -661,6 +805,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["state"] = new.State
 	}
 
+	if _, changed := diff["state"]; changed && new.State == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["state"] = nil
+	}
+
 	// Compare WaVersion
 
 	// Simple type comparison
@@ -668,6 +817,11 @@ func (new *ServiceDataStatus) Diff(old *ServiceDataStatus) map[string]interface{
 		diff["waVersion"] = new.WaVersion
 	}
 
+	if _, changed := diff["waVersion"]; changed && new.WaVersion == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["waVersion"] = nil
+	}
+
 	return diff
 }
 
@@ -690,6 +844,11 @@ func (new *ServiceData) Diff(old *ServiceData) map[string]interface{} {
 		diff["myId"] = new.MyId
 	}
 
+	if _, changed := diff["myId"]; changed && new.MyId == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["myId"] = nil
+	}
+
 	// Compare LastSyncAt
 
 	// Time comparison
@@ -699,6 +858,11 @@ func (new *ServiceData) Diff(old *ServiceData) map[string]interface{} {
 		diff["lastSyncAt"] = new.LastSyncAt
 	}
 
+	if _, changed := diff["lastSyncAt"]; changed && new.LastSyncAt == nil {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["lastSyncAt"] = nil
+	}
+
 	// Compare LastMessageTimestamp
 
 	// Time comparison
@@ -708,6 +872,11 @@ func (new *ServiceData) Diff(old *ServiceData) map[string]interface{} {
 		diff["lastMessageTimestamp"] = new.LastMessageTimestamp
 	}
 
+	if _, changed := diff["lastMessageTimestamp"]; changed && new.LastMessageTimestamp == nil {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["lastMessageTimestamp"] = nil
+	}
+
 	// Compare SyncCount
 
 	// Simple type comparison
@@ -715,6 +884,11 @@ func (new *ServiceData) Diff(old *ServiceData) map[string]interface{} {
 		diff["syncCount"] = new.SyncCount
 	}
 
+	if _, changed := diff["syncCount"]; changed && new.SyncCount == 0 {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["syncCount"] = nil
+	}
+
 	// Compare SyncFlowDone
 
 	// Simple type comparison
@@ -722,6 +896,11 @@ func (new *ServiceData) Diff(old *ServiceData) map[string]interface{} {
 		diff["syncFlowDone"] = new.SyncFlowDone
 	}
 
+	if _, changed := diff["syncFlowDone"]; changed && !new.SyncFlowDone {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["syncFlowDone"] = nil
+	}
+
 	// Compare Status
 
 	// Struct type comparison - call Diff method directly
@@ -739,6 +918,11 @@ func (new *ServiceData) Diff(old *ServiceData) map[string]interface{} {
 		diff["statusTimestamp"] = new.StatusTimestamp
 	}
 
+	if _, changed := diff["statusTimestamp"]; changed && new.StatusTimestamp == nil {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["statusTimestamp"] = nil
+	}
+
 	return diff
 }
 
@@ -761,6 +945,11 @@ func (new *ServiceSettings) Diff(old *ServiceSettings) map[string]interface{} {
 		diff["keepOnline"] = new.KeepOnline
 	}
 
+	if _, changed := diff["keepOnline"]; changed && !new.KeepOnline {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["keepOnline"] = nil
+	}
+
 	// Compare WppConnectVersion
 
 	// Simple type comparison
@@ -768,6 +957,11 @@ func (new *ServiceSettings) Diff(old *ServiceSettings) map[string]interface{} {
 		diff["wppConnectVersion"] = new.WppConnectVersion
 	}
 
+	if _, changed := diff["wppConnectVersion"]; changed && new.WppConnectVersion == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["wppConnectVersion"] = nil
+	}
+
 	// Compare WaVersion
 
 	// Simple type comparison
@@ -775,6 +969,11 @@ func (new *ServiceSettings) Diff(old *ServiceSettings) map[string]interface{} {
 		diff["waVersion"] = new.WaVersion
 	}
 
+	if _, changed := diff["waVersion"]; changed && new.WaVersion == "" {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["waVersion"] = nil
+	}
+
 	return diff
 }
 
@@ -830,7 +1029,7 @@ func (new *Service) Diff(old *Service) map[string]interface{} {
 		if len(DataDiff) > 0 {
 			jsonValue, err := sonic.Marshal(DataDiff)
 			if err == nil && !isEmptyJSON(string(jsonValue)) {
-				diff["Data"] = gorm.Expr("? || ?", clause.Column{Name: "data"}, string(jsonValue))
+				diff["Data"] = jsonbRemove(gorm.Expr("? || ?", clause.Column{Name: "data"}, string(jsonValue)), DataDiff)
 			} else if err != nil {
 				// Fallback to regular assignment if JSON marshaling fails
 				diff["Data"] = new.Data
@@ -862,7 +1061,7 @@ func (new *Service) Diff(old *Service) map[string]interface{} {
 		if len(SettingsDiff) > 0 {
 			jsonValue, err := sonic.Marshal(SettingsDiff)
 			if err == nil && !isEmptyJSON(string(jsonValue)) {
-				diff["Settings"] = gorm.Expr("? || ?", clause.Column{Name: "settings"}, string(jsonValue))
+				diff["Settings"] = jsonbRemove(gorm.Expr("? || ?", clause.Column{Name: "settings"}, string(jsonValue)), SettingsDiff)
 			} else if err != nil {
 				// Fallback to regular assignment if JSON marshaling fails
 				diff["Settings"] = new.Settings
@@ -994,6 +1193,11 @@ func (new *Item) Diff(old *Item) map[string]interface{} {
 		diff["price"] = new.Price
 	}
 
+	if _, changed := diff["price"]; changed && new.Price == 0 {
+		// Omitted from the JSON when empty - nil marks the key for removal
+		diff["price"] = nil
+	}
+
 	return diff
 }
 
@@ -1029,32 +1233,38 @@ func (new *SimpleModel) Diff(old *SimpleModel) map[string]interface{} {
 
 	// JSON field comparison - handle both datatypes.JSON and struct types with jsonb storage
 
-	// JSON field comparison - custom slice types with jsonb storage (not comparable with !=)
+	// Replace the whole JSON value, so clearing it to empty is persisted
 	if !reflect.DeepEqual(new.Tags, old.Tags) {
 		jsonValue, err := sonic.Marshal(new.Tags)
-		if err == nil && !isEmptyJSON(string(jsonValue)) {
-			diff["Tags"] = gorm.Expr("? || ?", clause.Column{Name: "tags"}, string(jsonValue))
-		} else if err != nil {
+		if err == nil {
+			if string(jsonValue) == "null" {
+				// A nil slice clears the column to an empty array
+				jsonValue = []byte("[]")
+			}
+			diff["Tags"] = string(jsonValue)
+		} else {
 			// Fallback to regular assignment if JSON marshaling fails
 			diff["Tags"] = new.Tags
 		}
-		// Skip adding to diff if JSON is empty (no-op update)
 	}
 
 	// Compare Items
 
 	// JSON field comparison - handle both datatypes.JSON and struct types with jsonb storage
 
-	// JSON field comparison - custom slice types with jsonb storage (not comparable with !=)
+	// Replace the whole JSON value, so clearing it to empty is persisted
 	if !reflect.DeepEqual(new.Items, old.Items) {
 		jsonValue, err := sonic.Marshal(new.Items)
-		if err == nil && !isEmptyJSON(string(jsonValue)) {
-			diff["Items"] = gorm.Expr("? || ?", clause.Column{Name: "items"}, string(jsonValue))
-		} else if err != nil {
+		if err == nil {
+			if string(jsonValue) == "null" {
+				// A nil slice clears the column to an empty array
+				jsonValue = []byte("[]")
+			}
+			diff["Items"] = string(jsonValue)
+		} else {
 			// Fallback to regular assignment if JSON marshaling fails
 			diff["Items"] = new.Items
 		}
-		// Skip adding to diff if JSON is empty (no-op update)
 	}
 
 	return diff
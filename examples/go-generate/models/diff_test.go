@@ -216,3 +216,57 @@ func TestGormExpressionGeneration(t *testing.T) {
 	expr := gorm.Expr("? || ?", clause.Column{Name: "test"}, `{"key": "value"}`)
 	_ = expr // Just verify it compiles
 }
+
+func TestReplaceJSONArrayClear(t *testing.T) {
+	old := &ServerPodType{
+		AccountIdWhitelist: JsonbStringSlice{"account-1", "account-2"},
+		ServiceIdWhitelist: JsonbStringSlice{"service-1"},
+	}
+
+	// Both lists are cleared intentionally
+	podType := &ServerPodType{
+		AccountIdWhitelist: JsonbStringSlice{},
+		ServiceIdWhitelist: JsonbStringSlice{},
+	}
+
+	diff := podType.Diff(old)
+
+	// Arrays are written as a whole, so the empty lists are persisted
+	if diff["AccountIdWhitelist"] != "[]" {
+		t.Errorf("Expected AccountIdWhitelist to be replaced with [], got %v", diff["AccountIdWhitelist"])
+	}
+	if diff["ServiceIdWhitelist"] != "[]" {
+		t.Errorf("Expected ServiceIdWhitelist to be replaced with [], got %v", diff["ServiceIdWhitelist"])
+	}
+
+	// A nil slice clears the column to an empty array as well
+	podType.AccountIdWhitelist = nil
+	if diff := podType.Diff(old); diff["AccountIdWhitelist"] != "[]" {
+		t.Errorf("Expected nil AccountIdWhitelist to be replaced with [], got %v", diff["AccountIdWhitelist"])
+	}
+
+	// Non-empty lists are replaced, not appended to
+	podType.AccountIdWhitelist = JsonbStringSlice{"account-3"}
+	if diff := podType.Diff(old); diff["AccountIdWhitelist"] != `["account-3"]` {
+		t.Errorf("Expected AccountIdWhitelist to be replaced, got %v", diff["AccountIdWhitelist"])
+	}
+}
+
+func TestCloneArrayElementsAreIndependent(t *testing.T) {
+	original := &SimpleModel{
+		Name: "original",
+		Tags: []*Tag{{Name: "env", Value: "prod"}},
+	}
+
+	clone := original.Clone()
+	clone.Tags[0].Value = "staging"
+
+	if original.Tags[0].Value != "prod" {
+		t.Fatalf("Expected clone to have its own Tag elements, original changed to %q", original.Tags[0].Value)
+	}
+
+	diff := clone.Diff(original)
+	if _, ok := diff["Tags"]; !ok {
+		t.Errorf("Expected Tags change made through the clone to be in diff, got %v", diff)
+	}
+}
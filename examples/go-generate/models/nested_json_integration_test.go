@@ -366,3 +366,88 @@ func isGormExpr(v interface{}) bool {
 	// Check for clause.Expr (which is what gorm.Expr returns)
 	return t.String() == "clause.Expr" || strings.Contains(t.String(), "clause.Expr")
 }
+
+func TestJSONBKeyRemoval(t *testing.T) {
+	now := time.Now()
+
+	old := &Service{
+		Data: &ServiceData{
+			MyId:       "test123",
+			SyncCount:  5,
+			LastSyncAt: &now,
+			Status:     ServiceDataStatus{Mode: "sync", IsConnected: true},
+		},
+	}
+
+	service := &Service{
+		Data: &ServiceData{
+			MyId:      "test123",
+			SyncCount: 6,                                    // Changed
+			Status:    ServiceDataStatus{IsConnected: true}, // Mode cleared
+			// LastSyncAt cleared
+		},
+	}
+
+	// Nested diff marks cleared omitempty fields with nil
+	dataDiff := service.Data.Diff(old.Data)
+	if value, ok := dataDiff["lastSyncAt"]; !ok || value != nil {
+		t.Errorf("Expected lastSyncAt to be marked for removal, got %v (present: %v)", value, ok)
+	}
+	if status, ok := dataDiff["status"].(map[string]interface{}); !ok || status["mode"] != nil {
+		t.Errorf("Expected status.mode to be marked for removal, got %v", dataDiff["status"])
+	}
+
+	// The root merge deletes the removed keys at their full path
+	expr, ok := service.Diff(old)["Data"].(clause.Expr)
+	if !ok {
+		t.Fatalf("Expected Data diff to be clause.Expr, got %T", service.Diff(old)["Data"])
+	}
+	if expr.SQL != "(?) #- ?::text[]" {
+		t.Errorf("Expected outer removal expression, got %q", expr.SQL)
+	}
+
+	var paths []string
+	for current := expr; ; {
+		paths = append(paths, current.Vars[1].(string))
+		inner, ok := current.Vars[0].(clause.Expr)
+		if !ok || inner.SQL == "? || ?" {
+			break
+		}
+		current = inner
+	}
+
+	expectedPaths := []string{`{"status","mode"}`, `{"lastSyncAt"}`}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("Expected removed paths %v, got %v", expectedPaths, paths)
+	}
+}
+
+func TestClonePointerValuesAreIndependent(t *testing.T) {
+	now := time.Now()
+	podID := uuid.New()
+	original := &Service{
+		ServerPodId: &podID,
+		Data: &ServiceData{
+			LastSyncAt: &now,
+		},
+	}
+
+	clone := original.Clone()
+	*clone.Data.LastSyncAt = now.Add(time.Hour)
+	*clone.ServerPodId = uuid.New()
+
+	if !original.Data.LastSyncAt.Equal(now) {
+		t.Errorf("Expected original LastSyncAt to be unchanged, got %v", original.Data.LastSyncAt)
+	}
+	if *original.ServerPodId != podID {
+		t.Errorf("Expected original ServerPodId to be unchanged, got %v", original.ServerPodId)
+	}
+
+	diff := clone.Diff(original)
+	if _, ok := diff["ServerPodId"]; !ok {
+		t.Errorf("Expected ServerPodId written through the clone to be in diff, got %v", diff)
+	}
+	if _, ok := diff["Data"]; !ok {
+		t.Errorf("Expected LastSyncAt written through the clone to be in diff, got %v", diff)
+	}
+}
@@ -1,3 +1,6 @@
+// Code generated by gorm-gen. DO NOT EDIT.
+// gorm-gen version: (devel)
+
 package structs
 
 // Clone creates a deep copy of the Address struct
@@ -28,29 +31,19 @@ func (original *Person) Clone() *Person {
 	// Create new instance and copy all simple fields
 	clone := *original
 
-	// Only handle the fields that need deep cloning
-
-	clone.Address = *(&original.Address).Clone()
+	// Only handle JSONB fields that need deep cloning
 
 	if original.Contacts != nil {
 		clone.Contacts = make([]Contact, len(original.Contacts))
-
-		for i, item := range original.Contacts {
-			clone.Contacts[i] = *item.Clone()
+		for i0, v0 := range original.Contacts {
+			clone.Contacts[i0] = *v0.Clone()
 		}
-
-	}
-
-	if original.Manager != nil {
-		clone.Manager = original.Manager.Clone()
 	}
 
 	if original.Metadata != nil {
 		clone.Metadata = make(map[string]interface{})
 		for k, v := range original.Metadata {
-
 			clone.Metadata[k] = v
-
 		}
 	}
 
@@ -65,21 +58,13 @@ func (original *Company) Clone() *Company {
 	// Create new instance and copy all simple fields
 	clone := *original
 
-	// Only handle the fields that need deep cloning
-
-	clone.Address = *(&original.Address).Clone()
+	// Only handle JSONB fields that need deep cloning
 
 	if original.Employees != nil {
 		clone.Employees = make([]Person, len(original.Employees))
-
-		for i, item := range original.Employees {
-			clone.Employees[i] = *item.Clone()
+		for i0, v0 := range original.Employees {
+			clone.Employees[i0] = *v0.Clone()
 		}
-
-	}
-
-	if original.CEO != nil {
-		clone.CEO = original.CEO.Clone()
 	}
 
 	return &clone
@@ -93,40 +78,24 @@ func (original *Project) Clone() *Project {
 	// Create new instance and copy all simple fields
 	clone := *original
 
-	// Only handle the fields that need deep cloning
-
-	if original.TeamLead != nil {
-		clone.TeamLead = original.TeamLead.Clone()
-	}
+	// Only handle JSONB fields that need deep cloning
 
 	if original.Members != nil {
 		clone.Members = make([]*Person, len(original.Members))
-
-		for i, item := range original.Members {
-			if item != nil {
-				clone.Members[i] = item.Clone()
-			}
+		for i0, v0 := range original.Members {
+			clone.Members[i0] = v0.Clone()
 		}
-
-	}
-
-	if original.Company != nil {
-		clone.Company = original.Company.Clone()
 	}
 
 	if original.Tags != nil {
 		clone.Tags = make([]string, len(original.Tags))
-
 		copy(clone.Tags, original.Tags)
-
 	}
 
 	if original.Properties != nil {
 		clone.Properties = make(map[string]string)
 		for k, v := range original.Properties {
-
 			clone.Properties[k] = v
-
 		}
 	}
 
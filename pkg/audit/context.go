@@ -0,0 +1,20 @@
+package audit
+
+import "context"
+
+// actorKey is the context key of the actor of an update
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor recorded in audit entries
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx by WithActor, or an empty string
+func ActorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
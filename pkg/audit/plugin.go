@@ -0,0 +1,358 @@
+// Package audit provides a GORM plugin that writes a change history row for every
+// tracked update of the models it is enabled for.
+//
+// It builds on the tracker plugin: when a tracked Save writes the diff of a model, the
+// audit plugin compares the model with its snapshot and records the changed fields
+// with their old and new values, the table, the primary key, the actor and the time:
+//
+//	tracked := tracker.New()
+//	db.Use(tracked)
+//
+//	auditor := audit.New(tracked, audit.Config{})
+//	auditor.Enable(&models.Service{})
+//	db.Use(auditor)
+//
+//	ctx := audit.WithActor(context.Background(), "jane@example.com")
+//	db.WithContext(ctx).Save(&service) // UPDATE "services" ...; INSERT INTO "audit_logs" ...
+//
+// Models that implement the Changes method generated with -types=changes are audited
+// per key inside @jsonb columns. Other models are audited per changed column.
+//
+// Entries are written in the transaction of the update they describe. Within Transaction
+// they are buffered and written in batches, at the latest just before the commit:
+//
+//	err := auditor.Transaction(db, func(tx *gorm.DB) error {
+//		tx.Save(&service)
+//		return tx.Save(&plan).Error
+//	}) // Both entries are written in one INSERT, or not at all if the transaction fails
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/tracker"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// pluginName is the name the plugin is registered under
+	pluginName = "gorm-tracked-updates:audit"
+
+	// DefaultTableName is the table audit entries are written to by default
+	DefaultTableName = "audit_logs"
+)
+
+// Entry is a row of the audit log table
+type Entry struct {
+	ID         uint           `gorm:"primaryKey"`
+	Table      string         `gorm:"column:table_name;size:255;index"`
+	PrimaryKey string         `gorm:"size:255;index"`
+	Actor      string         `gorm:"size:255"`
+	Changes    datatypes.JSON // Changed fields as a JSON array of changeset.FieldChange
+	CreatedAt  time.Time
+}
+
+// Config configures the audit plugin
+type Config struct {
+	// TableName is the table entries are written to, defaults to DefaultTableName
+	TableName string
+
+	// BatchSize is the number of entries of a transaction begun with Transaction that
+	// are buffered before they are written, defaults to 1. Updates outside Transaction
+	// write their entry right away.
+	BatchSize int
+
+	// Actor returns the actor of an update from its context, defaults to ActorFromContext
+	Actor func(ctx context.Context) string
+
+	// Now returns the time of an entry, defaults to time.Now
+	Now func() time.Time
+}
+
+// Plugin is a gorm.Plugin that writes audit entries for tracked updates
+type Plugin struct {
+	tracker *tracker.Plugin
+	config  Config
+
+	mu      sync.Mutex
+	enabled map[reflect.Type]bool     // Struct types of the audited models
+	pending map[gorm.ConnPool][]Entry // Entries buffered by transaction begun with Transaction
+}
+
+// New creates a new audit plugin reading snapshots from the given tracker plugin, which
+// must be registered on the same *gorm.DB before it
+func New(tracker *tracker.Plugin, config Config) *Plugin {
+	if config.TableName == "" {
+		config.TableName = DefaultTableName
+	}
+	if config.BatchSize < 1 {
+		config.BatchSize = 1
+	}
+	if config.Actor == nil {
+		config.Actor = ActorFromContext
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &Plugin{
+		tracker: tracker,
+		config:  config,
+		enabled: make(map[reflect.Type]bool),
+		pending: make(map[gorm.ConnPool][]Entry),
+	}
+}
+
+// Name returns the plugin name, implementing gorm.Plugin
+func (p *Plugin) Name() string {
+	return pluginName
+}
+
+// Initialize registers the plugin callback, implementing gorm.Plugin
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	// The callback is ordered relative to those of the tracker, which must exist by now
+	if db.Plugins[p.tracker.Name()] != p.tracker {
+		return fmt.Errorf("audit: the tracker plugin must be registered before the audit plugin")
+	}
+
+	// Runs after the diff was written and its version checked, so stale updates are not
+	// audited, but before the tracker refreshes the snapshot
+	return db.Callback().Update().After("tracker:check_version").Before("gorm:after_update").
+		Register("audit:write", p.audit)
+}
+
+// Enable turns auditing on for the types of the given models
+func (p *Plugin) Enable(models ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, model := range models {
+		p.enabled[structType(reflect.TypeOf(model))] = true
+	}
+}
+
+// Migrate creates or updates the audit log table
+func (p *Plugin) Migrate(db *gorm.DB) error {
+	return db.Table(p.config.TableName).AutoMigrate(&Entry{})
+}
+
+// Transaction runs fc in a transaction like gorm.DB.Transaction, buffering the entries
+// of its updates in batches of Config.BatchSize. The entries still buffered when fc
+// returns are written before the transaction commits, and discarded if it rolls back.
+func (p *Plugin) Transaction(db *gorm.DB, fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	if pool := db.Statement.ConnPool; p.isBuffering(pool) {
+		// Nested: the entries of the enclosing transaction are written before its savepoint,
+		// so rolling back to the savepoint only discards the entries of fc
+		if err := p.Flush(db); err != nil {
+			return err
+		}
+		return db.Transaction(func(tx *gorm.DB) error {
+			err := fc(tx)
+			if err != nil {
+				p.discard(pool, false)
+			}
+			return err
+		}, opts...)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		pool := tx.Statement.ConnPool
+		p.mu.Lock()
+		p.pending[pool] = nil
+		p.mu.Unlock()
+		defer p.discard(pool, true)
+
+		if err := fc(tx); err != nil {
+			return err
+		}
+		return p.Flush(tx)
+	}, opts...)
+}
+
+// Flush writes the entries buffered for the transaction of db, begun with Transaction.
+// Entries that fail to be written stay buffered.
+func (p *Plugin) Flush(db *gorm.DB) error {
+	pool := db.Statement.ConnPool
+	p.mu.Lock()
+	entries := p.pending[pool]
+	p.mu.Unlock()
+
+	if err := p.write(db.Session(&gorm.Session{NewDB: true}), entries); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if buffered, ok := p.pending[pool]; ok {
+		p.pending[pool] = buffered[len(entries):]
+	}
+	p.mu.Unlock()
+	return nil
+}
+
+// isBuffering reports whether pool is the connection of a transaction begun with Transaction
+func (p *Plugin) isBuffering(pool gorm.ConnPool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, ok := p.pending[pool]
+	return ok
+}
+
+// discard drops the entries buffered for pool, and stops buffering for it if end is set
+func (p *Plugin) discard(pool gorm.ConnPool, end bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if end {
+		delete(p.pending, pool)
+	} else if _, ok := p.pending[pool]; ok {
+		p.pending[pool] = nil
+	}
+}
+
+// audit records the changes of a tracked update of an audited model
+func (p *Plugin) audit(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || db.RowsAffected == 0 || stmt.Schema == nil || !tracker.IsTrackedUpdate(db) {
+		return
+	}
+
+	ptr := reflect.ValueOf(stmt.Model)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || !p.isEnabled(ptr.Type()) {
+		return
+	}
+
+	snapshot, ok := p.tracker.Snapshot(stmt.Model)
+	if !ok {
+		return
+	}
+
+	changes := p.changes(stmt, ptr, reflect.ValueOf(snapshot))
+	if len(changes) == 0 {
+		return
+	}
+
+	document, err := json.Marshal(changes)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: encoding changes of %s: %w", stmt.Table, err))
+		return
+	}
+
+	entry := Entry{
+		Table:      stmt.Table,
+		PrimaryKey: primaryKey(stmt, ptr),
+		Actor:      p.config.Actor(stmt.Context),
+		Changes:    datatypes.JSON(document),
+		CreatedAt:  p.config.Now(),
+	}
+
+	// Buffered within Transaction until the batch is full, else written right away, in
+	// the transaction of the update either way
+	p.mu.Lock()
+	entries, buffered := p.pending[stmt.ConnPool]
+	if buffered {
+		entries = append(entries, entry)
+		p.pending[stmt.ConnPool] = entries
+	}
+	p.mu.Unlock()
+
+	err = nil
+	if !buffered {
+		err = p.write(db.Session(&gorm.Session{NewDB: true}), []Entry{entry})
+	} else if len(entries) >= p.config.BatchSize {
+		err = p.Flush(db)
+	}
+	if err != nil {
+		db.AddError(err)
+	}
+}
+
+// write inserts entries into the audit log table
+func (p *Plugin) write(db *gorm.DB, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := db.Table(p.config.TableName).CreateInBatches(entries, p.config.BatchSize).Error; err != nil {
+		return fmt.Errorf("audit: writing %s: %w", p.config.TableName, err)
+	}
+	return nil
+}
+
+// isEnabled reports whether auditing is turned on for the model type ptrType points to
+func (p *Plugin) isEnabled(ptrType reflect.Type) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.enabled[ptrType.Elem()]
+}
+
+// changes returns the changes of the model pointed to by ptr relative to snapshot, from its
+// generated Changes method if it has one, else per changed column of its Diff
+func (p *Plugin) changes(stmt *gorm.Statement, ptr, snapshot reflect.Value) []changeset.FieldChange {
+	if method := changesMethod(ptr.Type()); method.IsValid() {
+		changes, _ := method.Call([]reflect.Value{ptr, snapshot})[0].Interface().([]changeset.FieldChange)
+		return changes
+	}
+
+	diff, _ := p.tracker.Diff(stmt.Model)
+	keys := make([]string, 0, len(diff))
+	for key := range diff {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changes := make([]changeset.FieldChange, 0, len(keys))
+	for _, key := range keys {
+		change := changeset.FieldChange{Field: key}
+		if field := stmt.Schema.LookUpField(key); field != nil {
+			change.Column = field.DBName
+			change.Old = field.ReflectValueOf(stmt.Context, snapshot.Elem()).Interface()
+			change.New = field.ReflectValueOf(stmt.Context, ptr.Elem()).Interface()
+		} else {
+			change.New = diff[key]
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+var changesType = reflect.TypeOf([]changeset.FieldChange(nil))
+
+// changesMethod returns the generated Changes method of ptrType, or an invalid value
+func changesMethod(ptrType reflect.Type) reflect.Value {
+	method, ok := ptrType.MethodByName("Changes")
+	if !ok || method.Type.NumIn() != 2 || method.Type.In(1) != ptrType ||
+		method.Type.NumOut() != 1 || method.Type.Out(0) != changesType {
+		return reflect.Value{}
+	}
+	return method.Func
+}
+
+// primaryKey returns the primary key of the model pointed to by ptr, with the values of
+// composite keys joined by commas
+func primaryKey(stmt *gorm.Statement, ptr reflect.Value) string {
+	values := make([]string, 0, len(stmt.Schema.PrimaryFields))
+	for _, field := range stmt.Schema.PrimaryFields {
+		value, _ := field.ValueOf(stmt.Context, ptr.Elem())
+		values = append(values, fmt.Sprint(value))
+	}
+	return strings.Join(values, ",")
+}
+
+// structType returns the struct type behind pointers
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,425 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/tracker"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Test model with Clone/Diff/Changes methods (simulating generated code)
+type TestAccount struct {
+	ID    uint
+	Name  string
+	Email string
+}
+
+func (original *TestAccount) Clone() *TestAccount {
+	if original == nil {
+		return nil
+	}
+	clone := *original
+	return &clone
+}
+
+func (new *TestAccount) Diff(old *TestAccount) map[string]interface{} {
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+	if new.Name != old.Name {
+		diff["Name"] = new.Name
+	}
+	if new.Email != old.Email {
+		diff["Email"] = new.Email
+	}
+	return diff
+}
+
+func (new *TestAccount) Changes(old *TestAccount) []changeset.FieldChange {
+	diff := new.Diff(old)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	var changes []changeset.FieldChange
+	if _, changed := diff["Name"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Name", Column: "name", Old: old.Name, New: new.Name})
+	}
+	if _, changed := diff["Email"]; changed {
+		changes = append(changes, changeset.FieldChange{Field: "Email", Column: "email", Old: old.Email, New: new.Email})
+	}
+	return changes
+}
+
+// Test model with only Clone/Diff methods
+type TestProject struct {
+	ID    uint
+	Title string
+	Stars int
+}
+
+func (original *TestProject) Clone() *TestProject {
+	if original == nil {
+		return nil
+	}
+	clone := *original
+	return &clone
+}
+
+func (new *TestProject) Diff(old *TestProject) map[string]interface{} {
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+	if new.Title != old.Title {
+		diff["Title"] = new.Title
+	}
+	if new.Stars != old.Stars {
+		diff["Stars"] = new.Stars
+	}
+	return diff
+}
+
+var testTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func setupDB(t *testing.T, config Config) (*gorm.DB, *Plugin) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+
+	tracked := tracker.New()
+	if err := db.Use(tracked); err != nil {
+		t.Fatalf("Error registering tracker: %v", err)
+	}
+
+	config.Now = func() time.Time { return testTime }
+	auditor := New(tracked, config)
+	auditor.Enable(&TestAccount{}, TestProject{})
+	if err := db.Use(auditor); err != nil {
+		t.Fatalf("Error registering audit plugin: %v", err)
+	}
+
+	if err := db.AutoMigrate(&TestAccount{}, &TestProject{}); err != nil {
+		t.Fatalf("Error migrating: %v", err)
+	}
+	if err := auditor.Migrate(db); err != nil {
+		t.Fatalf("Error migrating audit log: %v", err)
+	}
+
+	return db, auditor
+}
+
+func loadEntries(t *testing.T, db *gorm.DB, table string) []Entry {
+	t.Helper()
+
+	var entries []Entry
+	if err := db.Table(table).Order("id").Find(&entries).Error; err != nil {
+		t.Fatalf("Error loading audit entries: %v", err)
+	}
+	return entries
+}
+
+func TestTrackedUpdateWritesEntry(t *testing.T) {
+	db, _ := setupDB(t, Config{})
+
+	db.Create(&TestAccount{Name: "John", Email: "john@example.com"})
+
+	var account TestAccount
+	db.First(&account)
+	account.Name = "Jane"
+
+	ctx := WithActor(context.Background(), "admin@example.com")
+	if err := db.WithContext(ctx).Save(&account).Error; err != nil {
+		t.Fatalf("Error saving account: %v", err)
+	}
+
+	entries := loadEntries(t, db, DefaultTableName)
+	if len(entries) != 1 {
+		t.Fatalf("Expected one audit entry, got %+v", entries)
+	}
+
+	entry := entries[0]
+	if entry.Table != "test_accounts" || entry.PrimaryKey != "1" || entry.Actor != "admin@example.com" {
+		t.Errorf("Unexpected audit entry %+v", entry)
+	}
+	if !entry.CreatedAt.Equal(testTime) {
+		t.Errorf("Expected entry time %v, got %v", testTime, entry.CreatedAt)
+	}
+
+	var changes []changeset.FieldChange
+	if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+		t.Fatalf("Error decoding changes: %v", err)
+	}
+	expected := []changeset.FieldChange{{Field: "Name", Column: "name", Old: "John", New: "Jane"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %+v, got %+v", expected, changes)
+	}
+
+	// Nothing changed since the save: no further entry
+	db.Save(&account)
+	if entries := loadEntries(t, db, DefaultTableName); len(entries) != 1 {
+		t.Errorf("Expected unchanged save not to be audited, got %d entries", len(entries))
+	}
+}
+
+func TestModelsWithoutChangesAreAuditedPerColumn(t *testing.T) {
+	db, _ := setupDB(t, Config{})
+
+	db.Create(&TestProject{Title: "Draft", Stars: 1})
+
+	var project TestProject
+	db.First(&project)
+	project.Title = "Release"
+	project.Stars = 2
+	db.Save(&project)
+
+	entries := loadEntries(t, db, DefaultTableName)
+	if len(entries) != 1 {
+		t.Fatalf("Expected one audit entry, got %+v", entries)
+	}
+
+	var changes []changeset.FieldChange
+	if err := json.Unmarshal(entries[0].Changes, &changes); err != nil {
+		t.Fatalf("Error decoding changes: %v", err)
+	}
+	expected := []changeset.FieldChange{
+		{Field: "Stars", Column: "stars", Old: float64(1), New: float64(2)},
+		{Field: "Title", Column: "title", Old: "Draft", New: "Release"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %+v, got %+v", expected, changes)
+	}
+}
+
+func TestOnlyEnabledModelsAreAudited(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+
+	tracked := tracker.New()
+	db.Use(tracked)
+	auditor := New(tracked, Config{TableName: "history"})
+	auditor.Enable(&TestProject{})
+	db.Use(auditor)
+	db.AutoMigrate(&TestAccount{})
+	auditor.Migrate(db)
+
+	db.Create(&TestAccount{Name: "John"})
+	var account TestAccount
+	db.First(&account)
+	account.Name = "Jane"
+	db.Save(&account)
+
+	if entries := loadEntries(t, db, "history"); len(entries) != 0 {
+		t.Errorf("Expected model without auditing not to be audited, got %+v", entries)
+	}
+}
+
+// Test model with a version field tagged gorm:"version"
+type TestVersionedAccount struct {
+	ID      uint
+	Name    string
+	Version int `gorm:"version"`
+}
+
+func (original *TestVersionedAccount) Clone() *TestVersionedAccount {
+	if original == nil {
+		return nil
+	}
+	clone := *original
+	return &clone
+}
+
+func (new *TestVersionedAccount) Diff(old *TestVersionedAccount) map[string]interface{} {
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+	if new.Name != old.Name {
+		diff["Name"] = new.Name
+	}
+	return diff
+}
+
+func TestBatchingInTransaction(t *testing.T) {
+	db, auditor := setupDB(t, Config{TableName: "account_history", BatchSize: 2})
+
+	db.Create(&[]TestAccount{{Name: "A"}, {Name: "B"}, {Name: "C"}})
+
+	var accounts []TestAccount
+	db.Order("id").Find(&accounts)
+
+	err := auditor.Transaction(db, func(tx *gorm.DB) error {
+		accounts[0].Name = "A2"
+		tx.Save(&accounts[0])
+		if entries := loadEntries(t, tx, "account_history"); len(entries) != 0 {
+			t.Fatalf("Expected entry to be buffered until the batch is full, got %+v", entries)
+		}
+
+		accounts[1].Name = "B2"
+		tx.Save(&accounts[1])
+		if entries := loadEntries(t, tx, "account_history"); len(entries) != 2 {
+			t.Fatalf("Expected full batch to be written, got %+v", entries)
+		}
+
+		accounts[2].Name = "C2"
+		return tx.Save(&accounts[2]).Error
+	})
+	if err != nil {
+		t.Fatalf("Error in transaction: %v", err)
+	}
+
+	// The rest of the batch is written on commit
+	entries := loadEntries(t, db, "account_history")
+	if len(entries) != 3 || entries[2].PrimaryKey != "3" {
+		t.Errorf("Expected entry for account 3 written on commit, got %+v", entries)
+	}
+	if len(auditor.pending) != 0 {
+		t.Errorf("Expected no buffers left after the transaction, got %v", auditor.pending)
+	}
+}
+
+func TestRolledBackTransactionWritesNoEntries(t *testing.T) {
+	db, auditor := setupDB(t, Config{BatchSize: 2})
+
+	db.Create(&[]TestAccount{{Name: "A"}, {Name: "B"}, {Name: "C"}})
+
+	var accounts []TestAccount
+	db.Order("id").Find(&accounts)
+
+	rollback := errors.New("rollback")
+	err := auditor.Transaction(db, func(tx *gorm.DB) error {
+		accounts[0].Name = "A2"
+		tx.Save(&accounts[0])
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("Expected the transaction to fail, got %v", err)
+	}
+
+	// A later transaction does not write the entries of the rolled back one
+	err = auditor.Transaction(db, func(tx *gorm.DB) error {
+		accounts[1].Name = "B2"
+		return tx.Save(&accounts[1]).Error
+	})
+	if err != nil {
+		t.Fatalf("Error in transaction: %v", err)
+	}
+
+	// Nor does a rolled back nested transaction, but the enclosing one keeps its entries
+	err = auditor.Transaction(db, func(tx *gorm.DB) error {
+		accounts[2].Name = "C2"
+		tx.Save(&accounts[2])
+		auditor.Transaction(tx, func(tx *gorm.DB) error {
+			accounts[1].Name = "B3"
+			tx.Save(&accounts[1])
+			return rollback
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error in transaction: %v", err)
+	}
+
+	entries := loadEntries(t, db, DefaultTableName)
+	if len(entries) != 2 || entries[0].PrimaryKey != "2" || entries[1].PrimaryKey != "3" {
+		t.Errorf("Expected entries of accounts 2 and 3 only, got %+v", entries)
+	}
+	if len(auditor.pending) != 0 {
+		t.Errorf("Expected no buffers left after the transactions, got %v", auditor.pending)
+	}
+}
+
+func TestFailedWriteKeepsEntriesBuffered(t *testing.T) {
+	db, auditor := setupDB(t, Config{TableName: "account_history", BatchSize: 2})
+
+	db.Create(&TestAccount{Name: "A"})
+
+	var account TestAccount
+	db.First(&account)
+
+	auditor.Transaction(db, func(tx *gorm.DB) error {
+		account.Name = "A2"
+		tx.Save(&account)
+
+		tx.Migrator().DropTable("account_history")
+		if err := auditor.Flush(tx); err == nil {
+			t.Fatalf("Expected flush to fail without the audit table")
+		}
+		if entries := auditor.pending[tx.Statement.ConnPool]; len(entries) != 1 {
+			t.Fatalf("Expected the entry to stay buffered, got %+v", entries)
+		}
+
+		if err := auditor.Migrate(tx); err != nil {
+			t.Fatalf("Error migrating audit log: %v", err)
+		}
+		return nil
+	})
+
+	if entries := loadEntries(t, db, "account_history"); len(entries) != 1 {
+		t.Errorf("Expected the buffered entry written on commit, got %+v", entries)
+	}
+}
+
+func TestStaleUpdateIsNotAudited(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+
+	// The audit callback is ordered after the version check of the tracker, so the
+	// tracker must come first
+	tracked := tracker.New()
+	auditor := New(tracked, Config{})
+	auditor.Enable(&TestVersionedAccount{})
+	if err := db.Use(auditor); err == nil {
+		t.Fatalf("Expected an error registering the audit plugin before the tracker")
+	}
+	db.Use(tracked)
+	if err := db.Use(auditor); err != nil {
+		t.Fatalf("Error registering audit plugin: %v", err)
+	}
+	db.AutoMigrate(&TestVersionedAccount{})
+	auditor.Migrate(db)
+
+	db.Create(&TestVersionedAccount{Name: "John", Version: 1})
+
+	var first, second TestVersionedAccount
+	db.First(&first)
+	db.First(&second)
+
+	first.Name = "Jane"
+	if err := db.Save(&first).Error; err != nil {
+		t.Fatalf("Error saving account: %v", err)
+	}
+
+	// Without a transaction to roll back, only the order of the callbacks keeps the
+	// stale update from being audited
+	second.Name = "Joan"
+	err = db.Session(&gorm.Session{SkipDefaultTransaction: true}).Save(&second).Error
+	if !errors.Is(err, tracker.ErrStaleObject) {
+		t.Fatalf("Expected ErrStaleObject, got %v", err)
+	}
+
+	entries := loadEntries(t, db, DefaultTableName)
+	if len(entries) != 1 || !strings.Contains(string(entries[0].Changes), "Jane") {
+		t.Errorf("Expected only the entry of the successful update, got %+v", entries)
+	}
+}
@@ -0,0 +1,41 @@
+// Package changeset holds the types returned by the Changes methods generated by diffgen.
+//
+// Where a generated Diff returns only the new values of the changed columns, ready to be
+// passed to GORM's Updates, Changes returns one FieldChange per changed field with both
+// its old and new value. Changes inside @jsonb columns are reported per key with their
+// full JSON path, so they can be logged or audited:
+//
+//	for _, change := range service.Changes(snapshot) {
+//		log.Printf("%s changed from %v to %v", change.Field, change.Old, change.New)
+//	}
+package changeset
+
+import "strings"
+
+// FieldChange describes the change of a single field
+type FieldChange struct {
+	Field  string      `json:"field"`            // Go field path, such as "Settings.Status.Online"
+	Column string      `json:"column,omitempty"` // Database column the field is stored in
+	Path   []string    `json:"path,omitempty"`   // JSON path inside Column for fields of @jsonb structs
+	Old    interface{} `json:"old"`
+	New    interface{} `json:"new"`
+}
+
+// Nest returns the change as seen from the struct containing the changed struct in its
+// field named field. A non-empty column sets the column the change is stored in, and
+// path is prepended to the JSON path of the change.
+func (c FieldChange) Nest(field, column string, path ...string) FieldChange {
+	c.Field = field + "." + c.Field
+	if column != "" {
+		c.Column = column
+	}
+	if len(path) > 0 {
+		c.Path = append(append([]string{}, path...), c.Path...)
+	}
+	return c
+}
+
+// PathString returns the JSON path of the change joined with dots, such as "status.online"
+func (c FieldChange) PathString() string {
+	return strings.Join(c.Path, ".")
+}
@@ -0,0 +1,41 @@
+package changeset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNest(t *testing.T) {
+	change := FieldChange{Field: "Online", Path: []string{"online"}, Old: false, New: true}
+
+	nested := change.Nest("Status", "", "status").Nest("Settings", "settings")
+
+	if nested.Field != "Settings.Status.Online" {
+		t.Errorf("Expected field path Settings.Status.Online, got %q", nested.Field)
+	}
+	if nested.Column != "settings" {
+		t.Errorf("Expected column settings, got %q", nested.Column)
+	}
+	if !reflect.DeepEqual(nested.Path, []string{"status", "online"}) {
+		t.Errorf("Expected JSON path [status online], got %v", nested.Path)
+	}
+	if nested.PathString() != "status.online" {
+		t.Errorf("Expected path string status.online, got %q", nested.PathString())
+	}
+	if !reflect.DeepEqual(change.Path, []string{"online"}) {
+		t.Errorf("Expected Nest to leave the original path unchanged, got %v", change.Path)
+	}
+}
+
+func TestConflictNest(t *testing.T) {
+	conflict := Conflict{Field: "Firmware", Path: []string{"firmware"}, Base: "1.0", Ours: "1.1", Theirs: "1.2"}
+
+	nested := conflict.Nest("Status", "", "status").Nest("Settings", "settings")
+
+	if nested.Field != "Settings.Status.Firmware" || nested.Column != "settings" || nested.PathString() != "status.firmware" {
+		t.Errorf("Unexpected nested conflict %+v", nested)
+	}
+	if !reflect.DeepEqual(conflict.Path, []string{"firmware"}) {
+		t.Errorf("Expected Nest to leave the original path unchanged, got %v", conflict.Path)
+	}
+}
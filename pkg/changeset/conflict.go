@@ -0,0 +1,32 @@
+package changeset
+
+import "strings"
+
+// Conflict describes a field changed to different values on both sides of a three-way
+// merge, as returned by the Merge methods generated by diffgen
+type Conflict struct {
+	Field  string      `json:"field"`            // Go field path, such as "Settings.Status.Online"
+	Column string      `json:"column,omitempty"` // Database column the field is stored in
+	Path   []string    `json:"path,omitempty"`   // JSON path inside Column for fields of @jsonb structs
+	Base   interface{} `json:"base"`             // Value of the common ancestor
+	Ours   interface{} `json:"ours"`
+	Theirs interface{} `json:"theirs"`
+}
+
+// Nest returns the conflict as seen from the struct containing the merged struct in its
+// field named field, like FieldChange.Nest
+func (c Conflict) Nest(field, column string, path ...string) Conflict {
+	c.Field = field + "." + c.Field
+	if column != "" {
+		c.Column = column
+	}
+	if len(path) > 0 {
+		c.Path = append(append([]string{}, path...), c.Path...)
+	}
+	return c
+}
+
+// PathString returns the JSON path of the conflict joined with dots, such as "status.online"
+func (c Conflict) PathString() string {
+	return strings.Join(c.Path, ".")
+}
@@ -0,0 +1,88 @@
+package clonegen
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/typeregistry"
+)
+
+// Test models for field tag defaults
+type TestSessionState struct {
+	Tokens  []string
+	Cache   map[string]string
+	Buffers [][]byte
+}
+
+// Test models for registered types
+type TestWallet struct {
+	Balance big.Int
+	Limit   *big.Int
+	History []*big.Int
+	Rates   map[string]big.Int
+	Owner   *big.Int `gorm:"foreignKey:OwnerID"`
+}
+
+func TestFieldTagDefaults(t *testing.T) {
+	generator := New()
+	generator.FieldTags = map[string]string{
+		"TestSessionState.Cache": `clone:"-"`,
+		"*.Buffers":              `clone:"-"`,
+	}
+	if err := generator.ParseFile("config_test.go"); err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	var fields []string
+	for _, structInfo := range generator.Structs {
+		if structInfo.Name != "TestSessionState" {
+			continue
+		}
+		for _, field := range structInfo.Fields {
+			fields = append(fields, field.Name)
+		}
+	}
+
+	expected := []string{"Tokens"}
+	if strings.Join(fields, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected fields %v, got %v", expected, fields)
+	}
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	registry := typeregistry.New()
+	err := registry.Register("math/big.Int", typeregistry.Type{
+		Clone:   "*new(big.Int).Set(&{{.Value}})",
+		Imports: []string{"math/big"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register big.Int: %v", err)
+	}
+
+	generator := New()
+	generator.Types = registry
+	if err := generator.ParseFile("config_test.go"); err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	for _, expected := range []string{
+		`"math/big"`,
+		"clone.Balance = *new(big.Int).Set(&original.Balance)",
+		"value := *new(big.Int).Set(&(*original.Limit))",
+		"value := *new(big.Int).Set(&(*v0))",
+		"clone.Rates[k0] = *new(big.Int).Set(&v0)",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+	// Relationships are shared, as for any other type
+	if strings.Contains(code, "original.Owner") {
+		t.Error("Expected the Owner relationship not to be cloned")
+	}
+}
@@ -0,0 +1,113 @@
+package clonegen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// Test models for containers of structs
+type TestLineItem struct {
+	SKU      string
+	Quantity int
+}
+
+type TestOrder struct {
+	Items       []*TestLineItem
+	Batches     [][]TestLineItem
+	ByWarehouse map[string][]*TestLineItem
+	Notes       []string
+}
+
+// Named container types, cloned like their underlying types
+type TestLineItems []*TestLineItem
+
+type TestShipments map[string]TestLineItems
+
+type TestNamedOrder struct {
+	Lines     TestLineItems
+	Shipments TestShipments
+}
+
+func TestContainerElementCloning(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("containers_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "clone.go", code, 0); err != nil {
+		t.Fatalf("Generated code does not parse: %v", err)
+	}
+
+	orderCode := code[strings.Index(code, "func (original *TestOrder) Clone("):]
+	for _, expectedCode := range []string{
+		"clone.Items[i0] = v0.Clone()",
+		"clone.Batches[i0] = make([]TestLineItem, len(v0))",
+		"clone.Batches[i0][i1] = *v1.Clone()",
+		"for k0, v0 := range original.ByWarehouse {",
+		"clone.ByWarehouse[k0][i1] = v1.Clone()",
+		"copy(clone.Notes, original.Notes)",
+	} {
+		if !strings.Contains(orderCode, expectedCode) {
+			t.Errorf("Expected generated code to contain %q", expectedCode)
+		}
+	}
+}
+
+func TestNamedContainerElementCloning(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("containers_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	orderCode := code[strings.Index(code, "func (original *TestNamedOrder) Clone("):]
+	for _, expectedCode := range []string{
+		"clone.Lines = make(TestLineItems, len(original.Lines))",
+		"clone.Lines[i0] = v0.Clone()",
+		"clone.Shipments[k0] = make(TestLineItems, len(v0))",
+		"clone.Shipments[k0][i1] = v1.Clone()",
+	} {
+		if !strings.Contains(orderCode, expectedCode) {
+			t.Errorf("Expected generated code to contain %q", expectedCode)
+		}
+	}
+	if strings.Contains(orderCode, "copy(clone.Lines, original.Lines)") {
+		t.Error("Expected the struct pointers of a named slice not to be copied shallowly")
+	}
+}
+
+func TestContainerElementType(t *testing.T) {
+	tests := []struct {
+		typeStr  string
+		expected string
+		ok       bool
+	}{
+		{"[]*Item", "*Item", true},
+		{"[][]Item", "[]Item", true},
+		{"map[string][]*Item", "[]*Item", true},
+		{"map[[2]int]Item", "Item", true},
+		{"Item", "", false},
+		{"*[]Item", "", false},
+	}
+
+	for _, test := range tests {
+		elementType, ok := containerElementType(test.typeStr)
+		if elementType != test.expected || ok != test.ok {
+			t.Errorf("containerElementType(%q) = %q, %v, expected %q, %v", test.typeStr, elementType, ok, test.expected, test.ok)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package clonegen
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test models for excluded fields and structs
+type TestExcludedClone struct {
+	Tags    []string
+	Cache   map[string]string `gormtrack:"-"`
+	Buffer  []byte            `clone:"-"`
+	Created *int              // @noclone
+	// @noclone
+	Handles []string
+	Shared  *TestUnclonedState `gorm:"type:jsonb;serializer:json"`
+	Session map[string]string
+	Aliases []string // Copied, unlike the @noclonable Handles
+}
+
+// TestUnclonedState gets no Clone method, so it is shared by clones
+// @noclone
+type TestUnclonedState struct {
+	Counters []int
+}
+
+func TestExcludedFieldsAreNotCloned(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("exclusion_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	var fields []string
+	for _, structInfo := range generator.Structs {
+		if structInfo.Name == "TestUnclonedState" {
+			t.Error("Expected struct annotated with @noclone to be skipped")
+		}
+		if structInfo.Name != "TestExcludedClone" {
+			continue
+		}
+		for _, field := range structInfo.Fields {
+			fields = append(fields, field.Name)
+		}
+	}
+
+	expected := []string{"Tags", "Shared", "Session", "Aliases"}
+	if strings.Join(fields, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected fields %v, got %v", expected, fields)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	for _, unexpected := range []string{"original.Cache", "original.Buffer", "original.Created", "original.Handles", "original.Shared.Clone()"} {
+		if strings.Contains(code, unexpected) {
+			t.Errorf("Expected generated code not to deep clone %s", unexpected)
+		}
+	}
+	if !strings.Contains(code, "copy(clone.Tags, original.Tags)") {
+		t.Error("Expected fields that are not excluded to be cloned")
+	}
+}
+
+func TestExcludedFieldsAreShared(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     string
+		fieldTags map[string]string
+	}{
+		{"gormtrack tag", "Cache", nil},
+		{"clone tag", "Buffer", nil},
+		{"@noclone line comment", "Created", nil},
+		{"@noclone doc comment", "Handles", nil},
+		{"default field tag", "Session", map[string]string{"*.Session": `clone:"-"`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generator := New()
+			generator.FieldTags = test.fieldTags
+
+			if err := generator.ParseFile("exclusion_test.go"); err != nil {
+				t.Fatalf("Failed to parse test file: %v", err)
+			}
+
+			var model *StructInfo
+			for i := range generator.Structs {
+				if generator.Structs[i].Name == "TestExcludedClone" {
+					model = &generator.Structs[i]
+				}
+			}
+			if model == nil {
+				t.Fatal("Expected to find TestExcludedClone")
+			}
+			for _, field := range model.Fields {
+				if field.Name == test.field {
+					t.Errorf("Expected %s to be excluded from cloning", test.field)
+				}
+			}
+			if !strings.Contains(strings.Join(model.Shared, ","), test.field) {
+				t.Errorf("Expected %s to be listed as shared, got %v", test.field, model.Shared)
+			}
+
+			code, err := generator.GenerateCode()
+			if err != nil {
+				t.Fatalf("Failed to generate code: %v", err)
+			}
+			if strings.Contains(code, "clone."+test.field+" =") {
+				t.Errorf("Expected %s to be copied by assignment only", test.field)
+			}
+			_, comment, _ := strings.Cut(code, "// Excluded from cloning, shared with the original: ")
+			comment, _, _ = strings.Cut(comment, "\n")
+			if !strings.Contains(comment, test.field) {
+				t.Errorf("Expected the Clone method to document that %s is shared, got %q", test.field, comment)
+			}
+		})
+	}
+}
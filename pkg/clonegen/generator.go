@@ -7,10 +7,24 @@ import (
 	"go/ast"
 	"go/format"
 	"go/parser"
+	"go/scanner"
 	"go/token"
+	"go/types"
+	"io"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/genfile"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/selection"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/typeinfo"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/typeregistry"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/workspace"
 )
 
 // simpleCloneTemplate contains the embedded template for simple structs (no complex fields).
@@ -29,6 +43,9 @@ type StructField struct {
 	Type      string
 	FieldType FieldType
 	Tag       string
+
+	typeName string       // Fully qualified name of the registered type of a FieldTypeCustom field
+	value    *StructField // Value field of a FieldTypeSQLNull field, cloned like a field itself
 }
 
 // FieldType categorizes the field type for clone generation
@@ -38,10 +55,13 @@ const (
 	FieldTypeSimple    FieldType = iota // Primitives, strings, etc.
 	FieldTypeStruct                     // Custom struct types
 	FieldTypeStructPtr                  // Pointer to custom struct
+	FieldTypeValuePtr                   // Pointer to a primitive, time, UUID or named scalar value
 	FieldTypeSlice                      // Slice of any type
 	FieldTypeMap                        // Map of any type
 	FieldTypeInterface                  // Interface
 	FieldTypeComplex                    // Any other complex type
+	FieldTypeCustom                     // Types cloned with the clone expression registered in Types
+	FieldTypeSQLNull                    // sql.Null[T] whose value of type T needs deep cloning
 )
 
 // String returns the string representation of FieldType for template usage
@@ -53,6 +73,8 @@ func (ft FieldType) String() string {
 		return "Struct"
 	case FieldTypeStructPtr:
 		return "StructPtr"
+	case FieldTypeValuePtr:
+		return "ValuePtr"
 	case FieldTypeSlice:
 		return "Slice"
 	case FieldTypeMap:
@@ -61,6 +83,10 @@ func (ft FieldType) String() string {
 		return "Interface"
 	case FieldTypeComplex:
 		return "Complex"
+	case FieldTypeCustom:
+		return "Custom"
+	case FieldTypeSQLNull:
+		return "SQLNull"
 	default:
 		return "Unknown"
 	}
@@ -69,12 +95,34 @@ func (ft FieldType) String() string {
 // StructInfo represents information about a struct
 type StructInfo struct {
 	Name       string
+	TypeParams []TypeParam // Type parameters of a generic struct
 	Fields     []StructField
+	Shared     []string // Excluded fields, copied by assignment so clones share them
 	ImportPath string
+	SourceFile string // Path of the file declaring the struct
 	Package    string
 	IsJSONB    bool
 }
 
+// TypeParam represents a type parameter of a generic struct
+type TypeParam struct {
+	Name       string
+	Constraint string
+}
+
+// TypeArgs returns the type parameters of the struct as type arguments, such as "[K, V]",
+// for use in receivers. It returns an empty string for non-generic structs.
+func (s StructInfo) TypeArgs() string {
+	if len(s.TypeParams) == 0 {
+		return ""
+	}
+	names := make([]string, len(s.TypeParams))
+	for i, param := range s.TypeParams {
+		names[i] = param.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
 // HasComplexFields returns true if the struct has any fields that need deep cloning
 func (s StructInfo) HasComplexFields() bool {
 	for _, field := range s.Fields {
@@ -101,6 +149,46 @@ type CloneGenerator struct {
 	Structs      []StructInfo
 	KnownStructs map[string]bool
 	Imports      map[string]string
+
+	// Force overwrites existing files that were not generated by gorm-gen
+	Force bool
+
+	// FileName is the name of the file WriteToPackageDir writes, clone.go by default
+	FileName string
+
+	// SkipFiles are names of files ParseDirectory skips besides generated files, such as
+	// the configured output files
+	SkipFiles []string
+
+	// External holds the structs cloned in the other packages of a multi-package run,
+	// resolving fields whose types are structs of those packages
+	External *workspace.Structs
+
+	// Warnings receives the warnings of parsing, such as fields that are skipped, each
+	// written as one line with a single Write. New sets it to os.Stderr; nil discards them.
+	Warnings io.Writer
+
+	// FieldTags are default tags of fields by Struct.Field or *.Field, such as
+	// "*.Cache": `clone:"-"`. Keys set by the tag of the field itself take precedence,
+	// then those of Struct.Field. Set before parsing.
+	FieldTags map[string]string
+
+	// Types holds the expressions registered types are cloned with, such as
+	// {{.Value}}.Copy() for github.com/shopspring/decimal.Decimal. Fields, and slice and
+	// map elements, of types with a clone expression are cloned with it. Set before parsing.
+	Types *typeregistry.Registry
+
+	noClone    map[string]bool  // Structs annotated with @noclone, which get no Clone method
+	tracked    map[string]bool  // Structs annotated with @track or declaring TableName
+	structName string           // Name of the struct being parsed, used to look up FieldTags
+	fset       *token.FileSet   // File set of the parsed files, used to look up field types
+	types      *typeinfo.Loader // Type information of the parsed packages
+	pkg        *types.Package   // Type-checked package of the file being parsed, if loaded
+
+	// Underlying slice or map types of the named container types of the fields, such as
+	// []*Item for type Items []*Item, so their elements are cloned like those of the
+	// underlying type
+	containers map[string]string
 }
 
 // New creates a new CloneGenerator
@@ -108,6 +196,20 @@ func New() *CloneGenerator {
 	return &CloneGenerator{
 		KnownStructs: make(map[string]bool),
 		Imports:      make(map[string]string),
+		FileName:     "clone.go",
+		noClone:      make(map[string]bool),
+		tracked:      make(map[string]bool),
+		containers:   make(map[string]string),
+		fset:         token.NewFileSet(),
+		types:        typeinfo.NewLoader(),
+		Warnings:     os.Stderr,
+	}
+}
+
+// warnf writes a warning to Warnings
+func (g *CloneGenerator) warnf(format string, args ...interface{}) {
+	if g.Warnings != nil {
+		fmt.Fprintf(g.Warnings, "Warning: "+format+"\n", args...)
 	}
 }
 
@@ -125,17 +227,26 @@ func (g *CloneGenerator) ParseFile(filePath string) error {
 	// Collect struct names for reference
 	g.collectStructNames(node)
 
+	// Collect the structs chosen by Selector.TrackedOnly
+	for _, name := range selection.TrackedTypes(node) {
+		g.tracked[name] = true
+	}
+
+	// Load type information, falling back to syntactic classification without it
+	if err := g.types.Load(filePath); err != nil {
+		g.warnf("Type information unavailable for %s, classifying fields by syntax: %v", filePath, err)
+	}
+	g.pkg = g.types.Package(filePath)
+
 	// Extract struct details
-	return g.extractStructDetails(node, packageName)
+	return g.extractStructDetails(node, filePath, packageName)
 }
 
 // parseFileAST parses a Go file and returns the AST node and package name
 func (g *CloneGenerator) parseFileAST(filePath string) (*ast.File, string, error) {
-	// Set up the file set
-	fset := token.NewFileSet()
-
-	// Parse the file
-	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
+	// Parse the file into the generator's file set, so field type expressions can be
+	// matched with the loaded type information
+	node, err := parser.ParseFile(g.fset, filePath, nil, parser.ParseComments)
 	if err != nil {
 		return nil, "", fmt.Errorf("error parsing file: %v", err)
 	}
@@ -143,11 +254,24 @@ func (g *CloneGenerator) parseFileAST(filePath string) (*ast.File, string, error
 	return node, node.Name.Name, nil
 }
 
-// collectStructNames collects struct names for reference during type determination
+// collectStructNames collects struct names for reference during type determination.
+// Structs annotated with @noclone are not known structs, since they get no Clone method.
 func (g *CloneGenerator) collectStructNames(node *ast.File) {
 	ast.Inspect(node, func(n ast.Node) bool {
-		if typeSpec, ok := n.(*ast.TypeSpec); ok {
+		genDecl, ok := n.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			return true
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
 			if _, isStruct := typeSpec.Type.(*ast.StructType); isStruct {
+				if selection.HasAnnotation(genDecl.Doc, "@noclone") || selection.HasAnnotation(typeSpec.Doc, "@noclone") {
+					g.noClone[typeSpec.Name.Name] = true
+					continue
+				}
 				g.KnownStructs[typeSpec.Name.Name] = true
 			}
 		}
@@ -156,24 +280,33 @@ func (g *CloneGenerator) collectStructNames(node *ast.File) {
 }
 
 // extractStructDetails extracts detailed struct information from AST
-func (g *CloneGenerator) extractStructDetails(node *ast.File, packageName string) error {
+func (g *CloneGenerator) extractStructDetails(node *ast.File, filePath, packageName string) error {
 	for _, decl := range node.Decls {
 		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
 			for _, spec := range genDecl.Specs {
 				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
 					if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+						if g.noClone[typeSpec.Name.Name] {
+							continue
+						}
+
 						// Extract fields from struct
-						fields := g.extractFields(structType)
+						g.structName = typeSpec.Name.Name
+						fields, shared := g.extractFields(structType)
+						g.structName = ""
 
 						// Check for @jsonb annotation in comments
 						isJSONB := g.hasJSONBAnnotation(genDecl.Doc)
 
 						// Add to structs list
 						g.Structs = append(g.Structs, StructInfo{
-							Name:    typeSpec.Name.Name,
-							Fields:  fields,
-							Package: packageName,
-							IsJSONB: isJSONB,
+							Name:       typeSpec.Name.Name,
+							TypeParams: g.extractTypeParams(typeSpec.TypeParams),
+							Fields:     fields,
+							Shared:     shared,
+							SourceFile: filePath,
+							Package:    packageName,
+							IsJSONB:    isJSONB,
 						})
 					}
 				}
@@ -184,43 +317,124 @@ func (g *CloneGenerator) extractStructDetails(node *ast.File, packageName string
 	return nil
 }
 
-// extractFields extracts field information from a struct type
-func (g *CloneGenerator) extractFields(structType *ast.StructType) []StructField {
+// extractTypeParams extracts the type parameters of a generic type declaration
+func (g *CloneGenerator) extractTypeParams(list *ast.FieldList) []TypeParam {
+	if list == nil {
+		return nil
+	}
+
+	var params []TypeParam
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			params = append(params, TypeParam{Name: name.Name, Constraint: g.getTypeString(field.Type)})
+		}
+	}
+	return params
+}
+
+// extractFields extracts field information from a struct type. Excluded fields are left
+// out, so they are copied by assignment without deep cloning; their names are returned
+// separately.
+func (g *CloneGenerator) extractFields(structType *ast.StructType) ([]StructField, []string) {
 	var fields []StructField
+	var shared []string
 
 	for _, field := range structType.Fields.List {
 		fieldType := g.getTypeString(field.Type)
 
 		// Get field tag if present
-		var tagStr string
+		var fieldTag string
 		if field.Tag != nil {
-			tagStr = field.Tag.Value
+			fieldTag = field.Tag.Value
 		}
 
-		fieldTypeCategory := g.categorizeFieldTypeWithTag(fieldType, tagStr)
-
-		// Handle multiple field names (e.g., a, b int)
+		// Handle multiple field names (e.g., a, b int). Anonymous fields are named by their
+		// type, and get the default tags of their field name.
+		names := []string{fieldType}
+		tagNames := []string{embeddedFieldName(fieldType)}
 		if len(field.Names) > 0 {
+			names, tagNames = nil, nil
 			for _, name := range field.Names {
-				fields = append(fields, StructField{
-					Name:      name.Name,
-					Type:      fieldType,
-					FieldType: fieldTypeCategory,
-					Tag:       tagStr,
-				})
+				names = append(names, name.Name)
+				tagNames = append(tagNames, name.Name)
 			}
-		} else {
-			// Anonymous field
+		}
+
+		for i, name := range names {
+			tagStr := g.fieldTag(tagNames[i], fieldTag)
+			if g.isExcludedField(field, tagStr) {
+				shared = append(shared, tagNames[i])
+				continue
+			}
+
+			// Categorize from the type-checked type when available
+			fieldTypeCategory := g.categorizeFieldTypeWithTag(fieldType, tagStr)
+			t := g.types.FieldType(g.fset, field.Type)
+			if t != nil {
+				fieldTypeCategory = g.categorizeFieldTypeByTypes(t, fieldType, tagStr)
+				g.recordContainers(t)
+			}
+
+			// Registered types are cloned with their expression, unless they are relationships
+			var typeName string
+			var value *StructField
+			if !g.isRelationshipField(tagStr) {
+				if registered := typeregistry.Name(t, fieldType, g.Imports); g.hasCloneExpr(registered) {
+					fieldTypeCategory, typeName = FieldTypeCustom, registered
+				} else if value = g.sqlNullValue(t, name, fieldType); value != nil {
+					fieldTypeCategory = FieldTypeSQLNull
+				}
+			}
+
 			fields = append(fields, StructField{
-				Name:      fieldType,
+				Name:      name,
 				Type:      fieldType,
 				FieldType: fieldTypeCategory,
 				Tag:       tagStr,
+				typeName:  typeName,
+				value:     value,
 			})
 		}
 	}
 
-	return fields
+	return fields, shared
+}
+
+// fieldTag returns the tag of a field of the struct being parsed with the default tags
+// of FieldTags added under it
+func (g *CloneGenerator) fieldTag(fieldName, tagStr string) string {
+	tagStr = mergeTags(tagStr, g.FieldTags[g.structName+"."+fieldName])
+	return mergeTags(tagStr, g.FieldTags["*."+fieldName])
+}
+
+// mergeTags adds the key:"value" pairs of defaults whose keys tagStr does not set to
+// tagStr. Both are struct tags as written in source, in backticks.
+func mergeTags(tagStr, defaults string) string {
+	if defaults == "" {
+		return tagStr
+	}
+
+	tag := reflect.StructTag(strings.Trim(tagStr, "`"))
+	merged := string(tag)
+	for _, pair := range tagPairRegexp.FindAllStringSubmatch(strings.Trim(defaults, "`"), -1) {
+		if _, ok := tag.Lookup(pair[1]); !ok {
+			merged = strings.TrimSpace(merged + " " + pair[0])
+		}
+	}
+	if merged == "" {
+		return ""
+	}
+	return "`" + merged + "`"
+}
+
+// tagPairRegexp matches the key:"value" pairs of a struct tag
+var tagPairRegexp = regexp.MustCompile(`([^\s:"]+):"(?:\\.|[^"\\])*"`)
+
+// embeddedFieldName returns the name of an embedded field of the given type, such as
+// Model for "*gorm.Model"
+func embeddedFieldName(typeStr string) string {
+	name := baseTypeName(typeStr)
+	return name[strings.LastIndex(name, ".")+1:]
 }
 
 // extractImports extracts import information from AST imports
@@ -261,6 +475,16 @@ func (g *CloneGenerator) getTypeString(expr ast.Expr) string {
 		return "interface{}"
 	case *ast.SelectorExpr:
 		return g.getTypeString(t.X) + "." + t.Sel.Name
+	case *ast.IndexExpr:
+		// Instantiation of a generic type with one type argument
+		return g.getTypeString(t.X) + "[" + g.getTypeString(t.Index) + "]"
+	case *ast.IndexListExpr:
+		// Instantiation of a generic type with several type arguments
+		args := make([]string, len(t.Indices))
+		for i, index := range t.Indices {
+			args[i] = g.getTypeString(index)
+		}
+		return g.getTypeString(t.X) + "[" + strings.Join(args, ", ") + "]"
 	default:
 		return "interface{}"
 	}
@@ -281,6 +505,9 @@ func (g *CloneGenerator) categorizeFieldType(fieldType string) FieldType {
 		return FieldTypeInterface
 	case baseType == "json.RawMessage" || baseType == "datatypes.JSON":
 		return FieldTypeSlice // Treat as slice since they're []byte
+	case strings.HasPrefix(fieldType, "*") && (isSimpleType(baseType) || baseType == "uuid.UUID"):
+		// Pointers to values get a fresh allocation so the clone does not share them
+		return FieldTypeValuePtr
 	case isSimpleType(baseType):
 		return FieldTypeSimple
 	default:
@@ -300,8 +527,8 @@ func (g *CloneGenerator) categorizeFieldTypeWithTag(fieldType, tagStr string) Fi
 
 	// Check if this is a JSONB field based on GORM tags
 	if g.isJSONBField(tagStr) {
-		// Remove pointer prefix for analysis
-		baseType := strings.TrimPrefix(fieldType, "*")
+		// Remove pointer prefix and type arguments for analysis
+		baseType := baseTypeName(fieldType)
 
 		// Check if it's a known struct that should be treated as JSONB
 		if g.KnownStructs[baseType] {
@@ -321,6 +548,63 @@ func (g *CloneGenerator) categorizeFieldTypeWithTag(fieldType, tagStr string) Fi
 	return g.categorizeFieldType(fieldType)
 }
 
+// categorizeFieldTypeByTypes determines the category of a field from its type-checked type,
+// seeing through named types such as `type Tags []string`
+func (g *CloneGenerator) categorizeFieldTypeByTypes(t types.Type, fieldType, tagStr string) FieldType {
+	// Relationship fields should be treated as simple to avoid cloning
+	if g.isRelationshipField(tagStr) {
+		return FieldTypeSimple
+	}
+
+	// JSONB struct columns of the parsed package are cloned with their Clone method
+	if g.isJSONBField(tagStr) {
+		baseType := baseTypeName(fieldType)
+		if g.KnownStructs[baseType] {
+			if strings.HasPrefix(fieldType, "*") {
+				return FieldTypeStructPtr
+			}
+			return FieldTypeStruct
+		}
+	}
+
+	// Values of type parameters are copied by assignment, their types are unknown
+	if _, ok := t.(*types.TypeParam); ok {
+		return FieldTypeSimple
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		if isValueType(u.Elem()) {
+			return FieldTypeValuePtr
+		}
+		// Pointers to structs are copied as is to avoid cloning relationships
+		return FieldTypeSimple
+	case *types.Slice:
+		return FieldTypeSlice
+	case *types.Map:
+		return FieldTypeMap
+	case *types.Interface:
+		return FieldTypeInterface
+	default:
+		// Basic types, structs, arrays and pointers are copied by value
+		return FieldTypeSimple
+	}
+}
+
+// isValueType checks if a type is copied completely by assignment, so a pointer to it can
+// be cloned with a fresh allocation: primitives, named scalar types, time.Time, UUIDs and
+// nullable SQL values of those
+func isValueType(t types.Type) bool {
+	if typeinfo.IsNamed(t, "time", "Time") || typeinfo.IsNamed(t, "github.com/google/uuid", "UUID") {
+		return true
+	}
+	if null, ok := typeinfo.SQLNull(t, ""); ok {
+		return null.Type != nil && isValueType(null.Type)
+	}
+	_, ok := t.Underlying().(*types.Basic)
+	return ok
+}
+
 // isJSONBField checks if a field has JSONB-related GORM tags
 func (g *CloneGenerator) isJSONBField(tagStr string) bool {
 	if tagStr == "" {
@@ -352,22 +636,23 @@ func (g *CloneGenerator) isRelationshipField(tagStr string) bool {
 
 // hasJSONBAnnotation checks if a struct has @jsonb annotation in its comments
 func (g *CloneGenerator) hasJSONBAnnotation(commentGroup *ast.CommentGroup) bool {
-	if commentGroup == nil {
-		return false
-	}
+	return selection.HasAnnotation(commentGroup, "@jsonb")
+}
 
-	for _, comment := range commentGroup.List {
-		if strings.Contains(comment.Text, "@jsonb") {
-			return true
-		}
+// isExcludedField checks if a field is left out of deep cloning: tagged gormtrack:"-" or
+// clone:"-", or annotated with @noclone
+func (g *CloneGenerator) isExcludedField(field *ast.Field, tagStr string) bool {
+	tag := reflect.StructTag(strings.Trim(tagStr, "`"))
+	if tag.Get("gormtrack") == "-" || tag.Get("clone") == "-" {
+		return true
 	}
-	return false
+	return selection.HasAnnotation(field.Doc, "@noclone") || selection.HasAnnotation(field.Comment, "@noclone")
 }
 
 // identifyJSONBStructsAndReprocessFields identifies JSONB structs and re-processes field types
 func (g *CloneGenerator) identifyJSONBStructsAndReprocessFields() {
-	// Create a map to track JSONB structs
-	jsonbStructs := make(map[string]bool)
+	// Create a map to track JSONB structs, starting with those of imported packages
+	jsonbStructs := g.resolveExternalStructs()
 
 	// First, identify structs with @jsonb annotation
 	for _, structInfo := range g.Structs {
@@ -381,15 +666,20 @@ func (g *CloneGenerator) identifyJSONBStructsAndReprocessFields() {
 		for j := range g.Structs[i].Fields {
 			field := &g.Structs[i].Fields[j]
 
+			// Registered types keep their clone expression, and nullable values their value
+			if field.FieldType == FieldTypeCustom || field.FieldType == FieldTypeSQLNull {
+				continue
+			}
+
 			// Skip relationship fields - treat as simple
 			if g.isRelationshipField(field.Tag) {
 				field.FieldType = FieldTypeSimple
 				continue
 			}
 
-			// Re-determine field type considering JSONB structs
-			baseType := strings.TrimPrefix(field.Type, "*")
-			if jsonbStructs[baseType] && !g.isJSONBField(field.Tag) {
+			// Re-determine field type considering JSONB structs and structs of other packages
+			baseType := baseTypeName(field.Type)
+			if (jsonbStructs[baseType] && !g.isJSONBField(field.Tag)) || (g.isJSONBField(field.Tag) && g.KnownStructs[baseType]) {
 				// This is a nested JSONB struct, treat appropriately for cloning
 				if strings.HasPrefix(field.Type, "*") {
 					field.FieldType = FieldTypeStructPtr
@@ -401,6 +691,26 @@ func (g *CloneGenerator) identifyJSONBStructsAndReprocessFields() {
 	}
 }
 
+// resolveExternalStructs registers the structs cloned in the packages of a multi-package
+// run that the parsed package imports, under the names they are imported by, and returns
+// those annotated with @jsonb
+func (g *CloneGenerator) resolveExternalStructs() map[string]bool {
+	jsonbStructs := make(map[string]bool)
+	if g.External == nil {
+		return jsonbStructs
+	}
+	for importPath, importName := range g.Imports {
+		for name, jsonb := range g.External.Package(importPath) {
+			qualified := importName + "." + name
+			g.KnownStructs[qualified] = true
+			if jsonb {
+				jsonbStructs[qualified] = true
+			}
+		}
+	}
+	return jsonbStructs
+}
+
 // isSimpleType checks if a type is a simple built-in type
 func isSimpleType(typeName string) bool {
 	simpleTypes := map[string]bool{
@@ -428,60 +738,145 @@ func isSimpleType(typeName string) bool {
 	return simpleTypes[typeName]
 }
 
-// getRequiredImports determines which imports are needed for the generated code
-func (g *CloneGenerator) getRequiredImports() []string {
+// getRequiredImports determines which imports are needed for the generated code, as
+// import specs: datatypes for datatypes.JSON fields, and the packages of the parsed files
+// whose types the generated code spells out, such as common for make([]common.Item, n)
+func (g *CloneGenerator) getRequiredImports(structs []StructInfo, code []byte) []string {
 	var imports []string
 	importSet := make(map[string]bool)
 
 	// Check all struct fields for types that need imports
-	for _, structInfo := range g.Structs {
+	for _, structInfo := range structs {
 		for _, field := range structInfo.Fields {
 			// Check if field type contains datatypes.JSON
 			if strings.Contains(field.Type, "datatypes.JSON") {
 				if !importSet["gorm.io/datatypes"] {
-					imports = append(imports, "gorm.io/datatypes")
+					imports = append(imports, `"gorm.io/datatypes"`)
 					importSet["gorm.io/datatypes"] = true
 				}
 			}
 		}
 	}
 
+	var paths []string
+	for importPath := range g.Imports {
+		paths = append(paths, importPath)
+	}
+	sort.Strings(paths)
+
+	// Packages the expressions of registered types refer to
+	for _, importPath := range g.Types.Imports() {
+		if _, ok := g.Imports[importPath]; !ok && !importSet[importPath] && usesPackage(code, importPath[strings.LastIndex(importPath, "/")+1:]) {
+			importSet[importPath] = true
+			imports = append(imports, strconv.Quote(importPath))
+		}
+	}
+	for _, importPath := range paths {
+		name := g.Imports[importPath]
+		if importSet[importPath] || name == "_" || name == "." || !usesPackage(code, name) {
+			continue
+		}
+		importSet[importPath] = true
+		if name == importPath[strings.LastIndex(importPath, "/")+1:] {
+			imports = append(imports, strconv.Quote(importPath))
+		} else {
+			imports = append(imports, name+" "+strconv.Quote(importPath))
+		}
+	}
+
 	return imports
 }
 
+// usesPackage reports whether code refers to a member of the package with the given name,
+// ignoring comments, which may spell out type names of fields the code leaves alone
+func usesPackage(code []byte, name string) bool {
+	var s scanner.Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(code)), code, nil, 0)
+
+	var prev token.Token
+	var prevLit string
+	for selector := false; ; {
+		_, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return false
+		case tok == token.PERIOD && prev == token.IDENT && prevLit == name && !selector:
+			return true
+		}
+		selector = prev == token.PERIOD
+		prev, prevLit = tok, lit
+	}
+}
+
 // GenerateCode generates the code for all struct clone methods
 func (g *CloneGenerator) GenerateCode() (string, error) {
+	return g.generateCode(g.Structs)
+}
+
+// GenerateSourceFiles generates the code of each parsed source file separately, keyed by
+// output file name: <source>_gen.go holds the methods of the structs declared in <source>.go
+func (g *CloneGenerator) GenerateSourceFiles() (map[string]string, error) {
+	if len(g.Structs) == 0 {
+		return nil, fmt.Errorf("no structs found")
+	}
+
+	var sources []string
+	bySource := make(map[string][]StructInfo)
+	for _, structInfo := range g.Structs {
+		if _, ok := bySource[structInfo.SourceFile]; !ok {
+			sources = append(sources, structInfo.SourceFile)
+		}
+		bySource[structInfo.SourceFile] = append(bySource[structInfo.SourceFile], structInfo)
+	}
+
+	files := make(map[string]string)
+	for _, source := range sources {
+		code, err := g.generateCode(bySource[source])
+		if err != nil {
+			return nil, err
+		}
+		files[genfile.SourceFileName(source)] = code
+	}
+
+	return files, nil
+}
+
+// generateCode generates the clone methods of structs
+func (g *CloneGenerator) generateCode(structs []StructInfo) (string, error) {
 	var buf bytes.Buffer
 
 	// Identify JSONB structs and re-process field types
 	g.identifyJSONBStructsAndReprocessFields()
 
-	// Generate package declaration
+	// Generate header and package declaration
 	if len(g.Structs) > 0 {
+		buf.WriteString(genfile.Header())
 		fmt.Fprintf(&buf, "package %s\n\n", g.Structs[0].Package)
 	} else {
 		return "", fmt.Errorf("no structs found")
 	}
 
+	// Generate clone methods for each struct
+	var body bytes.Buffer
+	for _, structInfo := range structs {
+		code, err := g.generateCloneMethod(structInfo)
+		if err != nil {
+			return "", err
+		}
+		body.WriteString(code)
+		body.WriteString("\n\n")
+	}
+
 	// Generate imports if needed
-	requiredImports := g.getRequiredImports()
+	requiredImports := g.getRequiredImports(structs, body.Bytes())
 	if len(requiredImports) > 0 {
 		buf.WriteString("import (\n")
 		for _, imp := range requiredImports {
-			fmt.Fprintf(&buf, "\t\"%s\"\n", imp)
+			fmt.Fprintf(&buf, "\t%s\n", imp)
 		}
 		buf.WriteString(")\n\n")
 	}
-
-	// Generate clone methods for each struct
-	for _, structInfo := range g.Structs {
-		code, err := g.generateCloneMethod(structInfo)
-		if err != nil {
-			return "", err
-		}
-		buf.WriteString(code)
-		buf.WriteString("\n\n")
-	}
+	buf.Write(body.Bytes())
 
 	// Format the generated code
 	formatted, err := format.Source(buf.Bytes())
@@ -499,59 +894,15 @@ func (g *CloneGenerator) loadCloneTemplate(isComplex bool) (*template.Template,
 		"trimStar": func(s string) string {
 			return strings.TrimPrefix(s, "*")
 		},
-		"isSliceOfStruct": func(s string) bool {
-			if !strings.HasPrefix(s, "[]") {
-				return false
-			}
-			elementType := strings.TrimPrefix(s, "[]")
-			elementType = strings.TrimPrefix(elementType, "*")
-			return g.KnownStructs[elementType]
-		},
-		"isSliceOfStructPtr": func(s string) bool {
-			if !strings.HasPrefix(s, "[]") {
-				return false
-			}
-			elementType := strings.TrimPrefix(s, "[]")
-			if !strings.HasPrefix(elementType, "*") {
-				return false
-			}
-			elementType = strings.TrimPrefix(elementType, "*")
-			return g.KnownStructs[elementType]
-		},
-		"isMapOfStruct": func(s string) bool {
-			if !strings.HasPrefix(s, "map[") {
-				return false
-			}
-			// Extract value type from map[key]value
-			parts := strings.Split(s, "]")
-			if len(parts) < 2 {
-				return false
-			}
-			valueType := strings.TrimPrefix(parts[1], "*")
-			return g.KnownStructs[valueType]
-		},
-		"isMapOfStructPtr": func(s string) bool {
-			if !strings.HasPrefix(s, "map[") {
-				return false
-			}
-			// Extract value type from map[key]value
-			parts := strings.Split(s, "]")
-			if len(parts) < 2 {
-				return false
-			}
-			valueType := parts[1]
-			if !strings.HasPrefix(valueType, "*") {
-				return false
-			}
-			valueType = strings.TrimPrefix(valueType, "*")
-			return g.KnownStructs[valueType]
-		},
-		"getSliceElementType": func(s string) string {
-			return strings.TrimPrefix(s, "[]")
+		"hasPrefix":         strings.HasPrefix,
+		"join":              strings.Join,
+		"needsElementClone": g.needsElementClone,
+		"cloneElements": func(dst, src, typeStr string) string {
+			return g.cloneElements(dst, src, typeStr, 0)
 		},
-		"getSliceElementTypeName": func(s string) string {
-			elementType := strings.TrimPrefix(s, "[]")
-			return strings.TrimPrefix(elementType, "*")
+		"customClone": g.customClone,
+		"sqlNullValue": func(field StructField) StructField {
+			return *field.value
 		},
 	}
 
@@ -572,6 +923,172 @@ func (g *CloneGenerator) loadCloneTemplate(isComplex bool) (*template.Template,
 	return tmpl, nil
 }
 
+// baseTypeName strips the pointer and the type arguments from a type, so instantiations
+// of generic structs can be looked up by the struct name
+func baseTypeName(typeStr string) string {
+	baseType := strings.TrimPrefix(typeStr, "*")
+	if i := strings.Index(baseType, "["); i > 0 {
+		baseType = baseType[:i]
+	}
+	return baseType
+}
+
+// containerElementType returns the element type of a slice type or the value type of a
+// map type, and false for any other type
+func containerElementType(typeStr string) (string, bool) {
+	if strings.HasPrefix(typeStr, "[]") {
+		return strings.TrimPrefix(typeStr, "[]"), true
+	}
+	if !strings.HasPrefix(typeStr, "map[") {
+		return "", false
+	}
+
+	// Find the bracket closing the key type, which may contain brackets itself
+	depth := 0
+	for i := len("map"); i < len(typeStr); i++ {
+		switch typeStr[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return typeStr[i+1:], true
+			}
+		}
+	}
+	return "", false
+}
+
+// recordContainers records the underlying types of the named slice and map types of t and
+// of its elements in containers, spelled as in the parsed package
+func (g *CloneGenerator) recordContainers(t types.Type) {
+	qualifier := func(pkg *types.Package) string {
+		if pkg == g.pkg {
+			return ""
+		}
+		if name, ok := g.Imports[pkg.Path()]; ok {
+			return name
+		}
+		return pkg.Name()
+	}
+
+	for {
+		var elem types.Type
+		switch u := t.Underlying().(type) {
+		case *types.Slice:
+			elem = u.Elem()
+		case *types.Map:
+			elem = u.Elem()
+		default:
+			return
+		}
+		if named, ok := types.Unalias(t).(*types.Named); ok {
+			g.containers[types.TypeString(named, qualifier)] = types.TypeString(t.Underlying(), qualifier)
+		}
+		t = elem
+	}
+}
+
+// underlyingContainer returns the underlying type of a named slice or map type, such as
+// []*Item for Items, and typeStr for any other type
+func (g *CloneGenerator) underlyingContainer(typeStr string) string {
+	if underlying, ok := g.containers[typeStr]; ok {
+		return underlying
+	}
+	return typeStr
+}
+
+// needsElementClone checks if a slice or map type holds known structs or struct pointers,
+// directly or through nested slices and maps, so its elements must be cloned one by one.
+// Named container types are resolved to their underlying types.
+func (g *CloneGenerator) needsElementClone(typeStr string) bool {
+	elementType, ok := containerElementType(g.underlyingContainer(typeStr))
+	if !ok {
+		return false
+	}
+	if g.KnownStructs[baseTypeName(elementType)] || g.hasCloneExpr(typeregistry.Name(nil, elementType, g.Imports)) {
+		return true
+	}
+	return g.needsElementClone(elementType)
+}
+
+// sqlNullValue returns the value field of a field of a nullable type of database/sql, of
+// type t or nil without type information, if its values need deep cloning, as for
+// sql.Null[[]byte]. The values of the other nullable types are copied with the field.
+func (g *CloneGenerator) sqlNullValue(t types.Type, fieldName, fieldType string) *StructField {
+	null, ok := typeinfo.SQLNull(t, fieldType)
+	if !ok {
+		return nil
+	}
+
+	value := &StructField{Name: fieldName + "." + null.Field, Type: null.TypeStr}
+	if name := typeregistry.Name(null.Type, null.TypeStr, g.Imports); g.hasCloneExpr(name) {
+		value.FieldType, value.typeName = FieldTypeCustom, name
+	} else if null.Type != nil {
+		value.FieldType = g.categorizeFieldTypeByTypes(null.Type, null.TypeStr, "")
+	} else {
+		value.FieldType = g.categorizeFieldType(null.TypeStr)
+	}
+
+	if value.FieldType == FieldTypeSimple {
+		return nil
+	}
+	return value
+}
+
+// hasCloneExpr reports whether the type with the given fully qualified name is registered
+// in Types with a clone expression
+func (g *CloneGenerator) hasCloneExpr(typeName string) bool {
+	registered, ok := g.Types.Lookup(typeName)
+	return ok && registered.Clone != ""
+}
+
+// customClone returns the expression cloning the value of a field of a type registered
+// in Types
+func (g *CloneGenerator) customClone(field StructField, value string) string {
+	expr, _ := g.Types.Clone(field.typeName, value)
+	return expr
+}
+
+// cloneElements generates the statements filling dst, already allocated for the elements
+// of src, with clones of those elements. Nested slices and maps are allocated and filled
+// recursively; depth keeps the loop variables of the nested loops apart.
+func (g *CloneGenerator) cloneElements(dst, src, typeStr string, depth int) string {
+	typeStr = g.underlyingContainer(typeStr)
+	elementType, _ := containerElementType(typeStr)
+	key := fmt.Sprintf("i%d", depth)
+	if strings.HasPrefix(typeStr, "map[") {
+		key = fmt.Sprintf("k%d", depth)
+	}
+	value := fmt.Sprintf("v%d", depth)
+	elementDst := fmt.Sprintf("%s[%s]", dst, key)
+
+	var body string
+	typeName := typeregistry.Name(nil, elementType, g.Imports)
+	switch {
+	case g.hasCloneExpr(typeName) && strings.HasPrefix(elementType, "*"):
+		cloneExpr, _ := g.Types.Clone(typeName, "(*"+value+")")
+		body = fmt.Sprintf("if %s != nil {\nvalue := %s\n%s = &value\n}", value, cloneExpr, elementDst)
+	case g.hasCloneExpr(typeName):
+		cloneExpr, _ := g.Types.Clone(typeName, value)
+		body = fmt.Sprintf("%s = %s", elementDst, cloneExpr)
+	case g.KnownStructs[baseTypeName(elementType)] && strings.HasPrefix(elementType, "*"):
+		body = fmt.Sprintf("%s = %s.Clone()", elementDst, value)
+	case g.KnownStructs[baseTypeName(elementType)]:
+		body = fmt.Sprintf("%s = *%s.Clone()", elementDst, value)
+	case strings.HasPrefix(g.underlyingContainer(elementType), "[]"):
+		body = fmt.Sprintf("if %s != nil {\n%s = make(%s, len(%s))\n%s\n}",
+			value, elementDst, elementType, value, g.cloneElements(elementDst, value, elementType, depth+1))
+	case strings.HasPrefix(g.underlyingContainer(elementType), "map["):
+		body = fmt.Sprintf("if %s != nil {\n%s = make(%s, len(%s))\n%s\n}",
+			value, elementDst, elementType, value, g.cloneElements(elementDst, value, elementType, depth+1))
+	default:
+		body = fmt.Sprintf("%s = %s", elementDst, value)
+	}
+
+	return fmt.Sprintf("for %s, %s := range %s {\n%s\n}", key, value, src, body)
+}
+
 // generateCloneMethod generates a clone method for a struct
 func (g *CloneGenerator) generateCloneMethod(structInfo StructInfo) (string, error) {
 	// Determine if struct has complex fields
@@ -605,14 +1122,15 @@ func (g *CloneGenerator) generateCloneMethod(structInfo StructInfo) (string, err
 	return buf.String(), nil
 }
 
-// WriteToFile writes the generated code to a file
+// WriteToFile writes the generated code to a file, refusing to overwrite a file not
+// generated by gorm-gen unless Force is set
 func (g *CloneGenerator) WriteToFile(filePath string) error {
 	code, err := g.GenerateCode()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filePath, []byte(code), 0644)
+	return genfile.Write(filePath, []byte(code), g.Force)
 }
 
 // ParseFiles parses multiple Go files and extracts struct information
@@ -639,7 +1157,8 @@ func (g *CloneGenerator) ParseFiles(filePaths []string) error {
 	return nil
 }
 
-// ParseDirectory parses all .go files in a directory and extracts struct information
+// ParseDirectory parses all .go files in a directory and extracts struct information.
+// Test files, files generated by gorm-gen and SkipFiles are skipped.
 func (g *CloneGenerator) ParseDirectory(dirPath string) error {
 	files, err := os.ReadDir(dirPath)
 	if err != nil {
@@ -648,10 +1167,11 @@ func (g *CloneGenerator) ParseDirectory(dirPath string) error {
 
 	var goFiles []string
 	for _, file := range files {
+		filePath := dirPath + "/" + file.Name()
 		if !file.IsDir() && strings.HasSuffix(file.Name(), ".go") &&
-			!strings.HasSuffix(file.Name(), "_test.go") &&
-			file.Name() != "clone.go" && file.Name() != "diff.go" {
-			goFiles = append(goFiles, dirPath+"/"+file.Name())
+			!strings.HasSuffix(file.Name(), "_test.go") && !slices.Contains(g.SkipFiles, file.Name()) &&
+			!genfile.IsGenerated(filePath) {
+			goFiles = append(goFiles, filePath)
 		}
 	}
 
@@ -662,13 +1182,40 @@ func (g *CloneGenerator) ParseDirectory(dirPath string) error {
 	return g.ParseFiles(goFiles)
 }
 
-// WriteToPackageDir writes the generated code to clone.go in the specified directory
+// Select restricts the parsed structs to those chosen by selector and the structs they
+// depend on, so that no Clone methods are generated for the others
+func (g *CloneGenerator) Select(selector selection.Selector) error {
+	candidates := make([]selection.Struct, len(g.Structs))
+	for i, structInfo := range g.Structs {
+		candidates[i] = selection.Struct{Name: structInfo.Name, Tracked: g.tracked[structInfo.Name]}
+		for _, field := range structInfo.Fields {
+			candidates[i].Dependencies = append(candidates[i].Dependencies, selection.References(field.Type)...)
+		}
+	}
+
+	selected, err := selector.Select(candidates)
+	if err != nil {
+		return err
+	}
+
+	var structs []StructInfo
+	for _, structInfo := range g.Structs {
+		if selected[structInfo.Name] {
+			structs = append(structs, structInfo)
+		}
+	}
+	g.Structs = structs
+	return nil
+}
+
+// WriteToPackageDir writes the generated code to FileName in the specified directory,
+// refusing to overwrite a file not generated by gorm-gen unless Force is set
 func (g *CloneGenerator) WriteToPackageDir(packageDir string) error {
 	code, err := g.GenerateCode()
 	if err != nil {
 		return err
 	}
 
-	filePath := packageDir + "/clone.go"
-	return os.WriteFile(filePath, []byte(code), 0644)
+	filePath := packageDir + "/" + g.FileName
+	return genfile.Write(filePath, []byte(code), g.Force)
 }
@@ -1,9 +1,14 @@
 package clonegen
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/genfile"
 )
 
 // Test structs
@@ -111,6 +116,9 @@ func TestFieldTypeCategorization(t *testing.T) {
 		{"map[string]int", FieldTypeMap},
 		{"interface{}", FieldTypeInterface},
 		{"UnknownType", FieldTypeSimple},   // Unknown types are treated as simple by default
+		{"*string", FieldTypeValuePtr},
+		{"*time.Time", FieldTypeValuePtr},
+		{"*uuid.UUID", FieldTypeValuePtr},
 	}
 
 	for _, test := range tests {
@@ -157,15 +165,34 @@ func TestWriteToFile(t *testing.T) {
 		t.Fatalf("Error parsing test file: %v", err)
 	}
 
-	// Write to a temporary file
-	tempFile := "/tmp/test_clone_output.go"
-	err = generator.WriteToFile(tempFile)
+	// Write to a temporary file, then over the generated file
+	tempFile := filepath.Join(t.TempDir(), "clone.go")
+	for i := 0; i < 2; i++ {
+		if err := generator.WriteToFile(tempFile); err != nil {
+			t.Fatalf("Error writing to file: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(tempFile)
 	if err != nil {
-		t.Fatalf("Error writing to file: %v", err)
+		t.Fatalf("Error reading generated file: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "// Code generated by gorm-gen. DO NOT EDIT.\n") {
+		t.Errorf("Expected generated code header, got:\n%s", content)
 	}
 
-	// Verify file was created (basic check)
-	// In a real test, you might want to read and verify the file content
+	// A hand-written file is only overwritten when forced
+	handWritten := filepath.Join(t.TempDir(), "clone.go")
+	if err := os.WriteFile(handWritten, []byte("package structs\n"), 0644); err != nil {
+		t.Fatalf("Error writing hand-written file: %v", err)
+	}
+	if err := generator.WriteToFile(handWritten); !errors.Is(err, genfile.ErrNotGenerated) {
+		t.Errorf("Expected ErrNotGenerated overwriting a hand-written file, got %v", err)
+	}
+	generator.Force = true
+	if err := generator.WriteToFile(handWritten); err != nil {
+		t.Errorf("Expected forced write to succeed, got %v", err)
+	}
 }
 
 // Manual clone methods for testing (simulating generated code)
@@ -405,11 +432,11 @@ func TestEmbeddedTemplates(t *testing.T) {
 	}
 
 	// Test that the templates contain expected content
-	if !strings.Contains(simpleCloneTemplate, "func (original *{{.Name}}) Clone()") {
+	if !strings.Contains(simpleCloneTemplate, "func (original *{{.Name}}{{.TypeArgs}}) Clone()") {
 		t.Error("Simple template should contain the clone function signature")
 	}
 
-	if !strings.Contains(complexCloneTemplate, "func (original *{{.Name}}) Clone()") {
+	if !strings.Contains(complexCloneTemplate, "func (original *{{.Name}}{{.TypeArgs}}) Clone()") {
 		t.Error("Complex template should contain the clone function signature")
 	}
 
@@ -434,3 +461,43 @@ func TestEmbeddedTemplates(t *testing.T) {
 		t.Error("Parsed complex template should not be nil")
 	}
 }
+
+func TestGenerateSourceFiles(t *testing.T) {
+	generator := New()
+	if err := generator.ParseDirectory("../../examples/multi-file"); err != nil {
+		t.Fatalf("Error parsing directory: %v", err)
+	}
+
+	generated, err := generator.GenerateSourceFiles()
+	if err != nil {
+		t.Fatalf("Error generating source files: %v", err)
+	}
+
+	expected := map[string]string{
+		"address_gen.go": "func (original *Address) Clone()",
+		"company_gen.go": "func (original *Company) Clone()",
+		"contact_gen.go": "func (original *Contact) Clone()",
+		"person_gen.go":  "func (original *Person) Clone()",
+	}
+	if len(generated) != len(expected) {
+		t.Errorf("Expected %d files, got %d", len(expected), len(generated))
+	}
+	for name, method := range expected {
+		if !strings.Contains(generated[name], method) {
+			t.Errorf("Expected %s to contain %s", name, method)
+		}
+	}
+	if strings.Contains(generated["address_gen.go"], "Person") {
+		t.Error("Expected address_gen.go to hold only the methods of address.go")
+	}
+
+	// WriteToPackageDir writes all methods to FileName
+	dir := t.TempDir()
+	generator.FileName = "clone_gen.go"
+	if err := generator.WriteToPackageDir(dir); err != nil {
+		t.Fatalf("Error writing package file: %v", err)
+	}
+	if !genfile.IsGenerated(filepath.Join(dir, "clone_gen.go")) {
+		t.Error("Expected methods to be written to clone_gen.go")
+	}
+}
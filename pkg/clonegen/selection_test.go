@@ -0,0 +1,53 @@
+package clonegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/selection"
+)
+
+// Test models for struct selection
+type TestSelectedClone struct {
+	Items []TestSelectedItem
+}
+
+func (*TestSelectedClone) TableName() string {
+	return "selected_clones"
+}
+
+// TestSelectedItem is pulled in by TestSelectedClone
+type TestSelectedItem struct {
+	Tags []string
+}
+
+// TestSelectedResponse is a DTO without a table
+type TestSelectedResponse struct {
+	Items []string
+}
+
+func TestSelectStructs(t *testing.T) {
+	generator := New()
+	if err := generator.ParseFile("selection_test.go"); err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+	if err := generator.Select(selection.Selector{TrackedOnly: true}); err != nil {
+		t.Fatalf("Failed to select structs: %v", err)
+	}
+
+	var names []string
+	for _, structInfo := range generator.Structs {
+		names = append(names, structInfo.Name)
+	}
+	if strings.Join(names, ",") != "TestSelectedClone,TestSelectedItem" {
+		t.Errorf("Expected TestSelectedClone and its item, got %v", names)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+	if strings.Contains(code, "TestSelectedResponse") {
+		t.Error("Expected generated code not to clone TestSelectedResponse")
+	}
+}
@@ -0,0 +1,59 @@
+package clonegen
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test models for nullable SQL values
+type TestNullableProfile struct {
+	Name     sql.NullString
+	Due      sql.Null[time.Time]
+	Blob     sql.Null[[]byte]
+	Ref      sql.Null[*int]
+	Labels   sql.Null[map[string]string]
+	Nickname *sql.NullString
+}
+
+func TestSQLNullFields(t *testing.T) {
+	generator := New()
+	if err := generator.ParseFile("sql_null_test.go"); err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	categories := make(map[string]FieldType)
+	for _, structInfo := range generator.Structs {
+		for _, field := range structInfo.Fields {
+			categories[field.Name] = field.FieldType
+		}
+	}
+	for name, expected := range map[string]FieldType{
+		"Name":     FieldTypeSimple,
+		"Due":      FieldTypeSimple,
+		"Blob":     FieldTypeSQLNull,
+		"Ref":      FieldTypeSQLNull,
+		"Labels":   FieldTypeSQLNull,
+		"Nickname": FieldTypeValuePtr,
+	} {
+		if categories[name] != expected {
+			t.Errorf("Expected %s to be %s, got %s", name, expected, categories[name])
+		}
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+	for _, expected := range []string{
+		"clone.Blob.V = make([]byte, len(original.Blob.V))",
+		"value := *original.Ref.V",
+		"clone.Labels.V = make(map[string]string)",
+		"value := *original.Nickname",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package clonegen
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test models for type-checked field classification
+type TestTags []string
+
+type TestStatus string
+
+type TestTypedModel struct {
+	Tags     TestTags
+	Query    url.Values
+	Endpoint url.URL
+	Hashes   [4]uint32
+	Status   *TestStatus
+	SeenAt   *time.Time
+	Parent   *TestTypedModel
+	Payload  interface{}
+}
+
+func TestTypeCheckedFieldCategorization(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("typed_fields_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	var model *StructInfo
+	for i := range generator.Structs {
+		if generator.Structs[i].Name == "TestTypedModel" {
+			model = &generator.Structs[i]
+		}
+	}
+	if model == nil {
+		t.Fatal("Expected to find TestTypedModel")
+	}
+
+	expected := map[string]FieldType{
+		"Tags":     FieldTypeSlice,
+		"Query":    FieldTypeMap,
+		"Endpoint": FieldTypeSimple,
+		"Hashes":   FieldTypeSimple,
+		"Status":   FieldTypeValuePtr,
+		"SeenAt":   FieldTypeValuePtr,
+		"Parent":   FieldTypeSimple,
+		"Payload":  FieldTypeInterface,
+	}
+
+	for _, field := range model.Fields {
+		fieldType, ok := expected[field.Name]
+		if !ok {
+			t.Errorf("Unexpected field %s", field.Name)
+			continue
+		}
+		if field.FieldType != fieldType {
+			t.Errorf("Field %s: expected %v, got %v", field.Name, fieldType, field.FieldType)
+		}
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	for _, expectedCode := range []string{
+		"clone.Tags = make(TestTags, len(original.Tags))",
+		"clone.Query = make(url.Values)",
+		"value := *original.SeenAt",
+		"// Payload: Interface field shared with the original",
+	} {
+		if !strings.Contains(code, expectedCode) {
+			t.Errorf("Expected generated code to contain %q", expectedCode)
+		}
+	}
+	if strings.Contains(code, "deepcopy") {
+		t.Error("Expected generated code not to depend on a runtime copier")
+	}
+}
+
+func TestSyntaxCategorizationWithoutTypeInformation(t *testing.T) {
+	// A malformed go.mod makes the package unloadable
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module broken\n\ngo not-a-version\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := `package broken
+
+type Model struct {
+	Name  string
+	Tags  []string
+	Limit map[string]int
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings bytes.Buffer
+	generator := New()
+	generator.Warnings = &warnings
+
+	if err := generator.ParseFile(filepath.Join(dir, "models.go")); err != nil {
+		t.Fatalf("Expected parsing to fall back to syntax, got %v", err)
+	}
+	if !strings.Contains(warnings.String(), "Type information unavailable") {
+		t.Errorf("Expected a warning about missing type information, got %q", warnings.String())
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+	for _, expectedCode := range []string{
+		"clone.Tags = make([]string, len(original.Tags))",
+		"clone.Limit = make(map[string]int)",
+	} {
+		if !strings.Contains(code, expectedCode) {
+			t.Errorf("Expected generated code to contain %q", expectedCode)
+		}
+	}
+}
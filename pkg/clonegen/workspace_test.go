@@ -0,0 +1,49 @@
+package clonegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/workspace"
+)
+
+// Test models for structs of other packages
+type TestAuditLog struct {
+	Change  changeset.FieldChange `gorm:"type:jsonb"`
+	History []changeset.FieldChange
+}
+
+func TestExternalStructs(t *testing.T) {
+	generate := func(external *workspace.Structs) string {
+		generator := New()
+		generator.External = external
+		if err := generator.ParseFile("workspace_test.go"); err != nil {
+			t.Fatalf("Failed to parse test file: %v", err)
+		}
+		code, err := generator.GenerateCode()
+		if err != nil {
+			t.Fatalf("Failed to generate code: %v", err)
+		}
+		return code
+	}
+
+	// Without the struct cloned in its package, its values are copied
+	if code := generate(nil); strings.Contains(code, "(&original.Change).Clone()") {
+		t.Error("Expected no Clone call for a struct of another package without generated methods")
+	}
+
+	external := workspace.NewStructs()
+	external.Add("github.com/ikateclab/gorm-tracked-updates/pkg/changeset", "FieldChange", false)
+	code := generate(external)
+	for _, expected := range []string{
+		`"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"`,
+		"clone.Change = *(&original.Change).Clone()",
+		"clone.History = make([]changeset.FieldChange, len(original.History))",
+		"clone.History[i0] = *v0.Clone()",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package config reads the project configuration of gorm-gen, so that packages share their
+// settings instead of repeating them in every go:generate line. The configuration lives in
+// a .gorm-gen.yaml or gormgen.toml file, found by walking up from the package directory:
+//
+//	generate: [clone, diff, changes]
+//	diff_file: diff_gen.go
+//	exclude: (Request|Response)$
+//	dialect: postgres
+//	json: encoding/json
+//	overrides:
+//	  github.com/shopspring/decimal.Decimal:
+//	    equal: "{{.New}}.Equal({{.Old}})"
+//	    clone: "{{.Value}}.Copy()"
+//	  pgtype.Numeric:
+//	    compare: deep
+//	fields:
+//	  "*.Metadata": diff:"replace"
+//
+// Command line flags override the settings of the file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/typeregistry"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the names of configuration files, in order of precedence within a directory
+var FileNames = []string{".gorm-gen.yaml", ".gorm-gen.yml", "gormgen.toml"}
+
+// Config is the configuration of gorm-gen. Unset settings keep the defaults of the flags.
+type Config struct {
+	Generate          []string                `yaml:"generate" toml:"generate"`                       // Methods to generate: clone, diff, changes, patch, apply, merge
+	Layout            string                  `yaml:"layout" toml:"layout"`                           // Output files: package, source or single
+	CloneFile         string                  `yaml:"clone_file" toml:"clone_file"`                   // File clone methods are written to
+	DiffFile          string                  `yaml:"diff_file" toml:"diff_file"`                     // File diff methods are written to
+	Include           string                  `yaml:"include" toml:"include"`                         // Only structs whose name matches
+	Exclude           string                  `yaml:"exclude" toml:"exclude"`                         // No structs whose name matches
+	Structs           []string                `yaml:"structs" toml:"structs"`                         // Only these structs
+	Tracked           *bool                   `yaml:"tracked" toml:"tracked"`                         // Only structs annotated with @track or declaring TableName
+	Dialect           string                  `yaml:"dialect" toml:"dialect"`                         // SQL dialect of JSON merge expressions
+	JSONMerge         string                  `yaml:"json_merge" toml:"json_merge"`                   // How @jsonb struct columns are merged: shallow or deep
+	JSON              string                  `yaml:"json" toml:"json"`                               // JSON library of generated code: sonic or encoding/json
+	ReplaceJSONArrays *bool                   `yaml:"replace_json_arrays" toml:"replace_json_arrays"` // Replace array JSON columns as a whole
+	Overrides         map[string]TypeOverride `yaml:"overrides" toml:"overrides"`                     // Handling of fields by type, such as github.com/shopspring/decimal.Decimal
+	Fields            map[string]string       `yaml:"fields" toml:"fields"`                           // Default tags of fields by Struct.Field or *.Field
+
+	Path string `yaml:"-" toml:"-"` // File the configuration was read from, empty if none was found
+}
+
+// TypeOverride overrides how the fields of a type are handled. Types are named as written
+// in the source or by their fully qualified name, which expressions require.
+type TypeOverride struct {
+	Compare string   `yaml:"compare" toml:"compare"` // How values are compared: equal (Equal method), comparable (!=) or deep (reflect.DeepEqual)
+	Equal   string   `yaml:"equal" toml:"equal"`     // Expression comparing {{.New}} and {{.Old}}, such as {{.New}}.Equal({{.Old}})
+	Clone   string   `yaml:"clone" toml:"clone"`     // Expression copying {{.Value}}, such as {{.Value}}.Copy()
+	Imports []string `yaml:"imports" toml:"imports"` // Import paths of the packages the expressions refer to
+}
+
+// tagPattern matches struct tags made of key:"value" pairs
+var tagPattern = regexp.MustCompile(`^\s*([^\s:"]+:"(\\.|[^"\\])*"\s*)*$`)
+
+// Find returns the path of the configuration file closest to dir, looking in dir and then
+// in its parent directories, or an empty string if there is none
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the configuration file at path, written in TOML if its name ends in .toml
+// and in YAML otherwise. Unknown settings are reported as errors.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{Path: path}
+	if strings.HasSuffix(path, ".toml") {
+		metadata, err := toml.Decode(string(data), config)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%s: unknown setting %s", path, undecoded[0])
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return config, nil
+}
+
+// ForDir returns the configuration of the package in dir from the closest configuration
+// file, or an empty configuration if there is none
+func ForDir(dir string) (*Config, error) {
+	path, err := Find(dir)
+	if err != nil || path == "" {
+		return &Config{}, err
+	}
+	return Load(path)
+}
+
+// validate checks the settings that are not checked by the flags they set
+func (c *Config) validate() error {
+	for field, tag := range c.Fields {
+		if !strings.Contains(field, ".") {
+			return fmt.Errorf("fields: %q is not of the form Struct.Field or *.Field", field)
+		}
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("fields: tag %s of %s is not of the form key:\"value\"", tag, field)
+		}
+	}
+	for typeName, override := range c.Overrides {
+		if override.Compare == "" && override.Equal == "" && override.Clone == "" {
+			return fmt.Errorf("overrides: %s sets no comparison and no expression", typeName)
+		}
+		if override.Compare != "" && override.Equal != "" {
+			return fmt.Errorf("overrides: %s sets both compare and equal", typeName)
+		}
+	}
+	_, err := c.Types()
+	return err
+}
+
+// Comparisons returns the comparisons of the overridden types, by type name
+func (c *Config) Comparisons() map[string]string {
+	comparisons := make(map[string]string, len(c.Overrides))
+	for typeName, override := range c.Overrides {
+		if override.Compare != "" {
+			comparisons[typeName] = override.Compare
+		}
+	}
+	return comparisons
+}
+
+// Types returns the registry of the overridden types that set expressions
+func (c *Config) Types() (*typeregistry.Registry, error) {
+	registry := typeregistry.New()
+	for typeName, override := range c.Overrides {
+		if override.Equal == "" && override.Clone == "" {
+			continue
+		}
+		err := registry.Register(typeName, typeregistry.Type{
+			Equal:   override.Equal,
+			Clone:   override.Clone,
+			Imports: override.Imports,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("overrides: %v", err)
+		}
+	}
+	return registry, nil
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testYAML = `generate: [clone, diff, changes]
+diff_file: diff_gen.go
+exclude: (Request|Response)$
+tracked: true
+json: encoding/json
+overrides:
+  decimal.Decimal:
+    compare: equal
+  math/big.Int:
+    equal: "{{.New}}.Cmp(&{{.Old}}) == 0"
+    imports: [math/big]
+fields:
+  "*.Metadata": diff:"replace"
+`
+
+const testTOML = `generate = ["clone", "diff", "changes"]
+diff_file = "diff_gen.go"
+exclude = '(Request|Response)$'
+tracked = true
+json = "encoding/json"
+
+[overrides]
+"decimal.Decimal" = { compare = "equal" }
+"math/big.Int" = { equal = "{{.New}}.Cmp(&{{.Old}}) == 0", imports = ["math/big"] }
+
+[fields]
+"*.Metadata" = 'diff:"replace"'
+`
+
+// writeConfig writes a configuration file to dir
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Error creating %s: %v", dir, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Error writing %s: %v", path, err)
+	}
+	return path
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	pkgDir := filepath.Join(root, "internal", "billing")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatalf("Error creating %s: %v", pkgDir, err)
+	}
+
+	// The closest file is found from the package directory up
+	rootConfig := writeConfig(t, root, "gormgen.toml", testTOML)
+	if path, err := Find(pkgDir); err != nil || path != rootConfig {
+		t.Errorf("Expected %s, got %q (%v)", rootConfig, path, err)
+	}
+
+	internalConfig := writeConfig(t, filepath.Join(root, "internal"), ".gorm-gen.yaml", testYAML)
+	if path, err := Find(pkgDir); err != nil || path != internalConfig {
+		t.Errorf("Expected %s, got %q (%v)", internalConfig, path, err)
+	}
+}
+
+func TestForDirWithoutFile(t *testing.T) {
+	config, err := ForDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+	if config.Path != "" {
+		t.Skipf("Configuration file %s found above the temporary directory", config.Path)
+	}
+	if len(config.Generate) > 0 || config.Overrides != nil {
+		t.Errorf("Expected an empty configuration, got %+v", config)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	yamlConfig, err := Load(writeConfig(t, dir, ".gorm-gen.yaml", testYAML))
+	if err != nil {
+		t.Fatalf("Failed to load YAML configuration: %v", err)
+	}
+
+	if !reflect.DeepEqual(yamlConfig.Generate, []string{"clone", "diff", "changes"}) {
+		t.Errorf("Unexpected generate %v", yamlConfig.Generate)
+	}
+	if yamlConfig.DiffFile != "diff_gen.go" || yamlConfig.Exclude != "(Request|Response)$" || yamlConfig.JSON != "encoding/json" {
+		t.Errorf("Unexpected settings %+v", yamlConfig)
+	}
+	if yamlConfig.Tracked == nil || !*yamlConfig.Tracked || yamlConfig.ReplaceJSONArrays != nil {
+		t.Error("Expected tracked to be set and replace_json_arrays to be unset")
+	}
+	if comparisons := yamlConfig.Comparisons(); len(comparisons) != 1 || comparisons["decimal.Decimal"] != "equal" {
+		t.Errorf("Unexpected comparisons %v", comparisons)
+	}
+	registry, err := yamlConfig.Types()
+	if err != nil {
+		t.Fatalf("Failed to build the type registry: %v", err)
+	}
+	if expr, ok := registry.Equal("math/big.Int", "a", "b"); !ok || expr != "a.Cmp(&b) == 0" {
+		t.Errorf("Unexpected equal expression of big.Int %q", expr)
+	}
+	if tag := yamlConfig.Fields["*.Metadata"]; tag != `diff:"replace"` {
+		t.Errorf("Unexpected tag of *.Metadata %s", tag)
+	}
+
+	// The TOML file with the same settings loads the same configuration
+	tomlConfig, err := Load(writeConfig(t, dir, "gormgen.toml", testTOML))
+	if err != nil {
+		t.Fatalf("Failed to load TOML configuration: %v", err)
+	}
+	tomlConfig.Path = yamlConfig.Path
+	if !reflect.DeepEqual(tomlConfig, yamlConfig) {
+		t.Errorf("Expected TOML configuration %+v to equal YAML configuration %+v", tomlConfig, yamlConfig)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"generate: [clone]\nlayuot: single\n":                                                       "field layuot not found",
+		"fields:\n  Metadata: diff:\"replace\"\n":                                                   "not of the form Struct.Field",
+		"fields:\n  \"*.Metadata\": replace\n":                                                      `not of the form key:"value"`,
+		"overrides:\n  decimal.Decimal: {}\n":                                                       "sets no comparison",
+		"overrides:\n  decimal.Decimal:\n    compare: equal\n    equal: \"{{.New}} == {{.Old}}\"\n": "sets both compare and equal",
+		"overrides:\n  Decimal:\n    clone: \"{{.Value}}\"\n":                                       "not of the form import/path.Name",
+		"overrides:\n  math/big.Int:\n    equal: \"{{.New}}.Cmp({{.Other}})\"\n":                    "equal expression of math/big.Int",
+		"generate: clone\n":                             "cannot unmarshal",
+		"overrides:\n  decimal.Decimal:\n    cmp: eq\n": "field cmp not found",
+	}
+	for content, expected := range tests {
+		_, err := Load(writeConfig(t, dir, ".gorm-gen.yaml", content))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error containing %q for %q, got %v", expected, content, err)
+		}
+	}
+
+	tomlTests := map[string]string{
+		"generate = [\"clone\"]\nlayuot = \"single\"\n":         "unknown setting layuot",
+		"[overrides]\n\"decimal.Decimal\" = { cmp = \"eq\" }\n": `unknown setting overrides."decimal.Decimal".cmp`,
+		"generate = \"clone\"\n":                                "incompatible types",
+		"generate = [\"clone\"\n":                               "array terminator",
+		"[fields]\nMetadata = 'diff:\"replace\"'\n":             "not of the form Struct.Field",
+	}
+	for content, expected := range tomlTests {
+		_, err := Load(writeConfig(t, dir, "gormgen.toml", content))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error containing %q for %q, got %v", expected, content, err)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package diffapply
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// Numeric is the constraint of the fields set by Number
+type Numeric interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// errInexact is the conversion error of numbers that do not fit the field
+var errInexact = errors.New("number does not fit the field")
+
+// Number sets the number pointed to by dst from a diff value: a number of any type, such
+// as the float64 of decoded JSON, or a json.Number. Integer fields only accept values
+// they represent exactly. Nil sets zero.
+func Number[T Numeric](key string, value interface{}, dst *T) error {
+	var n T
+	switch v := value.(type) {
+	case nil:
+	case T:
+		n = v
+	case float64:
+		n = T(v)
+		if !isFloat[T]() && (float64(n) != v || math.IsInf(v, 0)) {
+			return mismatch(key, value, dst, errInexact)
+		}
+	case float32:
+		return Number(key, float64(v), dst)
+	case int:
+		return fromInt(key, value, int64(v), dst)
+	case int8:
+		return fromInt(key, value, int64(v), dst)
+	case int16:
+		return fromInt(key, value, int64(v), dst)
+	case int32:
+		return fromInt(key, value, int64(v), dst)
+	case int64:
+		return fromInt(key, value, v, dst)
+	case uint:
+		return fromUint(key, value, uint64(v), dst)
+	case uint8:
+		return fromUint(key, value, uint64(v), dst)
+	case uint16:
+		return fromUint(key, value, uint64(v), dst)
+	case uint32:
+		return fromUint(key, value, uint64(v), dst)
+	case uint64:
+		return fromUint(key, value, v, dst)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return fromInt(key, value, i, dst)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return mismatch(key, value, dst, err)
+		}
+		if err := Number(key, f, dst); err != nil {
+			return mismatch(key, value, dst, errInexact)
+		}
+		return nil
+	default:
+		return mismatch(key, value, dst, nil)
+	}
+	*dst = n
+	return nil
+}
+
+// isFloat reports whether T is a floating-point type
+func isFloat[T Numeric]() bool {
+	half := 0.5
+	return T(half) != 0
+}
+
+// fromInt sets the number pointed to by dst from the integer i of value
+func fromInt[T Numeric](key string, value interface{}, i int64, dst *T) error {
+	n := T(i)
+	if !isFloat[T]() && (int64(n) != i || (n < 0) != (i < 0)) {
+		return mismatch(key, value, dst, errInexact)
+	}
+	*dst = n
+	return nil
+}
+
+// fromUint sets the number pointed to by dst from the unsigned integer u of value
+func fromUint[T Numeric](key string, value interface{}, u uint64, dst *T) error {
+	n := T(u)
+	if !isFloat[T]() && (uint64(n) != u || n < 0) {
+		return mismatch(key, value, dst, errInexact)
+	}
+	*dst = n
+	return nil
+}
+
+// String sets the string pointed to by dst from a diff value. Nil sets "".
+func String[T ~string](key string, value interface{}, dst *T) error {
+	switch v := value.(type) {
+	case nil:
+		*dst = ""
+	case T:
+		*dst = v
+	case string:
+		*dst = T(v)
+	default:
+		return mismatch(key, value, dst, nil)
+	}
+	return nil
+}
+
+// Bool sets the bool pointed to by dst from a diff value. Nil sets false.
+func Bool[T ~bool](key string, value interface{}, dst *T) error {
+	switch v := value.(type) {
+	case nil:
+		*dst = false
+	case T:
+		*dst = v
+	case bool:
+		*dst = T(v)
+	default:
+		return mismatch(key, value, dst, nil)
+	}
+	return nil
+}
+
+// Time sets the time pointed to by dst from a diff value: a time.Time, a *time.Time or an
+// RFC 3339 string. Nil sets the zero time.
+func Time(key string, value interface{}, dst *time.Time) error {
+	switch v := value.(type) {
+	case nil:
+		*dst = time.Time{}
+	case time.Time:
+		*dst = v
+	case *time.Time:
+		if v == nil {
+			*dst = time.Time{}
+		} else {
+			*dst = *v
+		}
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return mismatch(key, value, dst, err)
+		}
+		*dst = t
+	default:
+		return mismatch(key, value, dst, nil)
+	}
+	return nil
+}
+
+// UUID sets the UUID pointed to by dst from a diff value: a uuid.UUID, a *uuid.UUID, a
+// string or 16 bytes. Nil sets the nil UUID.
+func UUID(key string, value interface{}, dst *uuid.UUID) error {
+	switch v := value.(type) {
+	case nil:
+		*dst = uuid.Nil
+	case uuid.UUID:
+		*dst = v
+	case *uuid.UUID:
+		if v == nil {
+			*dst = uuid.Nil
+		} else {
+			*dst = *v
+		}
+	case string:
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return mismatch(key, value, dst, err)
+		}
+		*dst = id
+	case []byte:
+		id, err := uuid.FromBytes(v)
+		if err != nil {
+			return mismatch(key, value, dst, err)
+		}
+		*dst = id
+	default:
+		return mismatch(key, value, dst, nil)
+	}
+	return nil
+}
+
+// NumberPtr sets the number pointer pointed to by dst from a diff value, to a new number
+// converted as by Number. Nil sets nil.
+func NumberPtr[T Numeric](key string, value interface{}, dst **T) error {
+	return setPtr(key, value, dst, Number[T])
+}
+
+// StringPtr sets the string pointer pointed to by dst from a diff value, to a new string
+// converted as by String. Nil sets nil.
+func StringPtr[T ~string](key string, value interface{}, dst **T) error {
+	return setPtr(key, value, dst, String[T])
+}
+
+// BoolPtr sets the bool pointer pointed to by dst from a diff value, to a new bool
+// converted as by Bool. Nil sets nil.
+func BoolPtr[T ~bool](key string, value interface{}, dst **T) error {
+	return setPtr(key, value, dst, Bool[T])
+}
+
+// TimePtr sets the time pointer pointed to by dst from a diff value, to a new time
+// converted as by Time. Nil sets nil.
+func TimePtr(key string, value interface{}, dst **time.Time) error {
+	return setPtr(key, value, dst, Time)
+}
+
+// UUIDPtr sets the UUID pointer pointed to by dst from a diff value, to a new UUID
+// converted as by UUID. Nil sets nil.
+func UUIDPtr(key string, value interface{}, dst **uuid.UUID) error {
+	return setPtr(key, value, dst, UUID)
+}
+
+// setPtr sets the pointer pointed to by dst to a new value converted by set, or to nil
+func setPtr[T any](key string, value interface{}, dst **T, set func(string, interface{}, *T) error) error {
+	switch v := value.(type) {
+	case nil:
+		*dst = nil
+		return nil
+	case *T:
+		if v == nil {
+			*dst = nil
+			return nil
+		}
+		value = *v
+	}
+
+	n := new(T)
+	if err := set(key, value, n); err != nil {
+		return err
+	}
+	*dst = n
+	return nil
+}
+
+// errExpression is the conversion error of SQL expressions, such as the JSON merge
+// expressions of JSON columns
+var errExpression = errors.New("SQL expressions cannot be applied, replay a MergePatch with ApplyMergePatch instead")
+
+// IsExpression reports whether a diff value is an SQL expression, such as the JSON merge
+// expression of a JSON column, or what is left of one after a JSON round trip: an object
+// with the SQL and Vars of a gorm.Expr, or the Column and Patch of a generated merge
+func IsExpression(value interface{}) bool {
+	switch v := value.(type) {
+	case clause.Expression:
+		return true
+	case map[string]interface{}:
+		_, sql := v["SQL"]
+		_, vars := v["Vars"]
+		_, column := v["Column"]
+		_, patch := v["Patch"]
+		return sql && vars || column && patch
+	}
+	return false
+}
+
+// ParseMergePatch decodes an RFC 7386 JSON merge patch of a struct, as returned by a
+// generated MergePatch. Numbers are decoded as json.Number, so large integers keep
+// their precision.
+func ParseMergePatch(patch []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.UseNumber()
+
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("diffapply: merge patch is not a JSON object: %w", err)
+	}
+	return document, nil
+}
+
+// Decode sets the value pointed to by dst from a diff value of its own type, or else by
+// decoding the JSON encoding of the value. JSON documents in strings, as in diffs of JSON
+// columns replaced as a whole, are decoded too. Nil sets the zero value.
+func Decode[T any](key string, value interface{}, dst *T) error {
+	switch v := value.(type) {
+	case nil:
+		var zero T
+		*dst = zero
+		return nil
+	case T:
+		*dst = v
+		return nil
+	}
+	if IsExpression(value) {
+		return mismatch(key, value, dst, errExpression)
+	}
+
+	document, err := json.Marshal(value)
+	if err != nil {
+		return mismatch(key, value, dst, err)
+	}
+
+	var decoded T
+	if err := json.Unmarshal(document, &decoded); err != nil {
+		text, ok := value.(string)
+		if !ok || json.Unmarshal([]byte(text), &decoded) != nil {
+			return mismatch(key, value, dst, err)
+		}
+	}
+	*dst = decoded
+	return nil
+}
+
+// Alloc returns the struct pointed to by the pointer dst points to, allocating it first
+// if the pointer is nil, so nested diffs can be applied to it
+func Alloc[T any](dst **T) *T {
+	if *dst == nil {
+		*dst = new(T)
+	}
+	return *dst
+}
@@ -0,0 +1,168 @@
+package diffapply
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type testStatus string
+
+func TestNumber(t *testing.T) {
+	var i int
+	for _, value := range []interface{}{float64(42), float32(42), int8(42), uint64(42), json.Number("42"), 42} {
+		i = 0
+		if err := Number("count", value, &i); err != nil || i != 42 {
+			t.Errorf("Expected %T %v to set 42, got %d (%v)", value, value, i, err)
+		}
+	}
+
+	var f float32
+	if err := Number("ratio", 0.1, &f); err != nil || f != 0.1 {
+		t.Errorf("Expected float fields to accept inexact values, got %v (%v)", f, err)
+	}
+
+	var u uint8
+	var mismatch *TypeMismatchError
+	for _, value := range []interface{}{2.5, -1, 256, math.Inf(1), math.NaN(), "1", true} {
+		err := Number("small", value, &u)
+		if !errors.As(err, &mismatch) || mismatch.PathString() != "small" || mismatch.Type != "uint8" {
+			t.Errorf("Expected type mismatch for %T %v, got %v", value, value, err)
+		}
+	}
+
+	i = 1
+	if err := Number("count", nil, &i); err != nil || i != 0 {
+		t.Errorf("Expected nil to set zero, got %d (%v)", i, err)
+	}
+}
+
+func TestStringAndBool(t *testing.T) {
+	var status testStatus
+	if err := String("status", "active", &status); err != nil || status != "active" {
+		t.Errorf("Expected named string to be set, got %q (%v)", status, err)
+	}
+	if err := String("status", 1.0, &status); err == nil {
+		t.Error("Expected type mismatch for a number")
+	}
+
+	flag := true
+	if err := Bool("flag", nil, &flag); err != nil || flag {
+		t.Errorf("Expected nil to set false, got %v (%v)", flag, err)
+	}
+}
+
+func TestTimeAndUUID(t *testing.T) {
+	expected := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+
+	var at time.Time
+	if err := Time("at", expected.Format(time.RFC3339Nano), &at); err != nil || !at.Equal(expected) {
+		t.Errorf("Expected RFC 3339 string to set %v, got %v (%v)", expected, at, err)
+	}
+	if err := Time("at", "yesterday", &at); err == nil {
+		t.Error("Expected type mismatch for an invalid time")
+	}
+
+	var ptr *time.Time
+	if err := TimePtr("at", &expected, &ptr); err != nil || ptr == &expected || !ptr.Equal(expected) {
+		t.Errorf("Expected a copy of the time, got %v (%v)", ptr, err)
+	}
+	if err := TimePtr("at", nil, &ptr); err != nil || ptr != nil {
+		t.Errorf("Expected nil to set nil, got %v (%v)", ptr, err)
+	}
+
+	id := uuid.New()
+	var parsed uuid.UUID
+	if err := UUID("id", id.String(), &parsed); err != nil || parsed != id {
+		t.Errorf("Expected string UUID to set %v, got %v (%v)", id, parsed, err)
+	}
+	if err := UUID("id", "not-a-uuid", &parsed); err == nil {
+		t.Error("Expected type mismatch for an invalid UUID")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var tags []string
+	if err := Decode("tags", []interface{}{"a", "b"}, &tags); err != nil || len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("Expected decoded slice, got %v (%v)", tags, err)
+	}
+	if err := Decode("tags", `["c"]`, &tags); err != nil || len(tags) != 1 || tags[0] != "c" {
+		t.Errorf("Expected slice decoded from a JSON document, got %v (%v)", tags, err)
+	}
+	if err := Decode("tags", map[string]interface{}{"a": 1}, &tags); err == nil {
+		t.Error("Expected type mismatch for an object")
+	}
+	if err := Decode("tags", gorm.Expr("tags || ?", "[]"), &tags); err == nil {
+		t.Error("Expected type mismatch for a SQL expression")
+	}
+}
+
+func TestIsExpression(t *testing.T) {
+	expr := gorm.Expr("? || ?", "settings", `{"theme":"dark"}`)
+
+	// The fields of expressions encoded as JSON, as received from a queue
+	var decoded map[string]interface{}
+	document, _ := json.Marshal(map[string]interface{}{"Settings": expr})
+	json.Unmarshal(document, &decoded)
+
+	for _, value := range []interface{}{
+		expr,
+		decoded["Settings"],
+		map[string]interface{}{"Column": map[string]interface{}{"Name": "settings"}, "Patch": "{}"},
+	} {
+		if !IsExpression(value) {
+			t.Errorf("Expected %v to be an expression", value)
+		}
+	}
+	for _, value := range []interface{}{nil, "SQL", map[string]interface{}{"SQL": "select"}} {
+		if IsExpression(value) {
+			t.Errorf("Expected %v not to be an expression", value)
+		}
+	}
+
+	var tags []string
+	var mismatch *TypeMismatchError
+	if err := Decode("tags", decoded["Settings"], &tags); !errors.As(err, &mismatch) || !errors.Is(err, errExpression) {
+		t.Errorf("Expected type mismatch for a decoded SQL expression, got %v", err)
+	}
+}
+
+func TestParseMergePatch(t *testing.T) {
+	patch, err := ParseMergePatch([]byte(`{"id":9007199254740993,"settings":{"theme":null}}`))
+	if err != nil {
+		t.Fatalf("Error parsing merge patch: %v", err)
+	}
+
+	var id int64
+	if err := Number("id", patch["id"], &id); err != nil || id != 9007199254740993 {
+		t.Errorf("Expected large integers to keep their precision, got %d (%v)", id, err)
+	}
+	if settings, ok := patch["settings"].(map[string]interface{}); !ok || settings["theme"] != nil {
+		t.Errorf("Expected nested object with null theme, got %v", patch["settings"])
+	}
+
+	for _, invalid := range []string{`[]`, `"patch"`, `{`} {
+		if _, err := ParseMergePatch([]byte(invalid)); err == nil {
+			t.Errorf("Expected an error parsing %s", invalid)
+		}
+	}
+}
+
+func TestNest(t *testing.T) {
+	err := Nest(Nest(&UnknownKeyError{Struct: "Status", Path: []string{"color"}}, "status"), "settings")
+
+	var unknown *UnknownKeyError
+	if !errors.As(err, &unknown) || unknown.PathString() != "settings.status.color" {
+		t.Errorf("Expected nested path settings.status.color, got %v", err)
+	}
+
+	other := errors.New("other")
+	if Nest(other, "status") != other {
+		t.Error("Expected other errors to be returned unchanged")
+	}
+}
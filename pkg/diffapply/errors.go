@@ -0,0 +1,89 @@
+// Package diffapply holds the conversions and errors used by the ApplyDiff and
+// ApplyMergePatch methods generated by diffgen.
+//
+// ApplyDiff is the inverse of a generated Diff: it sets the fields named by the keys of a
+// diff map to their values. Diffs that went through JSON, for example on a queue, are
+// converted back into the typed fields: numbers from float64, UUIDs and times from
+// strings, and nested struct diffs from objects:
+//
+//	var diff map[string]interface{}
+//	json.Unmarshal(message, &diff)
+//
+//	var unknown *diffapply.UnknownKeyError
+//	if err := service.ApplyDiff(diff); errors.As(err, &unknown) {
+//		log.Printf("Skipping diff for unknown field %s", unknown.PathString())
+//	}
+//
+// The diffs of JSON columns merged in SQL are expressions, which cannot be applied. Models
+// with such columns are replayed from the JSON merge patch of a generated MergePatch, which
+// is plain JSON, with ApplyMergePatch:
+//
+//	patch, _ := service.MergePatch(snapshot) // Sent to the queue
+//	err := replica.ApplyMergePatch(patch)
+package diffapply
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnknownKeyError is returned by ApplyDiff for a diff key that matches no field
+type UnknownKeyError struct {
+	Struct string   // Struct the key was looked up in
+	Path   []string // Keys leading to the unknown key, ending with it
+}
+
+// Error implements the error interface
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("diffapply: %s has no field with diff key %q", e.Struct, e.PathString())
+}
+
+// PathString returns the path of the unknown key joined with dots
+func (e *UnknownKeyError) PathString() string {
+	return strings.Join(e.Path, ".")
+}
+
+// TypeMismatchError is returned by ApplyDiff for a diff value that cannot be converted to
+// the type of its field
+type TypeMismatchError struct {
+	Path  []string    // Keys leading to the field, ending with its own
+	Value interface{} // Value found in the diff
+	Type  string      // Go type of the field
+	Err   error       // Conversion error, if any
+}
+
+// Error implements the error interface
+func (e *TypeMismatchError) Error() string {
+	msg := fmt.Sprintf("diffapply: cannot apply %T value %v to %q of type %s", e.Value, e.Value, e.PathString(), e.Type)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the conversion error
+func (e *TypeMismatchError) Unwrap() error {
+	return e.Err
+}
+
+// PathString returns the path of the field joined with dots
+func (e *TypeMismatchError) PathString() string {
+	return strings.Join(e.Path, ".")
+}
+
+// Nest returns err as seen from the struct containing the struct it was returned for, in
+// the field with the given diff key. Errors of other types are returned unchanged.
+func Nest(err error, key string) error {
+	switch e := err.(type) {
+	case *UnknownKeyError:
+		e.Path = append([]string{key}, e.Path...)
+	case *TypeMismatchError:
+		e.Path = append([]string{key}, e.Path...)
+	}
+	return err
+}
+
+// mismatch returns the TypeMismatchError of applying value to dst
+func mismatch[T any](key string, value interface{}, dst *T, err error) error {
+	return &TypeMismatchError{Path: []string{key}, Value: value, Type: fmt.Sprintf("%T", *dst), Err: err}
+}
@@ -0,0 +1,137 @@
+package diffgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyGeneration(t *testing.T) {
+	generator := New()
+	generator.GenerateApply = true
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if !strings.Contains(code, `"github.com/ikateclab/gorm-tracked-updates/pkg/diffapply"`) {
+		t.Error("Expected diffapply import")
+	}
+
+	// Fields of @jsonb structs are keyed by their JSON key, nested structs recurse
+	dataCode := code[strings.Index(code, "func (m *TestServiceData) ApplyDiff("):]
+	for _, expected := range []string{
+		`case "syncCount":`,
+		"diffapply.Number(key, value, &m.SyncCount)",
+		"diffapply.TimePtr(key, value, &m.LastSyncAt)",
+		"diffapply.Bool(key, value, &m.SyncFlowDone)",
+		"m.Status.ApplyDiff(nested)",
+		`&diffapply.UnknownKeyError{Struct: "TestServiceData", Path: []string{key}}`,
+	} {
+		if !strings.Contains(dataCode, expected) {
+			t.Errorf("Expected TestServiceData.ApplyDiff to contain %q", expected)
+		}
+	}
+
+	// Fields of regular structs are keyed by their name, JSONB columns recurse
+	serviceCode := code[strings.Index(code, "func (m *TestService) ApplyDiff("):]
+	for _, expected := range []string{
+		`case "Id":`,
+		"diffapply.UUID(key, value, &m.Id)",
+		"diffapply.String(key, value, &m.Name)",
+		"diffapply.Alloc(&m.Data).ApplyDiff(nested)",
+		"return diffapply.Nest(err, key)",
+		"diffapply.Decode(key, value, &m.Data)",
+		"diffapply.Time(key, value, &m.CreatedAt)",
+		"diffapply.Decode(key, value, &m.DeletedAt)",
+	} {
+		if !strings.Contains(serviceCode, expected) {
+			t.Errorf("Expected TestService.ApplyDiff to contain %q", expected)
+		}
+	}
+}
+
+func TestApplyMergePatchGeneration(t *testing.T) {
+	generator := New()
+	generator.GenerateApply = true
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	// Merge patches are keyed by JSON keys in every struct and recurse into nested structs
+	serviceCode := code[strings.Index(code, "func (m *TestService) applyMergePatch("):]
+	for _, expected := range []string{
+		"func (m *TestService) ApplyMergePatch(patch []byte) error {",
+		"diffapply.ParseMergePatch(patch)",
+		`case "Id":`,
+		"diffapply.Alloc(&m.Data).applyMergePatch(nested)",
+		`&diffapply.UnknownKeyError{Struct: "TestService", Path: []string{key}}`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+	if !strings.Contains(serviceCode, "diffapply.UUID(key, value, &m.Id)") {
+		t.Error("Expected TestService.applyMergePatch to convert the UUID")
+	}
+
+	// Expressions are not taken for nested diffs
+	if !strings.Contains(code, "value.(map[string]interface{}); ok && !diffapply.IsExpression(value)") {
+		t.Error("Expected ApplyDiff to reject expressions of JSON columns")
+	}
+}
+
+func TestApplyFunc(t *testing.T) {
+	generator := New()
+
+	tests := []struct {
+		field    StructField
+		expected string
+	}{
+		{StructField{Type: "int64", FieldType: FieldTypeSimple}, "Number"},
+		{StructField{Type: "*float64", FieldType: FieldTypeSimple}, "NumberPtr"},
+		{StructField{Type: "string", FieldType: FieldTypeSimple}, "String"},
+		{StructField{Type: "*bool", FieldType: FieldTypeSimple}, "BoolPtr"},
+		{StructField{Type: "time.Time", FieldType: FieldTypeTime}, "Time"},
+		{StructField{Type: "*uuid.UUID", FieldType: FieldTypeUUID}, "UUIDPtr"},
+		{StructField{Type: "[]string", FieldType: FieldTypeSlice}, "Decode"},
+		{StructField{Type: "Status", FieldType: FieldTypeComparable}, "Decode"},
+		{StructField{Type: "*Status", FieldType: FieldTypeComplex}, "Decode"},
+	}
+
+	for _, tt := range tests {
+		if got := generator.applyFunc(tt.field); got != tt.expected {
+			t.Errorf("applyFunc(%s) = %s, expected %s", tt.field.Type, got, tt.expected)
+		}
+	}
+}
+
+func TestApplyNotGeneratedByDefault(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if strings.Contains(code, "ApplyDiff(") || strings.Contains(code, "diffapply") {
+		t.Error("Expected no ApplyDiff methods unless GenerateApply is set")
+	}
+}
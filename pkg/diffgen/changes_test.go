@@ -0,0 +1,69 @@
+package diffgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChangesGeneration(t *testing.T) {
+	generator := New()
+	generator.GenerateChanges = true
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if !strings.Contains(code, `"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"`) {
+		t.Error("Expected changeset import")
+	}
+
+	// Fields of @jsonb structs are located by their JSON key
+	dataCode := code[strings.Index(code, "func (new *TestServiceData) Changes("):]
+	for _, expected := range []string{
+		`changeset.FieldChange{Field: "SyncCount", Path: []string{"syncCount"}, Old: old.SyncCount, New: new.SyncCount}`,
+		"range new.Status.Changes(&old.Status)",
+		`change.Nest("Status", "", "status")`,
+	} {
+		if !strings.Contains(dataCode, expected) {
+			t.Errorf("Expected TestServiceData.Changes to contain %q", expected)
+		}
+	}
+
+	// Fields of regular structs are located by their column, JSONB columns recurse
+	serviceCode := code[strings.Index(code, "func (new *TestService) Changes("):]
+	for _, expected := range []string{
+		`changeset.FieldChange{Field: "Name", Column: "name", Old: old.Name, New: new.Name}`,
+		"if new.Data != nil && old.Data != nil {",
+		"range new.Data.Changes(old.Data)",
+		`change.Nest("Data", "data")`,
+		`changeset.FieldChange{Field: "Data", Column: "data", Old: old.Data, New: new.Data}`,
+	} {
+		if !strings.Contains(serviceCode, expected) {
+			t.Errorf("Expected TestService.Changes to contain %q", expected)
+		}
+	}
+}
+
+func TestChangesNotGeneratedByDefault(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if strings.Contains(code, "Changes(") || strings.Contains(code, "changeset") {
+		t.Error("Expected no Changes methods unless GenerateChanges is set")
+	}
+}
@@ -0,0 +1,154 @@
+package diffgen
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/typeregistry"
+)
+
+// Test models for type comparisons, field tag defaults and the JSON library
+type TestAmount struct {
+	units int64
+	scale int32
+}
+
+func (a TestAmount) Equal(other TestAmount) bool {
+	return a.units*int64(other.scale) == other.units*int64(a.scale)
+}
+
+type TestLedgerEntry struct {
+	Amount   TestAmount
+	Fee      *TestAmount
+	Labels   []string
+	Booked   time.Time
+	Notes    []string
+	Settings []string `gorm:"type:jsonb"`
+	Reserve  *big.Int
+}
+
+func generateLedgerEntry(t *testing.T, configure func(*DiffGenerator)) map[string]string {
+	t.Helper()
+
+	generator := New()
+	configure(generator)
+	if err := generator.ParseFile("config_test.go"); err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	sections := map[string]string{"": code}
+	for _, section := range strings.Split(code, "// Compare ")[1:] {
+		name := section[:strings.Index(section, "\n")]
+		sections[name] = section
+	}
+	return sections
+}
+
+func TestTypeComparisons(t *testing.T) {
+	sections := generateLedgerEntry(t, func(g *DiffGenerator) {
+		g.TypeComparisons = map[string]Comparison{
+			"TestAmount": CompareEqual,
+			"time.Time":  CompareDeep,
+		}
+	})
+
+	if !strings.Contains(sections["Amount"], "!new.Amount.Equal(old.Amount)") {
+		t.Error("Expected Amount to be compared with its Equal method")
+	}
+	if !strings.Contains(sections["Fee"], "!new.Fee.Equal(*old.Fee)") {
+		t.Error("Expected Fee to be compared with the Equal method of its pointee")
+	}
+	if !strings.Contains(sections["Booked"], "reflect.DeepEqual(new.Booked, old.Booked)") {
+		t.Error("Expected Booked to be compared with reflect.DeepEqual")
+	}
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	registry := typeregistry.New()
+	for typeName, registered := range map[string]typeregistry.Type{
+		"github.com/ikateclab/gorm-tracked-updates/pkg/diffgen.TestAmount": {Equal: "{{.New}}.units == {{.Old}}.units"},
+		"math/big.Int": {Equal: "{{.New}}.Cmp(&{{.Old}}) == 0"},
+		"time.Time":    {Clone: "{{.Value}}.Round(0)"},
+	} {
+		if err := registry.Register(typeName, registered); err != nil {
+			t.Fatalf("Failed to register %s: %v", typeName, err)
+		}
+	}
+
+	sections := generateLedgerEntry(t, func(g *DiffGenerator) {
+		g.Types = registry
+		g.TypeComparisons = map[string]Comparison{"TestAmount": CompareDeep}
+	})
+
+	// Registered expressions take precedence over TypeComparisons
+	if !strings.Contains(sections["Amount"], "!(new.Amount.units == old.Amount.units)") {
+		t.Error("Expected Amount to be compared with its registered expression")
+	}
+	if !strings.Contains(sections["Fee"], "new.Fee != nil && !((*new.Fee).units == (*old.Fee).units)") {
+		t.Error("Expected Fee to be compared with the registered expression of its pointee")
+	}
+	if !strings.Contains(sections["Reserve"], "!((*new.Reserve).Cmp(&(*old.Reserve)) == 0)") {
+		t.Error("Expected Reserve to be compared with the registered expression of big.Int")
+	}
+	// Types registered with a clone expression only keep their comparison
+	if !strings.Contains(sections["Booked"], "!new.Booked.Equal(old.Booked)") {
+		t.Error("Expected Booked to keep its time comparison")
+	}
+}
+
+func TestFieldTagDefaults(t *testing.T) {
+	sections := generateLedgerEntry(t, func(g *DiffGenerator) {
+		g.FieldTags = map[string]string{
+			"TestLedgerEntry.Notes": `diff:"-"`,
+			"*.Labels":              `gorm:"type:jsonb" diff:"replace"`,
+			"*.Settings":            `gorm:"type:text" diff:"replace"`,
+		}
+	})
+
+	if _, ok := sections["Notes"]; ok {
+		t.Error("Expected Notes to be excluded by its default tag")
+	}
+	if !strings.Contains(sections["Labels"], "= string(jsonValue)") {
+		t.Error("Expected Labels to be replaced as a JSON column")
+	}
+	// Tags written on the field take precedence over defaults, which fill in the others
+	if !strings.Contains(sections["Settings"], "= string(jsonValue)") {
+		t.Error("Expected Settings to stay a JSON column and be replaced")
+	}
+}
+
+func TestJSONLibrary(t *testing.T) {
+	sections := generateLedgerEntry(t, func(g *DiffGenerator) {
+		g.JSONLibrary = JSONStandard
+	})
+
+	code := sections[""]
+	if strings.Contains(code, "sonic") {
+		t.Error("Expected no sonic calls or import with encoding/json")
+	}
+	if !strings.Contains(code, `"encoding/json"`) || !strings.Contains(code, "json.Marshal(new.Settings)") {
+		t.Error("Expected JSON columns to be encoded with encoding/json")
+	}
+}
+
+func TestParseComparison(t *testing.T) {
+	for name, expected := range map[string]Comparison{
+		"equal":      CompareEqual,
+		"comparable": CompareOperator,
+		"deep":       CompareDeep,
+	} {
+		if comparison, err := ParseComparison(name); err != nil || comparison != expected {
+			t.Errorf("Expected %s to parse as %v, got %v (%v)", name, expected, comparison, err)
+		}
+	}
+	if _, err := ParseComparison("bytes"); err == nil {
+		t.Error("Expected an error for an unknown comparison")
+	}
+}
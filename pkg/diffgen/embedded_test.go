@@ -0,0 +1,135 @@
+package diffgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// Test models for embedded structs
+// TestAuditBase is a shared base embedded by models
+type TestAuditBase struct {
+	CreatedBy string
+	UpdatedBy string
+}
+
+type TestEmbeddedModel struct {
+	gorm.Model
+	TestAuditBase
+	Name      string
+	UpdatedBy string `gorm:"column:modified_by"` // Shadows TestAuditBase.UpdatedBy
+}
+
+type TestEmbeddedPointerModel struct {
+	*TestAuditBase
+	Name string
+}
+
+func TestEmbeddedStructFlattening(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("embedded_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	var model *StructInfo
+	for i := range generator.Structs {
+		if generator.Structs[i].Name == "TestEmbeddedModel" {
+			model = &generator.Structs[i]
+		}
+	}
+	if model == nil {
+		t.Fatal("Expected to find TestEmbeddedModel")
+	}
+
+	expected := map[string]FieldType{
+		"ID":        FieldTypeSimple,
+		"CreatedAt": FieldTypeTime,
+		"UpdatedAt": FieldTypeTime,
+		"DeletedAt": FieldTypeGormDeletedAt,
+		"CreatedBy": FieldTypeSimple,
+		"Name":      FieldTypeSimple,
+		"UpdatedBy": FieldTypeSimple,
+	}
+
+	if len(model.Fields) != len(expected) {
+		t.Errorf("Expected %d flattened fields, got %d: %+v", len(expected), len(model.Fields), model.Fields)
+	}
+
+	for _, field := range model.Fields {
+		fieldType, ok := expected[field.Name]
+		if !ok {
+			t.Errorf("Unexpected field %s", field.Name)
+			continue
+		}
+		if field.FieldType != fieldType {
+			t.Errorf("Field %s: expected %v, got %v", field.Name, fieldType, field.FieldType)
+		}
+		if field.Name == "UpdatedBy" && !strings.Contains(field.Tag, "modified_by") {
+			t.Errorf("Expected outer UpdatedBy to shadow the promoted one, got tag %q", field.Tag)
+		}
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	modelCode := code[strings.Index(code, "func (new *TestEmbeddedModel) Diff("):]
+	for _, expectedCode := range []string{
+		"new.ID != old.ID",
+		"!new.UpdatedAt.Equal(old.UpdatedAt)",
+		"new.DeletedAt != old.DeletedAt",
+		"new.CreatedBy != old.CreatedBy",
+		`diff["UpdatedBy"] = new.UpdatedBy`,
+	} {
+		if !strings.Contains(modelCode, expectedCode) {
+			t.Errorf("Expected generated code to contain %q", expectedCode)
+		}
+	}
+}
+
+func TestEmbeddedPointerIsSkipped(t *testing.T) {
+	var warnings bytes.Buffer
+	generator := New()
+	generator.Warnings = &warnings
+
+	err := generator.ParseFile("embedded_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	for _, structInfo := range generator.Structs {
+		if structInfo.Name != "TestEmbeddedPointerModel" {
+			continue
+		}
+		if len(structInfo.Fields) != 1 || structInfo.Fields[0].Name != "Name" {
+			t.Errorf("Expected only Name field for embedded pointer model, got %+v", structInfo.Fields)
+		}
+	}
+
+	if !strings.Contains(warnings.String(), "Warning: Skipping embedded pointer *TestAuditBase") {
+		t.Errorf("Expected a warning about the skipped embedded pointer, got %q", warnings.String())
+	}
+}
+
+func TestColumnNamingMatchesGORM(t *testing.T) {
+	generator := New()
+
+	testCases := map[string]string{
+		"ID":          "id",
+		"AccountId":   "account_id",
+		"UserID":      "user_id",
+		"HTMLContent": "html_content",
+		"CreatedAt":   "created_at",
+	}
+
+	for fieldName, expected := range testCases {
+		if column := generator.extractColumnName(fieldName, ""); column != expected {
+			t.Errorf("extractColumnName(%q) = %q, expected %q", fieldName, column, expected)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package diffgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test models for excluded fields and structs
+type TestExcludedFields struct {
+	ID        uint
+	Name      string
+	Cache     map[string]string `gormtrack:"-"`
+	Scratch   string            `diff:"-"`
+	Computed  string            `gorm:"-"`
+	Ignored   string            `gorm:"-:all"`
+	Migrated  string            `gorm:"-:migration"`
+	ReadOnly  string            `gorm:"->"`
+	ReadWrite string            `gorm:"->;<-"`
+	CreatedBy string            `gorm:"<-:create"`
+	Frozen    string            `gorm:"<-:false"`
+	UpdatedBy string            `gorm:"<-:update"`
+	// @nodiff
+	Session string
+	Token   string               // @nodiff
+	Version string               // Compared, unlike the @nodiffed Token
+	Options *TestUndiffedOptions `gorm:"type:jsonb;serializer:json"`
+	TestExcludedBase
+	TestIgnoredBase `gorm:"-"`
+}
+
+// TestExcludedBase is embedded, its excluded fields stay excluded when promoted
+type TestExcludedBase struct {
+	Owner string
+	Lock  string `diff:"-"`
+}
+
+// TestIgnoredBase is embedded with gorm:"-", so none of its fields are diffed
+type TestIgnoredBase struct {
+	Draft string
+}
+
+// TestUndiffedOptions is stored in a JSON column but gets no Diff method
+// @jsonb
+// @nodiff
+type TestUndiffedOptions struct {
+	Color string `json:"color"`
+}
+
+func TestExcludedFieldsAreNotDiffed(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("exclusion_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	var fields []string
+	for _, structInfo := range generator.Structs {
+		if structInfo.Name == "TestUndiffedOptions" {
+			t.Error("Expected struct annotated with @nodiff to be skipped")
+		}
+		if structInfo.Name != "TestExcludedFields" {
+			continue
+		}
+		for _, field := range structInfo.Fields {
+			fields = append(fields, field.Name)
+		}
+	}
+
+	expected := []string{"ID", "Name", "Migrated", "ReadWrite", "UpdatedBy", "Version", "Options", "Owner"}
+	if strings.Join(fields, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected fields %v, got %v", expected, fields)
+	}
+
+	if generator.KnownStructs["TestUndiffedOptions"] {
+		t.Error("Expected struct annotated with @nodiff not to be a known struct")
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	// JSON columns of structs without Diff are replaced as a whole
+	if strings.Contains(code, "new.Options.Diff(") {
+		t.Error("Expected no Diff call for a JSON column of a @nodiff struct")
+	}
+	if !strings.Contains(code, "!reflect.DeepEqual(new.Options, old.Options)") {
+		t.Error("Expected JSON column of a @nodiff struct to be replaced as a whole")
+	}
+}
+
+func TestIsGORMUpdatable(t *testing.T) {
+	tests := map[string]bool{
+		"":                     true,
+		"column:name":          true,
+		"-":                    false,
+		"-:all":                false,
+		"-:migration":          true,
+		"->":                   false,
+		"->:false;<-:create":   false,
+		"->;<-:create,update":  true,
+		"<-":                   true,
+		"<-:update":            true,
+		"<-:false":             false,
+		"type:jsonb;<-:create": false,
+	}
+
+	for tag, expected := range tests {
+		if got := isGORMUpdatable(tag); got != expected {
+			t.Errorf("isGORMUpdatable(%q) = %v, expected %v", tag, got, expected)
+		}
+	}
+}
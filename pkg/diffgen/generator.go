@@ -7,12 +7,24 @@ import (
 	"go/ast"
 	"go/format"
 	"go/parser"
+	"go/scanner"
 	"go/token"
+	"go/types"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"text/template"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/genfile"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/selection"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/typeinfo"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/typeregistry"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/workspace"
+	"gorm.io/gorm/schema"
 )
 
 // diffFunctionTemplate contains the embedded template for generating diff functions.
@@ -20,6 +32,42 @@ import (
 //go:embed templates/diff_function.tmpl
 var diffFunctionTemplate string
 
+// jsonbDeepMergeHelper contains the helper functions emitted for JSONMergeDeep mode.
+//go:embed templates/jsonb_deep_merge.tmpl
+var jsonbDeepMergeHelper string
+
+// jsonbPathHelper contains the text[] path helper shared by the Postgres merge helpers.
+//go:embed templates/jsonb_path.tmpl
+var jsonbPathHelper string
+
+// jsonbRemoveHelper contains the helpers deleting removed keys after a shallow || merge.
+//go:embed templates/jsonb_remove.tmpl
+var jsonbRemoveHelper string
+
+// jsonMergeExprHelper contains the dialect-neutral merge expression emitted for DialectAuto.
+//go:embed templates/json_merge_expr.tmpl
+var jsonMergeExprHelper string
+
+// changesFunctionTemplate contains the embedded template for generating Changes methods.
+//go:embed templates/changes_function.tmpl
+var changesFunctionTemplate string
+
+// patchFunctionTemplate contains the embedded template for generating MergePatch and JSONPatch methods.
+//go:embed templates/patch_function.tmpl
+var patchFunctionTemplate string
+
+// applyFunctionTemplate contains the embedded template for generating ApplyDiff methods.
+//go:embed templates/apply_function.tmpl
+var applyFunctionTemplate string
+
+// mergeFunctionTemplate contains the embedded template for generating Merge methods.
+//go:embed templates/merge_function.tmpl
+var mergeFunctionTemplate string
+
+// jsonPatchHelper contains the helpers encoding patch operations as merge patches and JSON patches.
+//go:embed templates/json_patch.tmpl
+var jsonPatchHelper string
+
 // StructField represents a field in a struct
 type StructField struct {
 	Name      string
@@ -27,6 +75,27 @@ type StructField struct {
 	FieldType FieldType
 	Tag       string // Struct tag for the field
 	DiffKey   string // Pre-computed key for diff operations (JSON tag name or field name)
+
+	// OmitEmptyCheck is the Go condition under which an omitempty field of a @jsonb struct
+	// is left out of its JSON object. Empty if the field is never omitted.
+	OmitEmptyCheck string
+
+	// PatchKey is the key of the field in the JSON encoding of its struct (JSON tag name or
+	// field name), used by MergePatch and JSONPatch. Empty for fields tagged json:"-".
+	PatchKey string
+
+	// Replace writes a changed JSON column as a whole instead of merging it, so values
+	// cleared to empty are persisted. Set by diff:"replace" or ReplaceJSONArrays.
+	Replace bool
+
+	excluded  bool   // Left out of Diff, kept until promoted fields are resolved since it still shadows
+	jsonArray bool   // Encoded as a JSON array, which merging would concatenate
+	typeName string // Fully qualified name of the registered type of a FieldTypeCustom field or value
+
+	// Value field of a FieldTypeSQLNull field, such as String for sql.NullString, and the
+	// category its values are compared by
+	nullValue     string
+	nullValueType FieldType
 }
 
 // FieldType categorizes the field type for diff generation
@@ -45,6 +114,10 @@ const (
 	FieldTypeGormDeletedAt                  // gorm.DeletedAt
 	FieldTypeComparable                     // Other types that support == comparison
 	FieldTypeComplex                        // Any other complex type requiring reflection
+	FieldTypeEqual                          // Types compared with their Equal method, set by TypeComparisons
+	FieldTypeCustom                         // Types compared with the equal expression registered in Types
+	FieldTypeSQLNull                        // sql.NullString and the other nullable types of database/sql
+	FieldTypeValuePtr                       // Pointer to a primitive or named scalar value, compared by value
 )
 
 // String returns the string representation of FieldType for template usage
@@ -74,6 +147,14 @@ func (ft FieldType) String() string {
 		return "Comparable"
 	case FieldTypeComplex:
 		return "Complex"
+	case FieldTypeEqual:
+		return "Equal"
+	case FieldTypeCustom:
+		return "Custom"
+	case FieldTypeSQLNull:
+		return "SQLNull"
+	case FieldTypeValuePtr:
+		return "ValuePtr"
 	default:
 		return "Unknown"
 	}
@@ -82,18 +163,241 @@ func (ft FieldType) String() string {
 // StructInfo represents information about a struct
 type StructInfo struct {
 	Name       string
+	TypeParams []TypeParam // Type parameters of a generic struct
 	Fields     []StructField
 	ImportPath string
+	SourceFile string // Path of the file declaring the struct
 	Package    string
 	IsJSONB    bool // Whether this struct is annotated with @jsonb
 }
 
+// TypeParam represents a type parameter of a generic struct
+type TypeParam struct {
+	Name       string
+	Constraint string
+}
+
+// TypeArgs returns the type parameters of the struct as type arguments, such as "[K, V]",
+// for use in receivers. It returns an empty string for non-generic structs.
+func (s StructInfo) TypeArgs() string {
+	if len(s.TypeParams) == 0 {
+		return ""
+	}
+	names := make([]string, len(s.TypeParams))
+	for i, param := range s.TypeParams {
+		names[i] = param.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// JSONMergeMode selects how changes to @jsonb struct columns are written
+type JSONMergeMode int
+
+const (
+	// JSONMergeShallow merges the nested diff with the Postgres || operator. Only top-level
+	// keys are merged, so a changed nested object replaces the stored one.
+	JSONMergeShallow JSONMergeMode = iota
+	// JSONMergeDeep writes nested objects with jsonb_set at their full path, keeping keys
+	// of nested objects that did not change.
+	JSONMergeDeep
+)
+
+// ParseJSONMergeMode parses a JSON merge mode name ("shallow" or "deep")
+func ParseJSONMergeMode(name string) (JSONMergeMode, error) {
+	switch name {
+	case "shallow", "":
+		return JSONMergeShallow, nil
+	case "deep":
+		return JSONMergeDeep, nil
+	default:
+		return JSONMergeShallow, fmt.Errorf("unknown JSON merge mode %q (expected shallow or deep)", name)
+	}
+}
+
+// Dialect selects the SQL dialect the generated JSON merge expressions are written for
+type Dialect int
+
+const (
+	// DialectPostgres merges jsonb columns with the || operator (or jsonb_set in deep mode)
+	DialectPostgres Dialect = iota
+	// DialectMySQL merges JSON columns with JSON_MERGE_PATCH
+	DialectMySQL
+	// DialectSQLite merges JSON columns with json_patch
+	DialectSQLite
+	// DialectAuto emits a clause.Expression that picks the SQL from the dialector name when
+	// the statement is built. Unknown dialectors get the Postgres SQL.
+	DialectAuto
+)
+
+// JSONLibrary selects the package generated code encodes JSON values with
+type JSONLibrary int
+
+const (
+	// JSONSonic encodes with github.com/bytedance/sonic
+	JSONSonic JSONLibrary = iota
+	// JSONStandard encodes with encoding/json, for modules that do not depend on sonic
+	JSONStandard
+)
+
+// ParseJSONLibrary parses a JSON library name ("sonic" or "encoding/json")
+func ParseJSONLibrary(name string) (JSONLibrary, error) {
+	switch name {
+	case "sonic", "":
+		return JSONSonic, nil
+	case "encoding/json":
+		return JSONStandard, nil
+	default:
+		return JSONSonic, fmt.Errorf("unknown JSON library %q (expected sonic or encoding/json)", name)
+	}
+}
+
+// standardJSON rewrites the sonic calls of generated code to encoding/json
+var standardJSON = strings.NewReplacer("sonic.ConfigStd.Marshal(", "json.Marshal(", "sonic.Marshal(", "json.Marshal(")
+
+// Comparison selects how the fields of a type are compared, overriding their classification
+type Comparison int
+
+const (
+	// CompareEqual compares with the Equal method of the type, as for time.Time
+	CompareEqual Comparison = iota
+	// CompareOperator compares with !=
+	CompareOperator
+	// CompareDeep compares with reflect.DeepEqual
+	CompareDeep
+)
+
+// ParseComparison parses a comparison name ("equal", "comparable" or "deep")
+func ParseComparison(name string) (Comparison, error) {
+	switch name {
+	case "equal":
+		return CompareEqual, nil
+	case "comparable":
+		return CompareOperator, nil
+	case "deep":
+		return CompareDeep, nil
+	default:
+		return CompareEqual, fmt.Errorf("unknown comparison %q (expected equal, comparable or deep)", name)
+	}
+}
+
+// fieldType returns the category of fields compared this way
+func (c Comparison) fieldType() FieldType {
+	switch c {
+	case CompareOperator:
+		return FieldTypeComparable
+	case CompareDeep:
+		return FieldTypeComplex
+	default:
+		return FieldTypeEqual
+	}
+}
+
+// ParseDialect parses a dialect name ("postgres", "mysql", "sqlite" or "auto")
+func ParseDialect(name string) (Dialect, error) {
+	switch name {
+	case "postgres", "":
+		return DialectPostgres, nil
+	case "mysql":
+		return DialectMySQL, nil
+	case "sqlite":
+		return DialectSQLite, nil
+	case "auto":
+		return DialectAuto, nil
+	default:
+		return DialectPostgres, fmt.Errorf("unknown dialect %q (expected postgres, mysql, sqlite or auto)", name)
+	}
+}
+
+// numericTypes lists the predeclared numeric types, whose empty value is 0
+var numericTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "byte": true, "rune": true,
+}
+
+// knownEmbeddedStructs lists the promoted fields of external structs that are commonly
+// embedded in models. Their fields are flattened into the embedding struct's Diff.
+var knownEmbeddedStructs = map[string][]StructField{
+	"gorm.Model": {
+		{Name: "ID", Type: "uint"},
+		{Name: "CreatedAt", Type: "time.Time"},
+		{Name: "UpdatedAt", Type: "time.Time"},
+		{Name: "DeletedAt", Type: "gorm.DeletedAt", Tag: "`gorm:\"index\"`"},
+	},
+}
+
 // DiffGenerator handles the code generation for struct diff functions
 type DiffGenerator struct {
 	Structs      []StructInfo
 	KnownStructs map[string]bool
 	Imports      map[string]string
 	JSONBStructs map[string]bool // Tracks which structs are used as JSONB columns
+	JSONMerge    JSONMergeMode   // How @jsonb struct columns are merged on update
+	Dialect      Dialect         // SQL dialect of the generated JSON merge expressions
+	JSONLibrary  JSONLibrary     // Package generated code encodes JSON values with
+
+	// TypeComparisons overrides how fields of a type are compared, by the type as written
+	// in the source without pointer, such as decimal.Decimal, or by its fully qualified
+	// name. Set before parsing.
+	TypeComparisons map[string]Comparison
+
+	// Types holds the expressions registered types are compared with, such as
+	// {{.New}}.Equal({{.Old}}) for github.com/shopspring/decimal.Decimal. They take
+	// precedence over TypeComparisons. Set before parsing.
+	Types *typeregistry.Registry
+
+	// FieldTags are default tags of fields by Struct.Field or *.Field, such as
+	// "*.Metadata": `diff:"replace"`. Keys set by the tag of the field itself take
+	// precedence, then those of Struct.Field. Set before parsing.
+	FieldTags map[string]string
+
+	// ReplaceJSONArrays replaces array and custom slice JSON columns as a whole, since
+	// merging them would concatenate the stored elements with the new ones. New enables
+	// it; fields tagged diff:"merge" keep the merge.
+	ReplaceJSONArrays bool
+
+	// GenerateChanges also generates a Changes method per struct, returning the changed
+	// fields with their old and new values as changeset.FieldChange entries
+	GenerateChanges bool
+
+	// GeneratePatches also generates MergePatch and JSONPatch methods per struct, returning
+	// the changes as RFC 7386 JSON merge patches and RFC 6902 JSON patches
+	GeneratePatches bool
+
+	// GenerateApply also generates ApplyDiff and ApplyMergePatch methods per struct, the
+	// inverses of Diff and MergePatch, setting the fields named by the keys of a diff map
+	// or merge patch
+	GenerateApply bool
+
+	// GenerateMerge also generates a Merge method per struct, merging two versions edited
+	// concurrently from a common base and reporting conflicts as changeset.Conflict entries
+	GenerateMerge bool
+
+	// Force overwrites existing files that were not generated by gorm-gen
+	Force bool
+
+	// FileName is the name of the file WriteToPackageDir writes, diff.go by default
+	FileName string
+
+	// SkipFiles are names of files ParseDirectory skips besides generated files, such as
+	// the configured output files
+	SkipFiles []string
+
+	// External holds the structs generated for the other packages of a multi-package run,
+	// resolving fields whose types are structs of those packages
+	External *workspace.Structs
+
+	// Warnings receives the warnings of parsing, such as fields that are skipped, each
+	// written as one line with a single Write. New sets it to os.Stderr; nil discards them.
+	Warnings io.Writer
+
+	structTypes map[string]*ast.StructType // Struct declarations by name, used to flatten embedded structs
+	noDiff      map[string]bool            // Structs annotated with @nodiff, which get no Diff method
+	tracked     map[string]bool            // Structs annotated with @track or declaring TableName
+	typeParams  map[string]string          // Constraints of the type parameters of the struct being parsed
+	structName  string                     // Name of the struct being parsed, used to look up FieldTags
+	fset        *token.FileSet             // File set of the parsed files, used to look up field types
+	types       *typeinfo.Loader           // Type information of the parsed packages
 }
 
 // New creates a new DiffGenerator
@@ -102,6 +406,22 @@ func New() *DiffGenerator {
 		KnownStructs: make(map[string]bool),
 		Imports:      make(map[string]string),
 		JSONBStructs: make(map[string]bool),
+		FileName:     "diff.go",
+		structTypes:  make(map[string]*ast.StructType),
+		noDiff:       make(map[string]bool),
+		tracked:      make(map[string]bool),
+		fset:         token.NewFileSet(),
+		types:        typeinfo.NewLoader(),
+		Warnings:     os.Stderr,
+
+		ReplaceJSONArrays: true,
+	}
+}
+
+// warnf writes a warning to Warnings
+func (g *DiffGenerator) warnf(format string, args ...interface{}) {
+	if g.Warnings != nil {
+		fmt.Fprintf(g.Warnings, "Warning: "+format+"\n", args...)
 	}
 }
 
@@ -116,20 +436,28 @@ func (g *DiffGenerator) ParseFile(filePath string) error {
 	// Collect struct names for reference
 	g.collectStructNames(node)
 
+	// Collect the structs chosen by Selector.TrackedOnly
+	for _, name := range selection.TrackedTypes(node) {
+		g.tracked[name] = true
+	}
+
 	// Extract imports
 	g.extractImports(node.Imports)
 
+	// Load type information, falling back to syntactic classification without it
+	if err := g.types.Load(filePath); err != nil {
+		g.warnf("Type information unavailable for %s, classifying fields by syntax: %v", filePath, err)
+	}
+
 	// Extract struct details
 	return g.extractStructDetails(node, filePath, packageName)
 }
 
 // parseFileAST parses a Go file and returns the AST node and package name
 func (g *DiffGenerator) parseFileAST(filePath string) (*ast.File, string, error) {
-	// Set up the file set
-	fset := token.NewFileSet()
-
-	// Parse the file
-	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
+	// Parse the file into the generator's file set, so field type expressions can be
+	// matched with the loaded type information
+	node, err := parser.ParseFile(g.fset, filePath, nil, parser.ParseComments)
 	if err != nil {
 		return nil, "", fmt.Errorf("error parsing file: %v", err)
 	}
@@ -137,11 +465,29 @@ func (g *DiffGenerator) parseFileAST(filePath string) (*ast.File, string, error)
 	return node, node.Name.Name, nil
 }
 
-// collectStructNames collects struct names for reference during type determination
+// collectStructNames collects struct names for reference during type determination.
+// Structs annotated with @nodiff are not known structs, since they get no Diff method.
 func (g *DiffGenerator) collectStructNames(node *ast.File) {
 	ast.Inspect(node, func(n ast.Node) bool {
-		if typeSpec, ok := n.(*ast.TypeSpec); ok {
-			if _, isStruct := typeSpec.Type.(*ast.StructType); isStruct {
+		genDecl, ok := n.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			return true
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			if structType, isStruct := typeSpec.Type.(*ast.StructType); isStruct {
+				if g.structTypes == nil {
+					g.structTypes = make(map[string]*ast.StructType)
+				}
+				g.structTypes[typeSpec.Name.Name] = structType
+
+				if selection.HasAnnotation(genDecl.Doc, "@nodiff") || selection.HasAnnotation(typeSpec.Doc, "@nodiff") {
+					g.noDiff[typeSpec.Name.Name] = true
+					continue
+				}
 				g.KnownStructs[typeSpec.Name.Name] = true
 			}
 		}
@@ -156,8 +502,20 @@ func (g *DiffGenerator) extractStructDetails(node *ast.File, filePath, packageNa
 			for _, spec := range genDecl.Specs {
 				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
 					if structType, ok := typeSpec.Type.(*ast.StructType); ok {
-						// Extract fields from struct
+						if g.noDiff[typeSpec.Name.Name] {
+							continue
+						}
+
+						// Extract fields from struct, classifying type parameters by their constraints
+						typeParams := extractTypeParams(typeSpec.TypeParams)
+						g.typeParams = make(map[string]string)
+						for _, param := range typeParams {
+							g.typeParams[param.Name] = param.Constraint
+						}
+						g.structName = typeSpec.Name.Name
 						fields := g.extractFields(structType)
+						g.typeParams = nil
+						g.structName = ""
 
 						// Check for @jsonb annotation in comments
 						// Use genDecl.Doc (declaration comments) instead of typeSpec.Doc
@@ -166,8 +524,10 @@ func (g *DiffGenerator) extractStructDetails(node *ast.File, filePath, packageNa
 						// Add to structs list
 						g.Structs = append(g.Structs, StructInfo{
 							Name:       typeSpec.Name.Name,
+							TypeParams: typeParams,
 							Fields:     fields,
 							ImportPath: filepath.Dir(filePath),
+							SourceFile: filePath,
 							Package:    packageName,
 							IsJSONB:    isJSONB,
 						})
@@ -180,6 +540,35 @@ func (g *DiffGenerator) extractStructDetails(node *ast.File, filePath, packageNa
 	return nil
 }
 
+// extractTypeParams extracts the type parameters of a generic type declaration
+func extractTypeParams(list *ast.FieldList) []TypeParam {
+	if list == nil {
+		return nil
+	}
+
+	var params []TypeParam
+	for _, field := range list.List {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), field.Type); err != nil {
+			continue
+		}
+		for _, name := range field.Names {
+			params = append(params, TypeParam{Name: name.Name, Constraint: buf.String()})
+		}
+	}
+	return params
+}
+
+// baseTypeName strips the pointer and the type arguments from a type, so instantiations
+// of generic structs can be looked up by the struct name
+func baseTypeName(typeStr string) string {
+	baseType := strings.TrimPrefix(typeStr, "*")
+	if i := strings.Index(baseType, "["); i > 0 {
+		baseType = baseType[:i]
+	}
+	return baseType
+}
+
 // extractImports extracts import information from AST imports
 func (g *DiffGenerator) extractImports(imports []*ast.ImportSpec) {
 	for _, imp := range imports {
@@ -198,45 +587,321 @@ func (g *DiffGenerator) extractImports(imports []*ast.ImportSpec) {
 	}
 }
 
-// extractFields extracts field information from a struct
+// extractFields extracts field information from a struct, flattening embedded structs
+// into their promoted fields
 func (g *DiffGenerator) extractFields(structType *ast.StructType) []StructField {
-	var fields []StructField
+	collected, _ := g.collectFields(structType, 0, map[*ast.StructType]bool{})
 
-	for _, field := range structType.Fields.List {
-		// Skip embedded or anonymous fields
-		if len(field.Names) == 0 {
-			continue
+	var fields []StructField
+	for _, field := range collected {
+		if !field.excluded {
+			fields = append(fields, field)
 		}
+	}
+	return fields
+}
+
+// collectFields extracts the fields of a struct together with the embedding depth at which
+// each one is declared. Promoted fields follow Go's selector rules: a shallower field shadows
+// deeper ones, and fields promoted at the same depth from different embeds are ambiguous and dropped.
+func (g *DiffGenerator) collectFields(structType *ast.StructType, depth int, visiting map[*ast.StructType]bool) ([]StructField, []int) {
+	var fields []StructField
+	var depths []int
+
+	// Guard against recursive embedding
+	visiting[structType] = true
+	defer delete(visiting, structType)
 
+	for _, field := range structType.Fields.List {
 		// Get field type as string
 		var buf bytes.Buffer
 		if err := format.Node(&buf, token.NewFileSet(), field.Type); err != nil {
 			// Log error and skip this field - we can't process fields we can't format
-			fmt.Printf("Warning: Could not format field type for field %v: %v\n", field.Names, err)
+			g.warnf("Could not format field type for field %v: %v", field.Names, err)
 			continue
 		}
 		typeStr := buf.String()
 
 		// Get struct tag if present
-		var tagStr string
+		var fieldTag string
 		if field.Tag != nil {
-			tagStr = field.Tag.Value
+			fieldTag = field.Tag.Value
+		}
+
+		// Embedded fields are flattened into the promoted fields of the embedded struct
+		if len(field.Names) == 0 {
+			if g.isExcludedField(field, g.fieldTag(embeddedFieldName(typeStr), fieldTag)) {
+				continue
+			}
+			promoted, promotedDepths := g.collectEmbeddedFields(field.Type, typeStr, depth+1, visiting)
+			fields = append(fields, promoted...)
+			depths = append(depths, promotedDepths...)
+			continue
 		}
 
 		for _, name := range field.Names {
-			// Determine field type category
+			tagStr := g.fieldTag(name.Name, fieldTag)
+
+			// Determine field type category, from the type-checked type when available
 			fieldType := g.determineFieldType(field.Type, typeStr, tagStr)
+			t := g.types.FieldType(g.fset, field.Type)
+			if t != nil {
+				fieldType = g.determineFieldTypeByTypes(t, typeStr, tagStr)
+			}
 
-			fields = append(fields, StructField{
+			structField := StructField{
 				Name:      name.Name,
 				Type:      typeStr,
-				FieldType: fieldType,
 				Tag:       tagStr,
-			})
+				excluded:  g.isExcludedField(field, tagStr),
+				jsonArray: isJSONArray(t, typeStr),
+			}
+			structField.FieldType, structField.typeName = g.overrideFieldType(t, typeStr, fieldType)
+			if structField.FieldType == FieldTypeSQLNull {
+				g.resolveSQLNullValue(&structField, t)
+			}
+			fields = append(fields, structField)
+			depths = append(depths, depth)
 		}
 	}
 
-	return fields
+	return g.resolvePromotedFields(fields, depths)
+}
+
+// collectEmbeddedFields returns the promoted fields of an embedded struct. Structs from the
+// parsed package, known external structs such as gorm.Model and structs of other packages
+// with type information are supported; anything else is skipped with a warning since its
+// fields cannot be resolved.
+func (g *DiffGenerator) collectEmbeddedFields(expr ast.Expr, typeStr string, depth int, visiting map[*ast.StructType]bool) ([]StructField, []int) {
+	if _, isPointer := expr.(*ast.StarExpr); isPointer {
+		// Promoted fields of a nil embedded pointer would panic on access
+		g.warnf("Skipping embedded pointer %s: promoted fields of embedded pointers are not diffed", typeStr)
+		return nil, nil
+	}
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		if embedded, ok := g.structTypes[ident.Name]; ok && !visiting[embedded] {
+			return g.collectFields(embedded, depth, visiting)
+		}
+	}
+
+	if known, ok := knownEmbeddedStructs[typeStr]; ok {
+		var fields []StructField
+		var depths []int
+		for _, field := range known {
+			fieldExpr, err := parser.ParseExpr(field.Type)
+			if err != nil {
+				continue
+			}
+			field.FieldType = g.determineFieldType(fieldExpr, field.Type, field.Tag)
+			fields = append(fields, field)
+			depths = append(depths, depth)
+		}
+		return fields, depths
+	}
+
+	if t := g.types.FieldType(g.fset, expr); t != nil {
+		if structType, ok := t.Underlying().(*types.Struct); ok {
+			return g.collectTypedFields(structType, depth)
+		}
+	}
+
+	g.warnf("Skipping embedded field %s: struct definition not found", typeStr)
+	return nil, nil
+}
+
+// collectTypedFields returns the fields of a struct of another package from its type
+// information, with their types written as the parsed package refers to them. Fields
+// unexported by the other package cannot be accessed and are skipped.
+func (g *DiffGenerator) collectTypedFields(structType *types.Struct, depth int) ([]StructField, []int) {
+	var fields []StructField
+	var depths []int
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		typeStr := types.TypeString(field.Type(), g.qualifier)
+
+		var tagStr string
+		if tag := structType.Tag(i); tag != "" {
+			tagStr = "`" + tag + "`"
+		}
+		tagStr = g.fieldTag(field.Name(), tagStr)
+		if g.isExcludedTag(tagStr) {
+			continue
+		}
+
+		if field.Embedded() {
+			if _, isPointer := field.Type().(*types.Pointer); isPointer {
+				g.warnf("Skipping embedded pointer %s: promoted fields of embedded pointers are not diffed", typeStr)
+				continue
+			}
+			if embedded, ok := field.Type().Underlying().(*types.Struct); ok {
+				promoted, promotedDepths := g.collectTypedFields(embedded, depth+1)
+				fields = append(fields, promoted...)
+				depths = append(depths, promotedDepths...)
+			}
+			continue
+		}
+		if !field.Exported() {
+			continue
+		}
+
+		structField := StructField{Name: field.Name(), Type: typeStr, Tag: tagStr, jsonArray: isJSONArray(field.Type(), typeStr)}
+		fieldType := g.determineFieldTypeByTypes(field.Type(), typeStr, tagStr)
+		structField.FieldType, structField.typeName = g.overrideFieldType(field.Type(), typeStr, fieldType)
+		if structField.FieldType == FieldTypeSQLNull {
+			g.resolveSQLNullValue(&structField, field.Type())
+		}
+		fields = append(fields, structField)
+		depths = append(depths, depth)
+	}
+
+	return g.resolvePromotedFields(fields, depths)
+}
+
+// fieldTag returns the tag of a field of the struct being parsed with the default tags
+// of FieldTags added under it
+func (g *DiffGenerator) fieldTag(fieldName, tagStr string) string {
+	tagStr = mergeTags(tagStr, g.FieldTags[g.structName+"."+fieldName])
+	return mergeTags(tagStr, g.FieldTags["*."+fieldName])
+}
+
+// overrideFieldType applies Types and TypeComparisons to the category of a field of type t,
+// nil without type information, returning the fully qualified name of its type when it is
+// registered in Types. JSON columns are always merged as JSON.
+func (g *DiffGenerator) overrideFieldType(t types.Type, typeStr string, fieldType FieldType) (FieldType, string) {
+	if fieldType == FieldTypeJSON {
+		return fieldType, ""
+	}
+
+	typeName := typeregistry.Name(t, typeStr, g.Imports)
+	if registered, ok := g.Types.Lookup(typeName); ok && registered.Equal != "" {
+		return FieldTypeCustom, typeName
+	}
+	if comparison, ok := g.TypeComparisons[strings.TrimPrefix(typeStr, "*")]; ok {
+		return comparison.fieldType(), ""
+	}
+	if comparison, ok := g.TypeComparisons[typeName]; ok && typeName != "" {
+		return comparison.fieldType(), ""
+	}
+	return fieldType, ""
+}
+
+// resolveSQLNullValue sets the value field of a FieldTypeSQLNull field of type t, nil
+// without type information, and the category its values are compared by
+func (g *DiffGenerator) resolveSQLNullValue(field *StructField, t types.Type) {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	null, _ := typeinfo.SQLNull(t, strings.TrimPrefix(field.Type, "*"))
+	field.nullValue = null.Field
+
+	if null.Type != nil {
+		field.nullValueType = g.determineFieldTypeByTypes(null.Type, null.TypeStr, "")
+	} else if expr, err := parser.ParseExpr(null.TypeStr); err == nil {
+		field.nullValueType = g.determineFieldType(expr, null.TypeStr, "")
+	} else {
+		field.nullValueType = FieldTypeComplex
+	}
+	field.nullValueType, field.typeName = g.overrideFieldType(null.Type, null.TypeStr, field.nullValueType)
+}
+
+// sqlNullChanged returns the condition under which the nullable SQL values newValue and
+// oldValue of a field differ: their validity, or their values when both are valid
+func (g *DiffGenerator) sqlNullChanged(field StructField, newValue, oldValue string) string {
+	newField := newValue + "." + field.nullValue
+	oldField := oldValue + "." + field.nullValue
+
+	var equal string
+	switch field.nullValueType {
+	case FieldTypeTime, FieldTypeEqual:
+		equal = newField + ".Equal(" + oldField + ")"
+	case FieldTypeCustom:
+		equal = g.customEqual(field, newField, oldField)
+	case FieldTypeSimple, FieldTypeComparable, FieldTypeUUID, FieldTypeGormDeletedAt:
+		equal = newField + " == " + oldField
+	default:
+		equal = "reflect.DeepEqual(" + newField + ", " + oldField + ")"
+	}
+	return fmt.Sprintf("%s.Valid != %s.Valid || (%s.Valid && !(%s))", newValue, oldValue, newValue, equal)
+}
+
+// customEqual returns the expression comparing the values newValue and oldValue of a
+// field of a type registered in Types
+func (g *DiffGenerator) customEqual(field StructField, newValue, oldValue string) string {
+	expr, _ := g.Types.Equal(field.typeName, newValue, oldValue)
+	return expr
+}
+
+// mergeTags adds the key:"value" pairs of defaults whose keys tagStr does not set to
+// tagStr. Both are struct tags as written in source, in backticks.
+func mergeTags(tagStr, defaults string) string {
+	if defaults == "" {
+		return tagStr
+	}
+
+	tag := reflect.StructTag(strings.Trim(tagStr, "`"))
+	merged := string(tag)
+	for _, pair := range tagPairRegexp.FindAllStringSubmatch(strings.Trim(defaults, "`"), -1) {
+		if _, ok := tag.Lookup(pair[1]); !ok {
+			merged = strings.TrimSpace(merged + " " + pair[0])
+		}
+	}
+	if merged == "" {
+		return ""
+	}
+	return "`" + merged + "`"
+}
+
+// tagPairRegexp matches the key:"value" pairs of a struct tag
+var tagPairRegexp = regexp.MustCompile(`([^\s:"]+):"(?:\\.|[^"\\])*"`)
+
+// embeddedFieldName returns the name of an embedded field of the given type, such as
+// Model for "*gorm.Model"
+func embeddedFieldName(typeStr string) string {
+	name := baseTypeName(typeStr)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// qualifier names the packages of types from other packages by the names the parsed
+// package imports them under
+func (g *DiffGenerator) qualifier(pkg *types.Package) string {
+	if name, ok := g.Imports[pkg.Path()]; ok {
+		return name
+	}
+	return pkg.Name()
+}
+
+// resolvePromotedFields applies Go's shadowing rules to fields collected at different
+// embedding depths, keeping declaration order
+func (g *DiffGenerator) resolvePromotedFields(fields []StructField, depths []int) ([]StructField, []int) {
+	shallowest := make(map[string]int)
+	count := make(map[string]int)
+	for i, field := range fields {
+		if d, ok := shallowest[field.Name]; !ok || depths[i] < d {
+			shallowest[field.Name] = depths[i]
+			count[field.Name] = 1
+		} else if depths[i] == d {
+			count[field.Name]++
+		}
+	}
+
+	var resolved []StructField
+	var resolvedDepths []int
+	for i, field := range fields {
+		if depths[i] != shallowest[field.Name] {
+			continue
+		}
+		if count[field.Name] > 1 {
+			if depths[i] > 0 {
+				g.warnf("Skipping ambiguous promoted field %s", field.Name)
+			}
+			continue
+		}
+		resolved = append(resolved, field)
+		resolvedDepths = append(resolvedDepths, depths[i])
+	}
+
+	return resolved, resolvedDepths
 }
 
 // determineKnownTypeByString checks for known types by their string representation
@@ -278,7 +943,7 @@ func (g *DiffGenerator) determineFieldType(expr ast.Expr, typeStr string, tagStr
 
 	// For nested JSONB structs without database JSON tags, treat as regular struct fields
 	// This prevents nested gorm.Expr calls
-	baseType := strings.TrimPrefix(typeStr, "*")
+	baseType := baseTypeName(typeStr)
 	if g.JSONBStructs[baseType] {
 		// This is a nested JSONB struct, but treat as regular struct to avoid nested gorm.Expr
 		if strings.HasPrefix(typeStr, "*") {
@@ -288,6 +953,11 @@ func (g *DiffGenerator) determineFieldType(expr ast.Expr, typeStr string, tagStr
 		}
 	}
 
+	// Nullable SQL values are compared by validity and value, even sql.Null[time.Time]
+	if _, ok := typeinfo.SQLNull(nil, strings.TrimPrefix(typeStr, "*")); ok {
+		return FieldTypeSQLNull
+	}
+
 	// Check for specific known types by string representation
 	if fieldType := g.determineKnownTypeByString(typeStr); fieldType != FieldTypeComplex {
 		return fieldType
@@ -296,6 +966,76 @@ func (g *DiffGenerator) determineFieldType(expr ast.Expr, typeStr string, tagStr
 	return g.determineFieldTypeByAST(expr)
 }
 
+// determineFieldTypeByTypes determines the category of a field from its type-checked type.
+// Unlike the syntactic classification it sees through named types, so `type Tags []string`
+// is a slice and a struct from another package containing a map is not compared with !=.
+func (g *DiffGenerator) determineFieldTypeByTypes(t types.Type, typeStr string, tagStr string) FieldType {
+	// JSON columns and nested JSONB structs are recognized by their tags and annotations
+	if g.isJSONField(tagStr) {
+		return FieldTypeJSON
+	}
+	baseType := baseTypeName(typeStr)
+	if g.JSONBStructs[baseType] {
+		if strings.HasPrefix(typeStr, "*") {
+			return FieldTypeStructPtr
+		}
+		return FieldTypeStruct
+	}
+
+	// Nullable SQL values are compared by validity and value
+	if _, ok := typeinfo.SQLNull(t, typeStr); ok {
+		return FieldTypeSQLNull
+	}
+	if ptr, ok := t.(*types.Pointer); ok {
+		if _, ok := typeinfo.SQLNull(ptr.Elem(), strings.TrimPrefix(typeStr, "*")); ok {
+			return FieldTypeSQLNull
+		}
+	}
+
+	// Type parameters are compared with != only if their constraint guarantees it
+	if _, ok := t.(*types.TypeParam); ok {
+		if types.Comparable(t) {
+			return FieldTypeComparable
+		}
+		return FieldTypeComplex
+	}
+
+	switch {
+	case typeinfo.IsNamed(t, "time", "Time"), typeinfo.IsNamedPointer(t, "time", "Time"):
+		return FieldTypeTime
+	case typeinfo.IsNamed(t, "github.com/google/uuid", "UUID"), typeinfo.IsNamedPointer(t, "github.com/google/uuid", "UUID"):
+		return FieldTypeUUID
+	case typeinfo.IsNamed(t, "gorm.io/gorm", "DeletedAt"):
+		return FieldTypeGormDeletedAt
+	case typeinfo.IsNamed(t, "gorm.io/datatypes", "JSON"):
+		return FieldTypeJSON
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return FieldTypeSimple
+	case *types.Pointer:
+		// Clones point to copies of values, so those are compared by the value pointed to.
+		// Other pointers are compared by address to avoid following relationships.
+		if _, ok := u.Elem().Underlying().(*types.Basic); ok {
+			return FieldTypeValuePtr
+		}
+		return FieldTypeComparable
+	case *types.Slice:
+		return FieldTypeSlice
+	case *types.Map:
+		return FieldTypeMap
+	case *types.Interface:
+		return FieldTypeInterface
+	}
+
+	// Structs of the parsed package use reflect.DeepEqual, as in the syntactic classification
+	if typeinfo.Comparable(t) && !g.KnownStructs[typeStr] {
+		return FieldTypeComparable
+	}
+	return FieldTypeComplex
+}
+
 // determineFieldTypeByAST analyzes AST expressions to determine field type
 func (g *DiffGenerator) determineFieldTypeByAST(expr ast.Expr) FieldType {
 	switch t := expr.(type) {
@@ -318,6 +1058,13 @@ func (g *DiffGenerator) determineFieldTypeByAST(expr ast.Expr) FieldType {
 
 // handleIdentType handles ast.Ident expressions
 func (g *DiffGenerator) handleIdentType(t *ast.Ident) FieldType {
+	// Type parameters are compared with != only if constrained to comparable types
+	if constraint, ok := g.typeParams[t.Name]; ok {
+		if constraint == "comparable" {
+			return FieldTypeComparable
+		}
+		return FieldTypeComplex
+	}
 	// Check for common patterns that indicate slice types (but not JsonbStringSlice with JSON tags)
 	if strings.Contains(strings.ToLower(t.Name), "slice") {
 		return FieldTypeComplex
@@ -344,6 +1091,14 @@ func (g *DiffGenerator) handlePointerType(t *ast.StarExpr) FieldType {
 			return FieldTypeUUID
 		}
 	}
+	// Pointers to primitives are compared by the value pointed to, since clones point to copies
+	if ident, ok := t.X.(*ast.Ident); ok {
+		if typeName, ok := types.Universe.Lookup(ident.Name).(*types.TypeName); ok {
+			if _, ok := typeName.Type().(*types.Basic); ok {
+				return FieldTypeValuePtr
+			}
+		}
+	}
 	// For pointer to known structs, treat as comparable to avoid relationship handling
 	// Only JSONB fields should be handled specially through field tags
 	return FieldTypeComparable
@@ -393,16 +1148,50 @@ func (g *DiffGenerator) isJSONField(tagStr string) bool {
 
 // hasJSONBAnnotation checks if a struct has @jsonb annotation in its comments
 func (g *DiffGenerator) hasJSONBAnnotation(commentGroup *ast.CommentGroup) bool {
-	if commentGroup == nil {
-		return false
+	return selection.HasAnnotation(commentGroup, "@jsonb")
+}
+
+// isExcludedField checks if a field is left out of Diff: tagged gormtrack:"-" or diff:"-",
+// annotated with @nodiff, or a column GORM does not update
+func (g *DiffGenerator) isExcludedField(field *ast.Field, tagStr string) bool {
+	if selection.HasAnnotation(field.Doc, "@nodiff") || selection.HasAnnotation(field.Comment, "@nodiff") {
+		return true
 	}
+	return g.isExcludedTag(tagStr)
+}
 
-	for _, comment := range commentGroup.List {
-		if strings.Contains(comment.Text, "@jsonb") {
-			return true
+// isExcludedTag checks if the tags of a field leave it out of Diff, as isExcludedField
+// does for fields whose comments are not available
+func (g *DiffGenerator) isExcludedTag(tagStr string) bool {
+	tag := reflect.StructTag(strings.Trim(tagStr, "`"))
+	if tag.Get("gormtrack") == "-" || tag.Get("diff") == "-" {
+		return true
+	}
+	return !isGORMUpdatable(tag.Get("gorm"))
+}
+
+// isGORMUpdatable checks if GORM writes a field on update, following its rules for the
+// gorm:"-" and permission tags: "-" and "-:all" ignore the field, "->" makes it read-only
+// unless "<-" is given too, and "<-" without "update", such as "<-:create" or "<-:false",
+// excludes updates
+func isGORMUpdatable(gormTag string) bool {
+	settings := schema.ParseTagSetting(gormTag, ";")
+
+	if value, ok := settings["-"]; ok {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "-", "all":
+			return false
 		}
 	}
-	return false
+
+	updatable := true
+	if _, ok := settings["->"]; ok {
+		updatable = false
+	}
+	if value, ok := settings["<-"]; ok {
+		updatable = value == "<-" || strings.Contains(value, "update")
+	}
+	return updatable
 }
 
 // extractColumnName extracts the column name from GORM tag or converts field name to snake_case
@@ -425,12 +1214,10 @@ func (g *DiffGenerator) extractColumnName(fieldName, tagStr string) string {
 	return g.toSnakeCase(fieldName)
 }
 
-// toSnakeCase converts CamelCase to snake_case
+// toSnakeCase converts CamelCase to snake_case using GORM's default naming strategy,
+// so initialisms like ID or URL map to the same column names GORM uses
 func (g *DiffGenerator) toSnakeCase(str string) string {
-	// Insert underscore before uppercase letters (except the first one)
-	re := regexp.MustCompile(`([a-z0-9])([A-Z])`)
-	snake := re.ReplaceAllString(str, `${1}_${2}`)
-	return strings.ToLower(snake)
+	return schema.NamingStrategy{}.ColumnName("", str)
 }
 
 // extractJSONTagName extracts the JSON tag name from a struct field tag
@@ -453,9 +1240,98 @@ func (g *DiffGenerator) extractJSONTagName(fieldName, tagStr string) string {
 	return fieldName
 }
 
-// computeFieldKeysAndIdentifyJSONB identifies which structs are annotated with @jsonb
-// and computes diff keys for all fields
-func (g *DiffGenerator) computeFieldKeysAndIdentifyJSONB() {
+// extractDiffTag returns the value of the diff:"..." struct tag, or "" if there is none
+func (g *DiffGenerator) extractDiffTag(tagStr string) string {
+	tagStr = strings.Trim(tagStr, "`")
+	re := regexp.MustCompile(`diff:"([^"]*)"`)
+	if matches := re.FindStringSubmatch(tagStr); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// isJSONReplace reports whether a JSON column is written as a whole instead of merged
+func (g *DiffGenerator) isJSONReplace(field StructField) bool {
+	switch g.extractDiffTag(field.Tag) {
+	case "replace":
+		return true
+	case "merge":
+		return false
+	default:
+		return g.ReplaceJSONArrays && field.jsonArray
+	}
+}
+
+// isJSONArrayType reports whether a JSON column type is stored as a JSON array
+func isJSONArrayType(typeStr string) bool {
+	return strings.HasPrefix(typeStr, "[]") || strings.HasSuffix(typeStr, "Slice")
+}
+
+// isJSONArray reports whether values of type t, nil without type information, are encoded
+// as JSON arrays: slices and arrays, except byte slices such as datatypes.JSON and
+// json.RawMessage, which hold whole JSON documents
+func isJSONArray(t types.Type, typeStr string) bool {
+	if t == nil {
+		return isJSONArrayType(typeStr) && typeStr != "[]byte"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		basic, ok := u.Elem().Underlying().(*types.Basic)
+		return !ok || basic.Kind() != types.Byte
+	case *types.Array:
+		return true
+	}
+	return false
+}
+
+// extractPatchKey returns the key of a field in the JSON encoding of its struct, or ""
+// if the field is tagged json:"-" and never encoded
+func (g *DiffGenerator) extractPatchKey(fieldName, tagStr string) string {
+	re := regexp.MustCompile(`json:"-"`)
+	if re.MatchString(strings.Trim(tagStr, "`")) {
+		return ""
+	}
+	return g.extractJSONTagName(fieldName, tagStr)
+}
+
+// omitEmptyCheck returns the condition under which encoding/json omits an omitempty field
+// of the new struct value
+func (g *DiffGenerator) omitEmptyCheck(field StructField) string {
+	return g.omitEmptyCondition(field, "new."+field.Name)
+}
+
+// omitEmptyCondition returns the condition under which encoding/json omits value, an
+// omitempty field: false, 0, a nil pointer or interface, or an empty string, slice or map.
+// Structs are never omitted, and named types are skipped because their underlying type
+// is unknown here.
+func (g *DiffGenerator) omitEmptyCondition(field StructField, value string) string {
+	tagStr := strings.Trim(field.Tag, "`")
+	re := regexp.MustCompile(`json:"[^"]*,omitempty`)
+	if !re.MatchString(tagStr) {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(field.Type, "*"), field.Type == "interface{}", field.Type == "any":
+		return value + " == nil"
+	case strings.HasPrefix(field.Type, "[]"), strings.HasPrefix(field.Type, "map["):
+		return "len(" + value + ") == 0"
+	case field.Type == "string":
+		return value + ` == ""`
+	case field.Type == "bool":
+		return "!" + value
+	case numericTypes[field.Type]:
+		return value + " == 0"
+	default:
+		return ""
+	}
+}
+
+// computeFieldKeysAndIdentifyJSONB identifies which structs are annotated with @jsonb
+// and computes diff keys for all fields
+func (g *DiffGenerator) computeFieldKeysAndIdentifyJSONB() {
+	g.resolveExternalStructs()
+
 	// First, mark structs that have @jsonb annotation
 	for _, structInfo := range g.Structs {
 		if structInfo.IsJSONB {
@@ -473,9 +1349,11 @@ func (g *DiffGenerator) computeFieldKeysAndIdentifyJSONB() {
 			// This prevents nested gorm.Expr calls
 			if g.isJSONField(field.Tag) {
 				field.FieldType = FieldTypeJSON
+				// Structs annotated with @nodiff have no Diff method to merge with
+				field.Replace = g.isJSONReplace(*field) || g.noDiff[baseTypeName(field.Type)]
 			} else {
 				// For nested JSONB structs without database JSON tags, treat as regular struct
-				baseType := strings.TrimPrefix(field.Type, "*")
+				baseType := baseTypeName(field.Type)
 				if g.JSONBStructs[baseType] {
 					// This is a nested JSONB struct, but treat as regular struct to avoid nested gorm.Expr
 					if strings.HasPrefix(field.Type, "*") {
@@ -490,10 +1368,30 @@ func (g *DiffGenerator) computeFieldKeysAndIdentifyJSONB() {
 			if g.JSONBStructs[g.Structs[i].Name] {
 				// For JSONB structs, use JSON tag names
 				field.DiffKey = g.extractJSONTagName(field.Name, field.Tag)
+				field.OmitEmptyCheck = g.omitEmptyCheck(*field)
 			} else {
 				// For regular structs, use field names
 				field.DiffKey = field.Name
 			}
+			field.PatchKey = g.extractPatchKey(field.Name, field.Tag)
+		}
+	}
+}
+
+// resolveExternalStructs registers the structs generated for the packages of a
+// multi-package run that the parsed package imports, under the names they are imported
+// by, so fields of those types get nested Diff calls
+func (g *DiffGenerator) resolveExternalStructs() {
+	if g.External == nil {
+		return
+	}
+	for importPath, importName := range g.Imports {
+		for name, jsonb := range g.External.Package(importPath) {
+			qualified := importName + "." + name
+			g.KnownStructs[qualified] = true
+			if jsonb {
+				g.JSONBStructs[qualified] = true
+			}
 		}
 	}
 }
@@ -512,55 +1410,170 @@ func (g *DiffGenerator) hasJSONFields() bool {
 
 // GenerateCode generates the code for all struct diff functions
 func (g *DiffGenerator) GenerateCode() (string, error) {
+	return g.generateCode(g.Structs, true)
+}
+
+// GenerateSourceFiles generates the code of each parsed source file separately, keyed by
+// output file name: <source>_gen.go holds the methods of the structs declared in
+// <source>.go, and genfile.HelpersFileName the helper functions they share, if any
+func (g *DiffGenerator) GenerateSourceFiles() (map[string]string, error) {
+	if len(g.Structs) == 0 {
+		return nil, fmt.Errorf("no structs found")
+	}
+
+	var sources []string
+	bySource := make(map[string][]StructInfo)
+	for _, structInfo := range g.Structs {
+		if _, ok := bySource[structInfo.SourceFile]; !ok {
+			sources = append(sources, structInfo.SourceFile)
+		}
+		bySource[structInfo.SourceFile] = append(bySource[structInfo.SourceFile], structInfo)
+	}
+
+	files := make(map[string]string)
+	for _, source := range sources {
+		code, err := g.generateCode(bySource[source], false)
+		if err != nil {
+			return nil, err
+		}
+		files[genfile.SourceFileName(source)] = code
+	}
+
+	helpers, err := g.generateCode(nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if helpers != "" {
+		files[genfile.HelpersFileName] = helpers
+	}
+
+	return files, nil
+}
+
+// generateCode generates the methods of structs, and the helper functions of all parsed
+// structs if helpers is set. It returns an empty string if there is nothing to generate.
+func (g *DiffGenerator) generateCode(structs []StructInfo, helpers bool) (string, error) {
 	var buf bytes.Buffer
 
 	// Identify which structs are used as JSONB columns and compute field keys
 	g.computeFieldKeysAndIdentifyJSONB()
 
-	// Generate package declaration
+	// Generate header and package declaration
 	if len(g.Structs) > 0 {
+		buf.WriteString(genfile.Header())
 		fmt.Fprintf(&buf, "package %s\n\n", g.Structs[0].Package)
 	} else {
 		return "", fmt.Errorf("no structs found")
 	}
 
-	// Check if we need GORM imports
-	needsGORM := g.hasJSONFields()
-
-	// Generate imports
-	fmt.Fprintln(&buf, "import (")
-	if needsGORM {
-		fmt.Fprintln(&buf, "\t\"bytes\"")
-		fmt.Fprintln(&buf, "\t\"github.com/bytedance/sonic\"")
-	}
-	fmt.Fprintln(&buf, "\t\"reflect\"")
-	if needsGORM {
-		fmt.Fprintln(&buf, "\t\"strings\"")
-		fmt.Fprintln(&buf, "\t\"gorm.io/gorm\"")
-		fmt.Fprintln(&buf, "\t\"gorm.io/gorm/clause\"")
-	}
-	fmt.Fprintln(&buf, ")")
-	fmt.Fprintln(&buf)
-
 	// Generate helper functions if JSON fields are present
-	if needsGORM {
-		fmt.Fprintln(&buf, "// isEmptyJSON checks if a JSON string represents an empty object or array")
-		fmt.Fprintln(&buf, "func isEmptyJSON(jsonStr string) bool {")
-		fmt.Fprintln(&buf, "\ttrimmed := strings.TrimSpace(jsonStr)")
-		fmt.Fprintln(&buf, "\treturn trimmed == \"{}\" || trimmed == \"[]\" || trimmed == \"null\"")
-		fmt.Fprintln(&buf, "}")
-		fmt.Fprintln(&buf)
+	var body bytes.Buffer
+	if helpers && g.hasJSONFields() {
+		fmt.Fprintln(&body, "// isEmptyJSON checks if a JSON string represents an empty object or array")
+		fmt.Fprintln(&body, "func isEmptyJSON(jsonStr string) bool {")
+		fmt.Fprintln(&body, "\ttrimmed := strings.TrimSpace(jsonStr)")
+		fmt.Fprintln(&body, "\treturn trimmed == \"{}\" || trimmed == \"[]\" || trimmed == \"null\"")
+		fmt.Fprintln(&body, "}")
+		fmt.Fprintln(&body)
+
+		if g.usesJSONBHelpers() {
+			fmt.Fprintln(&body, jsonbPathHelper)
+		}
+		if g.usesJSONBDeepMerge() {
+			fmt.Fprintln(&body, jsonbDeepMergeHelper)
+		} else if g.usesJSONBHelpers() {
+			fmt.Fprintln(&body, jsonbRemoveHelper)
+		}
+
+		if g.Dialect == DialectAuto {
+			helper, err := template.New("jsonMergeExpr").Parse(jsonMergeExprHelper)
+			if err != nil {
+				return "", fmt.Errorf("error parsing embedded template: %v", err)
+			}
+			data := struct{ Deep bool }{Deep: g.JSONMerge == JSONMergeDeep}
+			if err := helper.Execute(&body, data); err != nil {
+				return "", fmt.Errorf("error executing template: %v", err)
+			}
+			fmt.Fprintln(&body)
+		}
 	}
 
 	// Generate diff functions for each struct
-	for _, structInfo := range g.Structs {
+	for _, structInfo := range structs {
 		code, err := g.GenerateDiffFunction(structInfo)
 		if err != nil {
 			return "", err
 		}
-		buf.WriteString(code)
-		buf.WriteString("\n\n")
+		body.WriteString(code)
+		body.WriteString("\n\n")
+
+		if g.GenerateChanges {
+			code, err := g.GenerateChangesFunction(structInfo)
+			if err != nil {
+				return "", err
+			}
+			body.WriteString(code)
+			body.WriteString("\n\n")
+		}
+
+		if g.GeneratePatches {
+			code, err := g.GeneratePatchFunctions(structInfo)
+			if err != nil {
+				return "", err
+			}
+			body.WriteString(code)
+			body.WriteString("\n\n")
+		}
+
+		if g.GenerateApply {
+			code, err := g.GenerateApplyFunction(structInfo)
+			if err != nil {
+				return "", err
+			}
+			body.WriteString(code)
+			body.WriteString("\n\n")
+		}
+
+		if g.GenerateMerge {
+			code, err := g.GenerateMergeFunction(structInfo)
+			if err != nil {
+				return "", err
+			}
+			body.WriteString(code)
+			body.WriteString("\n\n")
+		}
+	}
+
+	if helpers && g.GeneratePatches {
+		fmt.Fprintln(&body, jsonPatchHelper)
+	}
+	if body.Len() == 0 {
+		return "", nil
+	}
+
+	// Templates encode JSON with sonic
+	if g.JSONLibrary == JSONStandard {
+		code := standardJSON.Replace(body.String())
+		body.Reset()
+		body.WriteString(code)
+	}
+
+	// Generate imports, only of the packages the generated code uses
+	fmt.Fprintln(&buf, "import (")
+	imports := slices.Clone(generatedImports)
+	for _, imp := range g.Types.Imports() {
+		if !slices.Contains(imports, imp) {
+			imports = append(imports, imp)
+		}
 	}
+	for _, imp := range imports {
+		if usesPackage(body.Bytes(), imp[strings.LastIndex(imp, "/")+1:]) {
+			fmt.Fprintf(&buf, "\t%q\n", imp)
+		}
+	}
+	fmt.Fprintln(&buf, ")")
+	fmt.Fprintln(&buf)
+	buf.Write(body.Bytes())
 
 	// Format the code
 	formatted, err := format.Source(buf.Bytes())
@@ -571,6 +1584,42 @@ func (g *DiffGenerator) GenerateCode() (string, error) {
 	return string(formatted), nil
 }
 
+// generatedImports are the packages generated code may use
+var generatedImports = []string{
+	"bytes",
+	"encoding/json",
+	"github.com/bytedance/sonic",
+	"reflect",
+	"sort",
+	"strconv",
+	"strings",
+	"gorm.io/gorm",
+	"gorm.io/gorm/clause",
+	"github.com/ikateclab/gorm-tracked-updates/pkg/changeset",
+	"github.com/ikateclab/gorm-tracked-updates/pkg/diffapply",
+}
+
+// usesPackage reports whether code refers to a member of the package with the given name,
+// ignoring comments, which may spell out type names of fields the code leaves alone
+func usesPackage(code []byte, name string) bool {
+	var s scanner.Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(code)), code, nil, 0)
+
+	var prev token.Token
+	var prevLit string
+	for selector := false; ; {
+		_, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return false
+		case tok == token.PERIOD && prev == token.IDENT && prevLit == name && !selector:
+			return true
+		}
+		selector = prev == token.PERIOD
+		prev, prevLit = tok, lit
+	}
+}
+
 // loadDiffTemplate loads the diff function template from embedded content
 func (g *DiffGenerator) loadDiffTemplate() (*template.Template, error) {
 	// Create template funcs
@@ -587,7 +1636,21 @@ func (g *DiffGenerator) loadDiffTemplate() (*template.Template, error) {
 		"getColumnName": func(fieldName, tagStr string) string {
 			return g.extractColumnName(fieldName, tagStr)
 		},
-		"isEmptyJSON": isEmptyJSON,
+		"isEmptyJSON":    isEmptyJSON,
+		"jsonMerge":      g.jsonMergeExpr,
+		"jsonMergeDiff":  g.jsonMergeDiffExpr,
+		"isJSONArray":    func(field StructField) bool { return field.jsonArray },
+		"customEqual":    g.customEqual,
+		"sqlNullChanged": g.sqlNullChanged,
+		"deepJSONMerge": func() bool {
+			return g.usesJSONBDeepMerge() || (g.Dialect == DialectAuto && g.JSONMerge == JSONMergeDeep)
+		},
+		"deepJSONMergeFunc": func() string {
+			if g.Dialect == DialectAuto {
+				return "jsonDeepMerge"
+			}
+			return "jsonbDeepMerge"
+		},
 	}
 
 	// Parse the embedded template
@@ -599,6 +1662,46 @@ func (g *DiffGenerator) loadDiffTemplate() (*template.Template, error) {
 	return tmpl, nil
 }
 
+// usesJSONBHelpers reports whether the generated code builds Postgres jsonb expressions
+func (g *DiffGenerator) usesJSONBHelpers() bool {
+	return g.Dialect == DialectPostgres || g.Dialect == DialectAuto
+}
+
+// usesJSONBDeepMerge reports whether the Postgres jsonb_set helpers are needed.
+// MySQL and SQLite merge patches are deep already.
+func (g *DiffGenerator) usesJSONBDeepMerge() bool {
+	return g.JSONMerge == JSONMergeDeep && g.usesJSONBHelpers()
+}
+
+// jsonMergeExpr returns the Go expression merging the marshaled jsonValue into column
+func (g *DiffGenerator) jsonMergeExpr(column string) string {
+	switch g.Dialect {
+	case DialectMySQL:
+		return fmt.Sprintf(`gorm.Expr("JSON_MERGE_PATCH(COALESCE(?, '{}'), ?)", clause.Column{Name: %q}, string(jsonValue))`, column)
+	case DialectSQLite:
+		return fmt.Sprintf(`gorm.Expr("json_patch(COALESCE(?, '{}'), ?)", clause.Column{Name: %q}, string(jsonValue))`, column)
+	case DialectAuto:
+		return fmt.Sprintf(`jsonMergeExpr{Column: clause.Column{Name: %q}, Patch: string(jsonValue)}`, column)
+	default:
+		return fmt.Sprintf(`gorm.Expr("? || ?", clause.Column{Name: %q}, string(jsonValue))`, column)
+	}
+}
+
+// jsonMergeDiffExpr returns the Go expression merging the marshaled nested diff held in
+// diffVar into column. Keys removed from the diff (nil values) are deleted on Postgres;
+// MySQL and SQLite merge patches delete null keys already.
+func (g *DiffGenerator) jsonMergeDiffExpr(column, diffVar string) string {
+	postgres := fmt.Sprintf(`jsonbRemove(gorm.Expr("? || ?", clause.Column{Name: %q}, string(jsonValue)), %s)`, column, diffVar)
+	switch g.Dialect {
+	case DialectPostgres:
+		return postgres
+	case DialectAuto:
+		return fmt.Sprintf(`jsonMergeExpr{Column: clause.Column{Name: %q}, Patch: string(jsonValue), Postgres: %s}`, column, postgres)
+	default:
+		return g.jsonMergeExpr(column)
+	}
+}
+
 // isEmptyJSON checks if a JSON string represents an empty object or array
 func isEmptyJSON(jsonStr string) bool {
 	trimmed := strings.TrimSpace(jsonStr)
@@ -621,14 +1724,242 @@ func (g *DiffGenerator) GenerateDiffFunction(structInfo StructInfo) (string, err
 	return buf.String(), nil
 }
 
-// WriteToFile writes the generated code to a file
+// loadChangesTemplate loads the Changes method template from embedded content
+func (g *DiffGenerator) loadChangesTemplate() (*template.Template, error) {
+	funcMap := template.FuncMap{
+		"hasPrefix": func(s, prefix string) bool {
+			return strings.HasPrefix(s, prefix)
+		},
+		"hasNestedChanges": g.hasNestedChanges,
+		"changeLocation":   g.changeLocation,
+		"nestArgs":         g.nestArgs,
+	}
+
+	tmpl, err := template.New("changes").Funcs(funcMap).Parse(changesFunctionTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing embedded template: %v", err)
+	}
+
+	return tmpl, nil
+}
+
+// hasNestedChanges checks if the changes of a field are reported per field of its struct
+// type, as for @jsonb structs and struct JSON columns, instead of as a whole
+func (g *DiffGenerator) hasNestedChanges(field StructField) bool {
+	switch field.FieldType {
+	case FieldTypeStruct, FieldTypeStructPtr:
+		return true
+	case FieldTypeJSON:
+		return !field.Replace && g.KnownStructs[baseTypeName(field.Type)]
+	default:
+		return false
+	}
+}
+
+// changeLocation returns the FieldChange fields locating a field: its JSON path for fields
+// of @jsonb structs, its column otherwise
+func (g *DiffGenerator) changeLocation(structInfo StructInfo, field StructField) string {
+	if g.JSONBStructs[structInfo.Name] {
+		return fmt.Sprintf("Path: []string{%q}", field.DiffKey)
+	}
+	return fmt.Sprintf("Column: %q", g.extractColumnName(field.Name, field.Tag))
+}
+
+// nestArgs returns the arguments of FieldChange.Nest for the changes of a nested struct field
+func (g *DiffGenerator) nestArgs(structInfo StructInfo, field StructField) string {
+	if g.JSONBStructs[structInfo.Name] {
+		return fmt.Sprintf("%q, \"\", %q", field.Name, field.DiffKey)
+	}
+	return fmt.Sprintf("%q, %q", field.Name, g.extractColumnName(field.Name, field.Tag))
+}
+
+// GenerateChangesFunction generates the Changes method for a struct
+func (g *DiffGenerator) GenerateChangesFunction(structInfo StructInfo) (string, error) {
+	tmpl, err := g.loadChangesTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, structInfo); err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// loadPatchTemplate loads the MergePatch and JSONPatch method template from embedded content
+func (g *DiffGenerator) loadPatchTemplate() (*template.Template, error) {
+	funcMap := template.FuncMap{
+		"hasPrefix": func(s, prefix string) bool {
+			return strings.HasPrefix(s, prefix)
+		},
+		"hasNestedPatchOps": g.hasNestedPatchOps,
+		"isPatchArray":     g.isPatchArray,
+		"omitted":          g.omitted,
+	}
+
+	tmpl, err := template.New("patch").Funcs(funcMap).Parse(patchFunctionTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing embedded template: %v", err)
+	}
+
+	return tmpl, nil
+}
+
+// hasNestedPatchOps checks if the patch operations of a field are those of its struct type,
+// nested under its key. patchOps is unexported, so structs of other packages are patched
+// as a whole.
+func (g *DiffGenerator) hasNestedPatchOps(field StructField) bool {
+	return g.hasNestedChanges(field) && !strings.Contains(baseTypeName(field.Type), ".")
+}
+
+// isPatchArray checks if a field is encoded as a JSON array that JSON patches diff by
+// index. Fields tagged diff:"replace" and replaced JSON columns are replaced as a whole.
+func (g *DiffGenerator) isPatchArray(field StructField) bool {
+	if field.Replace || g.extractDiffTag(field.Tag) == "replace" {
+		return false
+	}
+	return isJSONArrayType(field.Type) && field.Type != "[]byte"
+}
+
+// omitted returns the condition under which a field of the new or old struct value is
+// left out of its JSON object, or false if the field is never omitted
+func (g *DiffGenerator) omitted(side string, field StructField) string {
+	if check := g.omitEmptyCondition(field, side+"."+field.Name); check != "" {
+		return check
+	}
+	return "false"
+}
+
+// GeneratePatchFunctions generates the MergePatch and JSONPatch methods for a struct
+func (g *DiffGenerator) GeneratePatchFunctions(structInfo StructInfo) (string, error) {
+	tmpl, err := g.loadPatchTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, structInfo); err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// loadMergeTemplate loads the Merge method template from embedded content
+func (g *DiffGenerator) loadMergeTemplate() (*template.Template, error) {
+	funcMap := template.FuncMap{
+		"hasPrefix": func(s, prefix string) bool {
+			return strings.HasPrefix(s, prefix)
+		},
+		"hasNestedChanges": g.hasNestedChanges,
+		"changeLocation":   g.changeLocation,
+		"nestArgs":         g.nestArgs,
+	}
+
+	tmpl, err := template.New("merge").Funcs(funcMap).Parse(mergeFunctionTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing embedded template: %v", err)
+	}
+
+	return tmpl, nil
+}
+
+// GenerateMergeFunction generates the Merge method for a struct
+func (g *DiffGenerator) GenerateMergeFunction(structInfo StructInfo) (string, error) {
+	tmpl, err := g.loadMergeTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, structInfo); err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// loadApplyTemplate loads the ApplyDiff method template from embedded content
+func (g *DiffGenerator) loadApplyTemplate() (*template.Template, error) {
+	funcMap := template.FuncMap{
+		"hasPrefix": func(s, prefix string) bool {
+			return strings.HasPrefix(s, prefix)
+		},
+		"applyNested":  g.hasNestedChanges,
+		"patchNested":  g.hasNestedPatchOps,
+		"hasPatchKeys": hasPatchKeys,
+		"applyFunc":    g.applyFunc,
+	}
+
+	tmpl, err := template.New("apply").Funcs(funcMap).Parse(applyFunctionTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing embedded template: %v", err)
+	}
+
+	return tmpl, nil
+}
+
+// hasPatchKeys reports whether any of fields is encoded in the JSON of its struct
+func hasPatchKeys(fields []StructField) bool {
+	for _, field := range fields {
+		if field.PatchKey != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFunc returns the diffapply function setting a field from a diff value: a conversion
+// for numbers, strings, bools, times and UUIDs and pointers to them, Decode otherwise
+func (g *DiffGenerator) applyFunc(field StructField) string {
+	typeStr := strings.TrimPrefix(field.Type, "*")
+	suffix := ""
+	if typeStr != field.Type {
+		suffix = "Ptr"
+	}
+
+	switch {
+	case numericTypes[typeStr]:
+		return "Number" + suffix
+	case typeStr == "string":
+		return "String" + suffix
+	case typeStr == "bool":
+		return "Bool" + suffix
+	case typeStr == "time.Time" && field.FieldType == FieldTypeTime:
+		return "Time" + suffix
+	case typeStr == "uuid.UUID" && field.FieldType == FieldTypeUUID:
+		return "UUID" + suffix
+	default:
+		return "Decode"
+	}
+}
+
+// GenerateApplyFunction generates the ApplyDiff method for a struct
+func (g *DiffGenerator) GenerateApplyFunction(structInfo StructInfo) (string, error) {
+	tmpl, err := g.loadApplyTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, structInfo); err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// WriteToFile writes the generated code to a file, refusing to overwrite a file not
+// generated by gorm-gen unless Force is set
 func (g *DiffGenerator) WriteToFile(filePath string) error {
 	code, err := g.GenerateCode()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filePath, []byte(code), 0644)
+	return genfile.Write(filePath, []byte(code), g.Force)
 }
 
 // ParseFiles parses multiple Go files and extracts struct information
@@ -642,14 +1973,7 @@ func (g *DiffGenerator) ParseFiles(filePaths []string) error {
 		}
 
 		// Collect struct names
-		ast.Inspect(node, func(n ast.Node) bool {
-			if typeSpec, ok := n.(*ast.TypeSpec); ok {
-				if _, isStruct := typeSpec.Type.(*ast.StructType); isStruct {
-					g.KnownStructs[typeSpec.Name.Name] = true
-				}
-			}
-			return true
-		})
+		g.collectStructNames(node)
 
 		// Extract imports
 		g.extractImports(node.Imports)
@@ -666,7 +1990,8 @@ func (g *DiffGenerator) ParseFiles(filePaths []string) error {
 	return nil
 }
 
-// ParseDirectory parses all .go files in a directory and extracts struct information
+// ParseDirectory parses all .go files in a directory and extracts struct information.
+// Test files, files generated by gorm-gen and SkipFiles are skipped.
 func (g *DiffGenerator) ParseDirectory(dirPath string) error {
 	files, err := os.ReadDir(dirPath)
 	if err != nil {
@@ -675,10 +2000,11 @@ func (g *DiffGenerator) ParseDirectory(dirPath string) error {
 
 	var goFiles []string
 	for _, file := range files {
+		filePath := dirPath + "/" + file.Name()
 		if !file.IsDir() && strings.HasSuffix(file.Name(), ".go") &&
-			!strings.HasSuffix(file.Name(), "_test.go") &&
-			file.Name() != "clone.go" && file.Name() != "diff.go" {
-			goFiles = append(goFiles, dirPath+"/"+file.Name())
+			!strings.HasSuffix(file.Name(), "_test.go") && !slices.Contains(g.SkipFiles, file.Name()) &&
+			!genfile.IsGenerated(filePath) {
+			goFiles = append(goFiles, filePath)
 		}
 	}
 
@@ -689,13 +2015,40 @@ func (g *DiffGenerator) ParseDirectory(dirPath string) error {
 	return g.ParseFiles(goFiles)
 }
 
-// WriteToPackageDir writes the generated code to diff.go in the specified directory
+// Select restricts the parsed structs to those chosen by selector and the structs they
+// depend on, so that no Diff methods are generated for the others
+func (g *DiffGenerator) Select(selector selection.Selector) error {
+	candidates := make([]selection.Struct, len(g.Structs))
+	for i, structInfo := range g.Structs {
+		candidates[i] = selection.Struct{Name: structInfo.Name, Tracked: g.tracked[structInfo.Name]}
+		for _, field := range structInfo.Fields {
+			candidates[i].Dependencies = append(candidates[i].Dependencies, selection.References(field.Type)...)
+		}
+	}
+
+	selected, err := selector.Select(candidates)
+	if err != nil {
+		return err
+	}
+
+	var structs []StructInfo
+	for _, structInfo := range g.Structs {
+		if selected[structInfo.Name] {
+			structs = append(structs, structInfo)
+		}
+	}
+	g.Structs = structs
+	return nil
+}
+
+// WriteToPackageDir writes the generated code to FileName in the specified directory,
+// refusing to overwrite a file not generated by gorm-gen unless Force is set
 func (g *DiffGenerator) WriteToPackageDir(packageDir string) error {
 	code, err := g.GenerateCode()
 	if err != nil {
 		return err
 	}
 
-	filePath := packageDir + "/diff.go"
-	return os.WriteFile(filePath, []byte(code), 0644)
+	filePath := packageDir + "/" + g.FileName
+	return genfile.Write(filePath, []byte(code), g.Force)
 }
@@ -1,9 +1,17 @@
 package diffgen
 
 import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/genfile"
 )
 
 // Test structs
@@ -172,15 +180,34 @@ func TestWriteToFile(t *testing.T) {
 		t.Fatalf("Error parsing test file: %v", err)
 	}
 
-	// Write to a temporary file
-	tempFile := "/tmp/test_diff_output.go"
-	err = generator.WriteToFile(tempFile)
+	// Write to a temporary file, then over the generated file
+	tempFile := filepath.Join(t.TempDir(), "diff.go")
+	for i := 0; i < 2; i++ {
+		if err := generator.WriteToFile(tempFile); err != nil {
+			t.Fatalf("Error writing to file: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(tempFile)
 	if err != nil {
-		t.Fatalf("Error writing to file: %v", err)
+		t.Fatalf("Error reading generated file: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "// Code generated by gorm-gen. DO NOT EDIT.\n") {
+		t.Errorf("Expected generated code header, got:\n%s", content)
 	}
 
-	// Verify file was created (basic check)
-	// In a real test, you might want to read and verify the file content
+	// A hand-written file is only overwritten when forced
+	handWritten := filepath.Join(t.TempDir(), "diff.go")
+	if err := os.WriteFile(handWritten, []byte("package structs\n"), 0644); err != nil {
+		t.Fatalf("Error writing hand-written file: %v", err)
+	}
+	if err := generator.WriteToFile(handWritten); !errors.Is(err, genfile.ErrNotGenerated) {
+		t.Errorf("Expected ErrNotGenerated overwriting a hand-written file, got %v", err)
+	}
+	generator.Force = true
+	if err := generator.WriteToFile(handWritten); err != nil {
+		t.Errorf("Expected forced write to succeed, got %v", err)
+	}
 }
 
 // Manual diff functions for testing (simulating generated code)
@@ -428,7 +455,7 @@ func TestEmbeddedTemplate(t *testing.T) {
 	}
 
 	// Test that the template contains expected content
-	if !strings.Contains(diffFunctionTemplate, "func (new *{{.Name}}) Diff(") {
+	if !strings.Contains(diffFunctionTemplate, "func (new *{{.Name}}{{.TypeArgs}}) Diff(") {
 		t.Error("Template should contain the diff function signature")
 	}
 
@@ -443,3 +470,80 @@ func TestEmbeddedTemplate(t *testing.T) {
 		t.Error("Parsed template should not be nil")
 	}
 }
+
+func TestParseDirectorySkipsGeneratedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"models.go":    "package models\n\ntype Account struct {\n\tName string\n}\n",
+		"diff.go":      "package models\n\n// Helper is hand-written next to the models\ntype Helper struct {\n\tValue int\n}\n",
+		"generated.go": genfile.Header() + "package models\n\ntype Stale struct {\n\tValue int\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing %s: %v", name, err)
+		}
+	}
+
+	generator := New()
+	if err := generator.ParseDirectory(dir); err != nil {
+		t.Fatalf("Error parsing directory: %v", err)
+	}
+
+	var names []string
+	for _, structInfo := range generator.Structs {
+		names = append(names, structInfo.Name)
+	}
+	if strings.Join(names, ",") != "Helper,Account" {
+		t.Errorf("Expected hand-written diff.go to be parsed and generated files skipped, got %v", names)
+	}
+}
+
+func TestGenerateSourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"account.go": "package models\n\ntype Account struct {\n\tName    string\n\tProfile Profile `gorm:\"type:jsonb;serializer:json\"`\n}\n",
+		"profile.go": "package models\n\n// @jsonb\ntype Profile struct {\n\tBio string `json:\"bio\"`\n}\n",
+		"diff.go":    "package models\n\ntype Domain struct {\n\tName string\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing %s: %v", name, err)
+		}
+	}
+
+	generator := New()
+	generator.SkipFiles = []string{"diff.go"}
+	if err := generator.ParseDirectory(dir); err != nil {
+		t.Fatalf("Error parsing directory: %v", err)
+	}
+
+	generated, err := generator.GenerateSourceFiles()
+	if err != nil {
+		t.Fatalf("Error generating source files: %v", err)
+	}
+
+	var names []string
+	for name := range generated {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "account_gen.go,profile_gen.go,"+genfile.HelpersFileName {
+		t.Fatalf("Expected a file per source file and a helpers file, got %v", names)
+	}
+
+	account := generated["account_gen.go"]
+	if !strings.Contains(account, "func (new *Account) Diff(") || strings.Contains(account, "func isEmptyJSON") {
+		t.Errorf("Expected account_gen.go to hold the Account methods without helpers:\n%s", account)
+	}
+	if !strings.Contains(account, "\"gorm.io/gorm\"") || strings.Contains(generated["profile_gen.go"], "\"gorm.io/gorm\"") {
+		t.Errorf("Expected only the files using gorm to import it")
+	}
+	if !strings.Contains(generated[genfile.HelpersFileName], "func isEmptyJSON") {
+		t.Errorf("Expected helpers in %s", genfile.HelpersFileName)
+	}
+	for name, code := range generated {
+		if _, err := parser.ParseFile(token.NewFileSet(), name, code, 0); err != nil {
+			t.Errorf("Error parsing %s: %v", name, err)
+		}
+	}
+}
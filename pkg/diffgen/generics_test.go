@@ -0,0 +1,86 @@
+package diffgen
+
+import (
+	"go/parser"
+	"strings"
+	"testing"
+)
+
+// Test models for generic structs
+type TestGenericPage[T any] struct {
+	Items []T
+	First T
+	Total int
+}
+
+type TestGenericPair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+func TestGenericStructGeneration(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("generics_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	expected := map[string]map[string]FieldType{
+		"TestGenericPage": {
+			"Items": FieldTypeSlice,
+			"First": FieldTypeComplex,
+			"Total": FieldTypeSimple,
+		},
+		"TestGenericPair": {
+			"Key":   FieldTypeComparable,
+			"Value": FieldTypeComplex,
+		},
+	}
+
+	for _, structInfo := range generator.Structs {
+		fieldTypes, ok := expected[structInfo.Name]
+		if !ok {
+			continue
+		}
+		for _, field := range structInfo.Fields {
+			if field.FieldType != fieldTypes[field.Name] {
+				t.Errorf("%s.%s: expected %v, got %v", structInfo.Name, field.Name, fieldTypes[field.Name], field.FieldType)
+			}
+		}
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	for _, expectedCode := range []string{
+		"func (new *TestGenericPage[T]) Diff(old *TestGenericPage[T]) map[string]interface{}",
+		"func (new *TestGenericPair[K, V]) Diff(old *TestGenericPair[K, V]) map[string]interface{}",
+		"!reflect.DeepEqual(new.First, old.First)",
+		"new.Key != old.Key",
+	} {
+		if !strings.Contains(code, expectedCode) {
+			t.Errorf("Expected generated code to contain %q", expectedCode)
+		}
+	}
+}
+
+func TestTypeParamFallbackClassification(t *testing.T) {
+	generator := New()
+	generator.typeParams = map[string]string{"K": "comparable", "V": "any"}
+
+	for typeStr, expected := range map[string]FieldType{
+		"K": FieldTypeComparable,
+		"V": FieldTypeComplex,
+	} {
+		expr, err := parser.ParseExpr(typeStr)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", typeStr, err)
+		}
+		if fieldType := generator.determineFieldType(expr, typeStr, ""); fieldType != expected {
+			t.Errorf("determineFieldType(%s) = %v, expected %v", typeStr, fieldType, expected)
+		}
+	}
+}
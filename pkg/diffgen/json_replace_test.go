@@ -0,0 +1,120 @@
+package diffgen
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/datatypes"
+)
+
+// Test models for JSON replace semantics
+type TestReplaceStringSlice []string
+
+type TestReplaceIDs []string
+
+type TestReplaceModel struct {
+	Tags      []string               `gorm:"type:jsonb;serializer:json"`
+	Whitelist TestReplaceStringSlice `gorm:"type:jsonb" diff:"replace"`
+	Blocklist TestReplaceStringSlice `gorm:"type:jsonb"`
+	Denylist  TestReplaceStringSlice `gorm:"type:jsonb" diff:"merge"`
+	Members   TestReplaceIDs         `gorm:"type:jsonb;serializer:json"`
+	Labels    map[string]string      `gorm:"type:jsonb;serializer:json"`
+	Payload   datatypes.JSON         `gorm:"type:jsonb" diff:"replace"`
+	Extra     datatypes.JSON         `gorm:"type:jsonb"`
+}
+
+func generateReplaceModel(t *testing.T, replaceArrays bool) map[string]string {
+	t.Helper()
+
+	generator := New()
+	generator.ReplaceJSONArrays = replaceArrays
+
+	err := generator.ParseFile("json_replace_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	// Split the generated Diff into its per-field sections
+	sections := make(map[string]string)
+	for _, section := range strings.Split(code, "// Compare ")[1:] {
+		name := section[:strings.Index(section, "\n")]
+		sections[name] = section
+	}
+	return sections
+}
+
+func TestJSONReplaceTag(t *testing.T) {
+	sections := generateReplaceModel(t, true)
+
+	for field, replaced := range map[string]bool{
+		"Tags":      true, // Arrays are replaced by default
+		"Whitelist": true,
+		"Blocklist": true,
+		"Denylist":  false, // diff:"merge" opts out of the default
+		"Members":   true,  // Named slice, recognized by its underlying type
+		"Labels":    false,
+		"Payload":   true,
+		"Extra":     false, // Not an array
+	} {
+		section := sections[field]
+		isReplaced := strings.Contains(section, "= string(jsonValue)")
+		if isReplaced != replaced {
+			t.Errorf("Field %s: expected replace %v, got %v", field, replaced, isReplaced)
+		}
+		if replaced && strings.Contains(section, "isEmptyJSON") {
+			t.Errorf("Field %s: replaced fields should persist empty values", field)
+		}
+	}
+
+	// Only array columns turn a nil slice into an empty array
+	if !strings.Contains(sections["Whitelist"], `jsonValue = []byte("[]")`) {
+		t.Error("Expected Whitelist to clear to an empty array")
+	}
+	if strings.Contains(sections["Payload"], `jsonValue = []byte("[]")`) {
+		t.Error("Expected Payload not to be turned into an array")
+	}
+}
+
+func TestJSONArraysAreReplacedNotConcatenated(t *testing.T) {
+	sections := generateReplaceModel(t, true)
+
+	tags := sections["Tags"]
+	if !strings.Contains(tags, `diff["Tags"] = string(jsonValue)`) {
+		t.Errorf("Expected a []string JSON column to be replaced as a whole, got:\n%s", tags)
+	}
+	if strings.Contains(tags, "||") || strings.Contains(tags, "gorm.Expr") {
+		t.Errorf("Expected a []string JSON column not to be merged with ||, got:\n%s", tags)
+	}
+
+	// Objects keep the merge
+	if !strings.Contains(sections["Labels"], "||") {
+		t.Errorf("Expected a map JSON column to be merged, got:\n%s", sections["Labels"])
+	}
+}
+
+func TestReplaceJSONArraysDisabled(t *testing.T) {
+	sections := generateReplaceModel(t, false)
+
+	for field, replaced := range map[string]bool{
+		"Tags":      false,
+		"Whitelist": true, // diff:"replace" still replaces
+		"Blocklist": false,
+		"Denylist":  false,
+		"Members":   false,
+		"Labels":    false,
+		"Payload":   true,
+		"Extra":     false,
+	} {
+		if isReplaced := strings.Contains(sections[field], "= string(jsonValue)"); isReplaced != replaced {
+			t.Errorf("Field %s: expected replace %v, got %v", field, replaced, isReplaced)
+		}
+	}
+	if !strings.Contains(sections["Tags"], "||") {
+		t.Errorf("Expected a []string JSON column to be merged, got:\n%s", sections["Tags"])
+	}
+}
@@ -0,0 +1,82 @@
+package diffgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test model without fields
+type TestEmptyMerge struct{}
+
+func TestMergeGeneration(t *testing.T) {
+	generator := New()
+	generator.GenerateMerge = true
+
+	for _, file := range []string{"nested_json_test.go", "merge_test.go"} {
+		if err := generator.ParseFile(file); err != nil {
+			t.Fatalf("Failed to parse %s: %v", file, err)
+		}
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if !strings.Contains(code, `"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"`) {
+		t.Error("Expected changeset import")
+	}
+
+	// Fields of @jsonb structs conflict by their JSON key, nested structs merge key by key
+	dataCode := code[strings.Index(code, "func (ours *TestServiceData) Merge("):]
+	for _, expected := range []string{
+		"func (ours *TestServiceData) Merge(base, theirs *TestServiceData) (*TestServiceData, []changeset.Conflict)",
+		`changeset.Conflict{Field: "SyncCount", Path: []string{"syncCount"}, Base: base.SyncCount, Ours: ours.SyncCount, Theirs: theirs.SyncCount}`,
+		"ours.Status.Merge(&base.Status, &theirs.Status)",
+		"merged.Status = *nestedMerged",
+		`conflict.Nest("Status", "", "status")`,
+	} {
+		if !strings.Contains(dataCode, expected) {
+			t.Errorf("Expected TestServiceData.Merge to contain %q", expected)
+		}
+	}
+
+	// Fields of regular structs conflict by their column, JSONB columns merge key by key
+	serviceCode := code[strings.Index(code, "func (ours *TestService) Merge("):]
+	for _, expected := range []string{
+		`changeset.Conflict{Field: "Name", Column: "name", Base: base.Name, Ours: ours.Name, Theirs: theirs.Name}`,
+		"ours.Data != nil && base.Data != nil && theirs.Data != nil",
+		"ours.Data.Merge(base.Data, theirs.Data)",
+		`conflict.Nest("Data", "data")`,
+		`changeset.Conflict{Field: "Data", Column: "data", Base: base.Data, Ours: ours.Data, Theirs: theirs.Data}`,
+	} {
+		if !strings.Contains(serviceCode, expected) {
+			t.Errorf("Expected TestService.Merge to contain %q", expected)
+		}
+	}
+
+	// Structs without fields merge to a copy of base
+	emptyCode := code[strings.Index(code, "func (ours *TestEmptyMerge) Merge("):]
+	emptyCode = emptyCode[:strings.Index(emptyCode, "\n}\n")]
+	if strings.Contains(emptyCode, "Diff(") || !strings.Contains(emptyCode, "return &merged, nil") {
+		t.Errorf("Expected Merge without fields to return a copy of base, got:\n%s", emptyCode)
+	}
+}
+
+func TestMergeNotGeneratedByDefault(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if strings.Contains(code, "Merge(") || strings.Contains(code, "changeset") {
+		t.Error("Expected no Merge methods unless GenerateMerge is set")
+	}
+}
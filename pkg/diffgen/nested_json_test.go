@@ -346,3 +346,195 @@ func extractFunctionCode(code, structName, fieldName string) string {
 
 	return strings.Join(result, "\n")
 }
+
+func TestJSONMergeDeepGeneration(t *testing.T) {
+	generator := New()
+	generator.JSONMerge = JSONMergeDeep
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	// Root JSONB struct fields use the deep merge helper instead of ||
+	dataDiffSection := extractFunctionCode(code, "TestService", "Data")
+	if !strings.Contains(dataDiffSection, `jsonbDeepMerge("data", DataDiff)`) {
+		t.Error("Root JSONB field should use jsonbDeepMerge in deep mode")
+	}
+	if strings.Contains(dataDiffSection, "sonic.Marshal(DataDiff)") {
+		t.Error("Root JSONB field should not marshal the nested diff itself in deep mode")
+	}
+
+	// Nested structs still produce plain nested maps for the helper to walk
+	if !strings.Contains(code, `diff["status"] = nestedDiff`) {
+		t.Error("Nested diff should be assigned as plain value")
+	}
+
+	// The helper is emitted with the imports it needs
+	for _, expected := range []string{
+		"func jsonbDeepMerge(column string, patch map[string]interface{}) (clause.Expr, error)",
+		"jsonb_set(",
+		`"sort"`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+}
+
+func TestParseJSONMergeMode(t *testing.T) {
+	for name, expected := range map[string]JSONMergeMode{
+		"":        JSONMergeShallow,
+		"shallow": JSONMergeShallow,
+		"deep":    JSONMergeDeep,
+	} {
+		mode, err := ParseJSONMergeMode(name)
+		if err != nil || mode != expected {
+			t.Errorf("ParseJSONMergeMode(%q) = %v, %v; expected %v", name, mode, err, expected)
+		}
+	}
+
+	if _, err := ParseJSONMergeMode("replace"); err == nil {
+		t.Error("Expected error for unknown merge mode")
+	}
+}
+
+func TestDialectGeneration(t *testing.T) {
+	testCases := []struct {
+		dialect   Dialect
+		jsonMerge JSONMergeMode
+		expected  []string
+		absent    []string
+	}{
+		{
+			dialect:  DialectPostgres,
+			expected: []string{`gorm.Expr("? || ?", clause.Column{Name: "data"}, string(jsonValue))`},
+			absent:   []string{"jsonMergeExpr", "JSON_MERGE_PATCH", "json_patch"},
+		},
+		{
+			dialect:  DialectMySQL,
+			expected: []string{`gorm.Expr("JSON_MERGE_PATCH(COALESCE(?, '{}'), ?)", clause.Column{Name: "data"}, string(jsonValue))`},
+			absent:   []string{"? || ?", "jsonMergeExpr"},
+		},
+		{
+			dialect:   DialectSQLite,
+			jsonMerge: JSONMergeDeep, // json_patch is deep already, so no jsonb_set helpers
+			expected:  []string{`gorm.Expr("json_patch(COALESCE(?, '{}'), ?)", clause.Column{Name: "data"}, string(jsonValue))`},
+			absent:    []string{"? || ?", "jsonbDeepMerge", `"sort"`},
+		},
+		{
+			dialect: DialectAuto,
+			expected: []string{
+				`jsonMergeExpr{Column: clause.Column{Name: "data"}, Patch: string(jsonValue)}`,
+				"func (e jsonMergeExpr) Build(builder clause.Builder)",
+			},
+			absent: []string{"func jsonDeepMerge(", "jsonb_set("},
+		},
+		{
+			dialect:   DialectAuto,
+			jsonMerge: JSONMergeDeep,
+			expected: []string{
+				`jsonDeepMerge("data", DataDiff)`,
+				"func jsonDeepMerge(",
+				"func jsonbDeepMerge(",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		generator := New()
+		generator.Dialect = tc.dialect
+		generator.JSONMerge = tc.jsonMerge
+
+		err := generator.ParseFile("nested_json_test.go")
+		if err != nil {
+			t.Fatalf("Failed to parse test file: %v", err)
+		}
+
+		code, err := generator.GenerateCode()
+		if err != nil {
+			t.Fatalf("Failed to generate code for dialect %v: %v", tc.dialect, err)
+		}
+
+		for _, expected := range tc.expected {
+			if !strings.Contains(code, expected) {
+				t.Errorf("Dialect %v: expected generated code to contain %q", tc.dialect, expected)
+			}
+		}
+		for _, absent := range tc.absent {
+			if strings.Contains(code, absent) {
+				t.Errorf("Dialect %v: expected generated code not to contain %q", tc.dialect, absent)
+			}
+		}
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	for name, expected := range map[string]Dialect{
+		"":         DialectPostgres,
+		"postgres": DialectPostgres,
+		"mysql":    DialectMySQL,
+		"sqlite":   DialectSQLite,
+		"auto":     DialectAuto,
+	} {
+		dialect, err := ParseDialect(name)
+		if err != nil || dialect != expected {
+			t.Errorf("ParseDialect(%q) = %v, %v; expected %v", name, dialect, err, expected)
+		}
+	}
+
+	if _, err := ParseDialect("oracle"); err == nil {
+		t.Error("Expected error for unknown dialect")
+	}
+}
+
+func TestJSONBRemovalGeneration(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	// Emptied omitempty fields of @jsonb structs are marked for removal
+	dataCode := code[strings.Index(code, "func (new *TestServiceData) Diff("):]
+	dataCode = dataCode[:strings.Index(dataCode, "\n}\n")]
+	for _, expected := range []string{
+		`changed && new.MyId == ""`,
+		"changed && new.LastSyncAt == nil",
+		"changed && new.SyncCount == 0",
+		"changed && !new.SyncFlowDone",
+	} {
+		if !strings.Contains(dataCode, expected) {
+			t.Errorf("Expected TestServiceData.Diff to contain %q", expected)
+		}
+	}
+	if strings.Contains(dataCode, `diff["status"]; changed`) {
+		t.Error("Struct fields are never omitted and should not be marked for removal")
+	}
+
+	// Regular structs keep plain values
+	serviceCode := code[strings.Index(code, "func (new *TestService) Diff("):]
+	if strings.Contains(serviceCode, "changed &&") {
+		t.Error("Fields of non-JSONB structs should not be marked for removal")
+	}
+
+	// The root merge deletes removed keys after merging
+	expected := `jsonbRemove(gorm.Expr("? || ?", clause.Column{Name: "data"}, string(jsonValue)), DataDiff)`
+	if !strings.Contains(serviceCode, expected) {
+		t.Errorf("Expected root JSONB merge to remove keys: %s", expected)
+	}
+	if !strings.Contains(code, "func jsonbRemove(merge clause.Expr, patch map[string]interface{}) clause.Expr") {
+		t.Error("Expected jsonbRemove helper to be generated")
+	}
+}
@@ -0,0 +1,102 @@
+package diffgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test model for patch keys
+type TestPatchDocument struct {
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags,omitempty"`
+	Raw      []byte   `json:"raw"`
+	Versions []int    `json:"versions" diff:"replace"`
+	Secret   string   `json:"-"`
+	Note     string
+}
+
+func TestPatchGeneration(t *testing.T) {
+	generator := New()
+	generator.GeneratePatches = true
+
+	for _, file := range []string{"nested_json_test.go", "patch_test.go"} {
+		if err := generator.ParseFile(file); err != nil {
+			t.Fatalf("Failed to parse %s: %v", file, err)
+		}
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	for _, expected := range []string{
+		"func (new *TestService) MergePatch(old *TestService) ([]byte, error)",
+		"func (new *TestService) JSONPatch(old *TestService) ([]byte, error)",
+		"func mergePatch(ops []patchOp) ([]byte, error)",
+		"func jsonPatch(ops []patchOp) ([]byte, error)",
+		`"strconv"`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+
+	// Fields of @jsonb structs are addressed by their JSON key, JSONB columns recurse
+	serviceCode := code[strings.Index(code, "func (new *TestService) patchOps("):]
+	for _, expected := range []string{
+		`patchOp{Path: []string{"Name"}, Old: old.Name, Value: new.Name, Removed: false, Added: false}`,
+		"range new.Data.patchOps(old.Data)",
+		`op.nest("Data")`,
+	} {
+		if !strings.Contains(serviceCode, expected) {
+			t.Errorf("Expected TestService.patchOps to contain %q", expected)
+		}
+	}
+
+	dataCode := code[strings.Index(code, "func (new *TestServiceData) patchOps("):]
+	for _, expected := range []string{
+		`patchOp{Path: []string{"syncCount"}, Old: old.SyncCount, Value: new.SyncCount, Removed: new.SyncCount == 0, Added: old.SyncCount == 0}`,
+		"range new.Status.patchOps(&old.Status)",
+		`op.nest("status")`,
+	} {
+		if !strings.Contains(dataCode, expected) {
+			t.Errorf("Expected TestServiceData.patchOps to contain %q", expected)
+		}
+	}
+
+	// Only slices merged by key are diffed by index, fields tagged json:"-" are skipped
+	documentCode := code[strings.Index(code, "func (new *TestPatchDocument) patchOps("):]
+	documentCode = documentCode[:strings.Index(documentCode, "func (new *TestPatchDocument) MergePatch(")]
+	for _, expected := range []string{
+		`Value: new.Tags, Removed: len(new.Tags) == 0, Added: len(old.Tags) == 0, Array: true}`,
+		`Value: new.Raw, Removed: false, Added: false}`,
+		`Value: new.Versions, Removed: false, Added: false}`,
+		`patchOp{Path: []string{"Note"}`,
+	} {
+		if !strings.Contains(documentCode, expected) {
+			t.Errorf("Expected TestPatchDocument.patchOps to contain %q", expected)
+		}
+	}
+	if strings.Contains(documentCode, "Secret") {
+		t.Error("Expected field tagged json:\"-\" to be left out of patches")
+	}
+}
+
+func TestPatchesNotGeneratedByDefault(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("nested_json_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	if strings.Contains(code, "Patch(") || strings.Contains(code, "patchOp") {
+		t.Error("Expected no patch methods unless GeneratePatches is set")
+	}
+}
@@ -0,0 +1,106 @@
+package diffgen
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/selection"
+)
+
+// Test models for struct selection
+// @track
+type TestSelectedModel struct {
+	ID       uint
+	Settings TestSelectedSettings
+}
+
+// TestSelectedSettings is pulled in by TestSelectedModel
+type TestSelectedSettings struct {
+	Schedules []*TestSelectedSchedule
+}
+
+// TestSelectedSchedule is pulled in transitively
+type TestSelectedSchedule struct {
+	Cron string
+}
+
+// TestSelectedTable is tracked through its TableName method
+type TestSelectedTable struct {
+	Name string
+}
+
+func (TestSelectedTable) TableName() string {
+	return "selected_tables"
+}
+
+// TestSelectedRequest is a DTO without a table
+type TestSelectedRequest struct {
+	Settings TestSelectedSettings
+}
+
+func parseSelection(t *testing.T, selector selection.Selector) *DiffGenerator {
+	t.Helper()
+
+	generator := New()
+	if err := generator.ParseFile("selection_test.go"); err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+	if err := generator.Select(selector); err != nil {
+		t.Fatalf("Failed to select structs: %v", err)
+	}
+	return generator
+}
+
+func structNames(structs []StructInfo) string {
+	names := make([]string, len(structs))
+	for i, structInfo := range structs {
+		names[i] = structInfo.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSelectStructs(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector selection.Selector
+		expected string
+	}{
+		{"tracked", selection.Selector{TrackedOnly: true}, "TestSelectedModel,TestSelectedSettings,TestSelectedSchedule,TestSelectedTable"},
+		{"types", selection.Selector{Types: []string{"TestSelectedRequest"}}, "TestSelectedSettings,TestSelectedSchedule,TestSelectedRequest"},
+		{"exclude", selection.Selector{Exclude: regexp.MustCompile(`Request$`)}, "TestSelectedModel,TestSelectedSettings,TestSelectedSchedule,TestSelectedTable"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generator := parseSelection(t, test.selector)
+			if names := structNames(generator.Structs); names != test.expected {
+				t.Errorf("Expected structs %s, got %s", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestSelectedStructsGenerateCode(t *testing.T) {
+	generator := parseSelection(t, selection.Selector{Types: []string{"TestSelectedModel"}})
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	for _, expected := range []string{"func (new *TestSelectedModel) Diff(", "func (new *TestSelectedSchedule) Diff("} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+	for _, unexpected := range []string{"TestSelectedRequest", "TestSelectedTable"} {
+		if strings.Contains(code, unexpected) {
+			t.Errorf("Expected generated code not to contain %s", unexpected)
+		}
+	}
+
+	if err := New().Select(selection.Selector{Types: []string{"TestMissing"}}); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
@@ -0,0 +1,86 @@
+package diffgen
+
+import (
+	"database/sql"
+	"go/parser"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test models for nullable SQL values
+type TestNullableContact struct {
+	Name     sql.NullString
+	Seen     sql.NullTime
+	Due      sql.Null[time.Time]
+	Blob     sql.Null[[]byte]
+	Score    sql.Null[float64]
+	Nickname *sql.NullString
+}
+
+func TestSQLNullFields(t *testing.T) {
+	generator := New()
+	if err := generator.ParseFile("sql_null_test.go"); err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	for _, structInfo := range generator.Structs {
+		for _, field := range structInfo.Fields {
+			if field.FieldType != FieldTypeSQLNull {
+				t.Errorf("Expected %s to be a nullable SQL value, got %s", field.Name, field.FieldType)
+			}
+		}
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+	for _, expected := range []string{
+		"new.Name.Valid != old.Name.Valid || (new.Name.Valid && !(new.Name.String == old.Name.String))",
+		"new.Seen.Valid && !(new.Seen.Time.Equal(old.Seen.Time))",
+		"new.Due.Valid && !(new.Due.V.Equal(old.Due.V))",
+		"new.Blob.Valid && !(reflect.DeepEqual(new.Blob.V, old.Blob.V))",
+		"new.Score.Valid && !(new.Score.V == old.Score.V)",
+		"(new.Nickname == nil) != (old.Nickname == nil) || (new.Nickname != nil && (new.Nickname.Valid != old.Nickname.Valid",
+		"if new.Nickname != nil && new.Nickname.Valid {",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+
+	// Values becoming invalid are written as NULL
+	if !strings.Contains(code, `diff["Name"] = nil`) {
+		t.Error("Expected Name to be set to nil when it becomes invalid")
+	}
+}
+
+func TestSQLNullWithoutTypeInfo(t *testing.T) {
+	generator := New()
+
+	for typeStr, expected := range map[string]FieldType{
+		"sql.NullInt64":       FieldTypeSQLNull,
+		"*sql.NullTime":       FieldTypeSQLNull,
+		"sql.Null[time.Time]": FieldTypeSQLNull,
+		"sql.Null[[]byte]":    FieldTypeSQLNull,
+		"sql.RawBytes":        FieldTypeComparable,
+		"nullable.NullString": FieldTypeComparable,
+	} {
+		expr, err := parser.ParseExpr(typeStr)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", typeStr, err)
+		}
+		if fieldType := generator.determineFieldType(expr, typeStr, ""); fieldType != expected {
+			t.Errorf("Expected %s to be %s, got %s", typeStr, expected, fieldType)
+		}
+	}
+
+	// Values are compared by the type written in the type argument
+	field := StructField{Name: "Due", Type: "sql.Null[time.Time]", FieldType: FieldTypeSQLNull}
+	generator.resolveSQLNullValue(&field, nil)
+	expected := "new.Due.Valid != old.Due.Valid || (new.Due.Valid && !(new.Due.V.Equal(old.Due.V)))"
+	if changed := generator.sqlNullChanged(field, "new.Due", "old.Due"); changed != expected {
+		t.Errorf("Expected %q, got %q", expected, changed)
+	}
+}
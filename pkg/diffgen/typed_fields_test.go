@@ -0,0 +1,141 @@
+package diffgen
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test models for type-checked field classification
+type TestTags []string
+
+type TestLimits map[string]int
+
+type TestMode string
+
+type TestTypedModel struct {
+	Tags     TestTags
+	Limits   TestLimits
+	Mode     TestMode
+	Query    url.Values
+	Endpoint url.URL // Comparable struct from another package
+	Hashes   [4]uint32
+	Buckets  [2][]string
+	Status   *TestMode // Pointer to a named scalar, compared by value
+	Parent   *url.URL  // Pointer to a struct, compared by address
+}
+
+func TestTypeCheckedFieldClassification(t *testing.T) {
+	generator := New()
+
+	err := generator.ParseFile("typed_fields_test.go")
+	if err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	var model *StructInfo
+	for i := range generator.Structs {
+		if generator.Structs[i].Name == "TestTypedModel" {
+			model = &generator.Structs[i]
+		}
+	}
+	if model == nil {
+		t.Fatal("Expected to find TestTypedModel")
+	}
+
+	expected := map[string]FieldType{
+		"Tags":     FieldTypeSlice,
+		"Limits":   FieldTypeMap,
+		"Mode":     FieldTypeSimple,
+		"Query":    FieldTypeMap,
+		"Endpoint": FieldTypeComparable,
+		"Hashes":   FieldTypeComparable,
+		"Buckets":  FieldTypeComplex,
+		"Status":   FieldTypeValuePtr,
+		"Parent":   FieldTypeComparable,
+	}
+
+	for _, field := range model.Fields {
+		fieldType, ok := expected[field.Name]
+		if !ok {
+			t.Errorf("Unexpected field %s", field.Name)
+			continue
+		}
+		if field.FieldType != fieldType {
+			t.Errorf("Field %s: expected %v, got %v", field.Name, fieldType, field.FieldType)
+		}
+	}
+
+	code, err := generator.GenerateCode()
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+
+	modelCode := code[strings.Index(code, "func (new *TestTypedModel) Diff("):]
+	for _, expectedCode := range []string{
+		"!reflect.DeepEqual(new.Tags, old.Tags)",
+		"!reflect.DeepEqual(new.Query, old.Query)",
+		"!reflect.DeepEqual(new.Buckets, old.Buckets)",
+		"new.Hashes != old.Hashes",
+		"*new.Status != *old.Status",
+		"new.Parent != old.Parent",
+	} {
+		if !strings.Contains(modelCode, expectedCode) {
+			t.Errorf("Expected generated code to contain %q", expectedCode)
+		}
+	}
+	if strings.Contains(modelCode, "new.Buckets != old.Buckets") {
+		t.Error("Expected non-comparable array not to be compared with !=")
+	}
+}
+
+func TestSyntaxClassificationWithoutTypeInformation(t *testing.T) {
+	// A malformed go.mod makes the package unloadable
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module broken\n\ngo not-a-version\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := `package broken
+
+type Model struct {
+	Name  string
+	Tags  []string
+	Limit map[string]int
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings bytes.Buffer
+	generator := New()
+	generator.Warnings = &warnings
+
+	if err := generator.ParseFile(filepath.Join(dir, "models.go")); err != nil {
+		t.Fatalf("Expected parsing to fall back to syntax, got %v", err)
+	}
+	if !strings.Contains(warnings.String(), "Type information unavailable") {
+		t.Errorf("Expected a warning about missing type information, got %q", warnings.String())
+	}
+
+	if len(generator.Structs) != 1 {
+		t.Fatalf("Expected 1 struct, got %d", len(generator.Structs))
+	}
+	expected := map[string]FieldType{
+		"Name":  FieldTypeSimple,
+		"Tags":  FieldTypeSlice,
+		"Limit": FieldTypeMap,
+	}
+	for _, field := range generator.Structs[0].Fields {
+		if field.FieldType != expected[field.Name] {
+			t.Errorf("Field %s: expected %v, got %v", field.Name, expected[field.Name], field.FieldType)
+		}
+	}
+
+	if _, err := generator.GenerateCode(); err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package diffgen
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/changeset"
+	"github.com/ikateclab/gorm-tracked-updates/pkg/workspace"
+)
+
+// Test models for structs of other packages
+// @jsonb
+type TestAuditEntry struct {
+	Change changeset.FieldChange `json:"change"`
+}
+
+type TestNetworkModel struct {
+	net.IPNet
+	Name string
+}
+
+func TestEmbeddedStructFromOtherPackage(t *testing.T) {
+	generator := New()
+	if err := generator.ParseFile("workspace_test.go"); err != nil {
+		t.Fatalf("Failed to parse test file: %v", err)
+	}
+
+	var model *StructInfo
+	for i := range generator.Structs {
+		if generator.Structs[i].Name == "TestNetworkModel" {
+			model = &generator.Structs[i]
+		}
+	}
+	if model == nil {
+		t.Fatal("Expected to find TestNetworkModel")
+	}
+
+	expected := []StructField{
+		{Name: "IP", Type: "net.IP", FieldType: FieldTypeSlice},
+		{Name: "Mask", Type: "net.IPMask", FieldType: FieldTypeSlice},
+		{Name: "Name", Type: "string", FieldType: FieldTypeSimple},
+	}
+	if len(model.Fields) != len(expected) {
+		t.Fatalf("Expected fields %v, got %v", expected, model.Fields)
+	}
+	for i, field := range model.Fields {
+		if field.Name != expected[i].Name || field.Type != expected[i].Type || field.FieldType != expected[i].FieldType {
+			t.Errorf("Expected field %s %s (%s), got %s %s (%s)", expected[i].Name, expected[i].Type, expected[i].FieldType,
+				field.Name, field.Type, field.FieldType)
+		}
+	}
+}
+
+func TestExternalStructs(t *testing.T) {
+	generate := func(external *workspace.Structs) string {
+		generator := New()
+		generator.GenerateChanges = true
+		generator.External = external
+		if err := generator.ParseFile("workspace_test.go"); err != nil {
+			t.Fatalf("Failed to parse test file: %v", err)
+		}
+		code, err := generator.GenerateCode()
+		if err != nil {
+			t.Fatalf("Failed to generate code: %v", err)
+		}
+		return code
+	}
+
+	// Without the struct generated in its package, the field is compared as a whole
+	if code := generate(nil); strings.Contains(code, "new.Change.Diff(") {
+		t.Error("Expected no nested Diff call for a struct of another package without generated methods")
+	}
+
+	external := workspace.NewStructs()
+	external.Add("github.com/ikateclab/gorm-tracked-updates/pkg/changeset", "FieldChange", true)
+	code := generate(external)
+	for _, expected := range []string{
+		"nestedDiff := new.Change.Diff(&old.Change)",
+		"new.Change.Changes(&old.Change)",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected generated code to contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package genfile
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+
+	expected := `--- diff.go
++++ diff.go (generated)
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if diff := UnifiedDiff("diff.go", "diff.go (generated)", old, new); diff != expected {
+		t.Errorf("Expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+}
+
+func TestUnifiedDiffMergesCloseChanges(t *testing.T) {
+	diff := UnifiedDiff("a", "b", "1\n2\n3\n4\n5\n6\n7\n8\n", "1\nX\n3\n4\n5\n6\nY\n8\n")
+	if strings.Count(diff, "@@ -") != 1 || !strings.Contains(diff, "@@ -1,8 +1,8 @@") {
+		t.Errorf("Expected close changes in one hunk, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiffOfNewFile(t *testing.T) {
+	expected := "--- /dev/null\n+++ clone.go\n@@ -0,0 +1,2 @@\n+package models\n+\n"
+	if diff := UnifiedDiff("/dev/null", "clone.go", "", "package models\n\n"); diff != expected {
+		t.Errorf("Expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+
+	if diff := UnifiedDiff("a", "b", "same\n", "same\n"); diff != "" {
+		t.Errorf("Expected no diff of equal files, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiffOfLargeChanges(t *testing.T) {
+	// Too many lines to compare line by line: the lines between the common prefix and
+	// suffix are replaced as a whole
+	var old, new strings.Builder
+	old.WriteString("package models\n")
+	new.WriteString("package models\n")
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&old, "old %d\n", i)
+		fmt.Fprintf(&new, "new %d\n", i)
+	}
+	old.WriteString("}\n")
+	new.WriteString("}\n")
+
+	diff := UnifiedDiff("a", "b", old.String(), new.String())
+	if !strings.HasPrefix(diff, "--- a\n+++ b\n@@ -1,3002 +1,3002 @@\n package models\n-old 0\n") ||
+		!strings.HasSuffix(diff, "+new 2999\n }\n") {
+		t.Errorf("Expected one hunk replacing the changed lines, got:\n%.200s", diff)
+	}
+	if strings.Count(diff, "\n-") != 3000 || strings.Count(diff, "\n+") != 3001 {
+		t.Errorf("Expected 3000 removed and added lines")
+	}
+}
@@ -0,0 +1,212 @@
+// Package genfile writes the files produced by the code generators. Generated files start
+// with the standard header recognized by Go tools and linters,
+//
+//	// Code generated by gorm-gen. DO NOT EDIT.
+//
+// followed by the version of the generator. The generators recognize their own files by
+// this header, so they neither parse them as input nor overwrite files written by hand.
+//
+// Generated code is written to clone.go and diff.go by default, to one <source>_gen.go
+// file per source file, or to a single CombinedFileName file combining both. UnifiedDiff
+// reports how generated code drifted from the files on disk.
+package genfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// modulePath is the module path of the generators, used to look up their version
+	modulePath = "github.com/ikateclab/gorm-tracked-updates"
+
+	// headerLine is the first line of generated files
+	headerLine = "// Code generated by gorm-gen. DO NOT EDIT."
+
+	// versionPrefix starts the second line of generated files, carrying the version
+	versionPrefix = "// gorm-gen version: "
+
+	// develVersion is the version reported by builds without module version information
+	develVersion = "(devel)"
+
+	// CombinedFileName is the file clone and diff methods are written to together
+	CombinedFileName = "zz_gormtrack_gen.go"
+
+	// HelpersFileName is the file the helper functions shared by <source>_gen.go files
+	// are written to
+	HelpersFileName = "zz_gormtrack_helpers_gen.go"
+)
+
+// ErrNotGenerated is returned when writing over a file not generated by gorm-gen
+var ErrNotGenerated = errors.New("file was not generated by gorm-gen")
+
+// Version returns the module version of the generators, such as v1.4.0, or (devel) if
+// the running binary carries no version for it
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return develVersion
+	}
+
+	version := info.Main.Version
+	if info.Main.Path != modulePath {
+		version = ""
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				version = dep.Version
+				if dep.Replace != nil && dep.Replace.Version != "" {
+					version = dep.Replace.Version
+				}
+			}
+		}
+	}
+	if version == "" {
+		return develVersion
+	}
+	return version
+}
+
+// Header returns the header of generated files, ending with a blank line so it is not
+// taken for the package documentation
+func Header() string {
+	return fmt.Sprintf("%s\n%s%s\n\n", headerLine, versionPrefix, Version())
+}
+
+// SameCode reports whether two generated files are equal apart from the generator
+// versions in their headers, which differ between builds of the same code, such as
+// (devel) under go run and a pseudo-version when installed
+func SameCode(a, b string) bool {
+	return withoutVersion(a) == withoutVersion(b)
+}
+
+// withoutVersion returns code with the version removed from its header
+func withoutVersion(code string) string {
+	prefix := headerLine + "\n" + versionPrefix
+	if !strings.HasPrefix(code, prefix) {
+		return code
+	}
+	if end := strings.IndexByte(code[len(prefix):], '\n'); end >= 0 {
+		return prefix + code[len(prefix)+end:]
+	}
+	return prefix
+}
+
+// SourceFileName returns the name of the file the code generated for the structs of a
+// source file is written to, such as account_gen.go for models/account.go
+func SourceFileName(sourcePath string) string {
+	return strings.TrimSuffix(filepath.Base(sourcePath), ".go") + "_gen.go"
+}
+
+// Combine combines generated files of the same package into one, merging their imports.
+// Empty files are skipped, and an empty string is returned if all files are empty.
+func Combine(files ...string) (string, error) {
+	var packageName string
+	var imports []string
+	var body strings.Builder
+	for _, code := range files {
+		if code == "" {
+			continue
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", code, parser.ImportsOnly)
+		if err != nil {
+			return "", fmt.Errorf("error parsing generated code: %v", err)
+		}
+		packageName = file.Name.Name
+
+		// The declarations of the file follow its package clause and imports
+		end := file.Name.End()
+		for _, decl := range file.Decls {
+			end = decl.End()
+		}
+		for _, spec := range file.Imports {
+			imp := spec.Path.Value
+			if spec.Name != nil {
+				imp = spec.Name.Name + " " + imp
+			}
+			if !slices.Contains(imports, imp) {
+				imports = append(imports, imp)
+			}
+		}
+
+		body.WriteString(code[fset.Position(end).Offset:])
+		body.WriteString("\n")
+	}
+	if packageName == "" {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(Header())
+	fmt.Fprintf(&buf, "package %s\n\n", packageName)
+	if len(imports) > 0 {
+		sort.Strings(imports)
+		fmt.Fprintf(&buf, "import (\n\t%s\n)\n", strings.Join(imports, "\n\t"))
+	}
+	buf.WriteString(body.String())
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.String(), fmt.Errorf("error formatting code: %v", err)
+	}
+	return string(formatted), nil
+}
+
+// IsGenerated reports whether the Go file at filePath was generated by gorm-gen, by
+// looking for the header among the comments above its package clause. Files that cannot
+// be read or parsed are reported as not generated.
+func IsGenerated(filePath string) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return false
+	}
+
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if strings.TrimSpace(comment.Text) == headerLine {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// List returns the names of the Go files in dir generated by gorm-gen, sorted
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") && IsGenerated(filepath.Join(dir, entry.Name())) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// Write writes generated code to filePath. An existing file is only replaced if it was
+// generated by gorm-gen, unless force is set.
+func Write(filePath string, code []byte, force bool) error {
+	if _, err := os.Stat(filePath); err == nil && !force && !IsGenerated(filePath) {
+		return fmt.Errorf("%w: refusing to overwrite %s", ErrNotGenerated, filePath)
+	}
+
+	return os.WriteFile(filePath, code, 0644)
+}
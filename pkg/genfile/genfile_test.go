@@ -0,0 +1,147 @@
+package genfile
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsGenerated(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"generated.go":   Header() + "package models\n",
+		"handwritten.go": "package models\n\nfunc (s *Service) Diff(old *Service) map[string]interface{} { return nil }\n",
+		"other.go":       "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage models\n",
+		"doc.go":         "package models\n\n// Code generated by gorm-gen. DO NOT EDIT.\n",
+		"invalid.go":     "not go",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing %s: %v", name, err)
+		}
+		expected := name == "generated.go"
+		if generated := IsGenerated(filepath.Join(dir, name)); generated != expected {
+			t.Errorf("Expected IsGenerated(%s) to be %v", name, expected)
+		}
+	}
+
+	if IsGenerated(filepath.Join(dir, "missing.go")) {
+		t.Error("Expected a missing file not to be generated")
+	}
+}
+
+func TestHeader(t *testing.T) {
+	header := Header()
+	if !strings.HasPrefix(header, "// Code generated by gorm-gen. DO NOT EDIT.\n// gorm-gen version: ") {
+		t.Errorf("Unexpected header %q", header)
+	}
+	if !strings.HasSuffix(header, Version()+"\n\n") {
+		t.Errorf("Expected header to end with the version and a blank line, got %q", header)
+	}
+}
+
+func TestSameCode(t *testing.T) {
+	code := "package models\n\nfunc (a *Account) Clone() *Account { return a }\n"
+	devel := headerLine + "\n// gorm-gen version: (devel)\n\n" + code
+	installed := headerLine + "\n// gorm-gen version: v1.4.1-0.20240501120000-abcdef123456\n\n" + code
+
+	if !SameCode(devel, installed) {
+		t.Error("Expected files differing only in the generator version to be the same code")
+	}
+	if SameCode(devel, strings.Replace(installed, "return a", "return nil", 1)) {
+		t.Error("Expected files with different code not to be the same code")
+	}
+	if SameCode("package models\n", "// gorm-gen version: v1\npackage models\n") {
+		t.Error("Expected version lines outside the header to be compared")
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"clone.go":       Header() + "package models\n",
+		"account_gen.go": Header() + "package models\n",
+		"account.go":     "package models\n",
+		"notes.txt":      Header(),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing %s: %v", name, err)
+		}
+	}
+
+	names, err := List(dir)
+	if err != nil {
+		t.Fatalf("Error listing generated files: %v", err)
+	}
+	if strings.Join(names, ",") != "account_gen.go,clone.go" {
+		t.Errorf("Expected account_gen.go and clone.go, got %v", names)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "diff.go")
+	code := []byte(Header() + "package models\n")
+
+	// New and generated files are written
+	for i := 0; i < 2; i++ {
+		if err := Write(filePath, code, false); err != nil {
+			t.Fatalf("Error writing generated file: %v", err)
+		}
+	}
+
+	// Hand-written files are only overwritten when forced
+	handWritten := []byte("package models\n")
+	if err := os.WriteFile(filePath, handWritten, 0644); err != nil {
+		t.Fatalf("Error writing hand-written file: %v", err)
+	}
+	if err := Write(filePath, code, false); !errors.Is(err, ErrNotGenerated) {
+		t.Errorf("Expected ErrNotGenerated, got %v", err)
+	}
+	if content, _ := os.ReadFile(filePath); string(content) != string(handWritten) {
+		t.Errorf("Expected hand-written file to be kept, got %q", content)
+	}
+
+	if err := Write(filePath, code, true); err != nil {
+		t.Fatalf("Error forcing write: %v", err)
+	}
+	if !IsGenerated(filePath) {
+		t.Error("Expected forced write to replace the hand-written file")
+	}
+}
+
+func TestSourceFileName(t *testing.T) {
+	if name := SourceFileName("models/account.go"); name != "account_gen.go" {
+		t.Errorf("Expected account_gen.go, got %s", name)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	clone := Header() + "package models\n\nimport (\n\t\"gorm.io/datatypes\"\n)\n\n// Clone clones\nfunc (a *Account) Clone() *Account { return a }\n"
+	diff := Header() + "package models\n\nimport (\n\t\"reflect\"\n\t\"gorm.io/datatypes\"\n)\n\nfunc (a *Account) Diff(old *Account) map[string]interface{} { return nil }\n"
+
+	code, err := Combine(clone, "", diff)
+	if err != nil {
+		t.Fatalf("Error combining files: %v", err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", code, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Error parsing combined code: %v\n%s", err, code)
+	}
+	if len(file.Imports) != 2 || len(file.Decls) != 3 {
+		t.Errorf("Expected two imports and both methods, got:\n%s", code)
+	}
+	if strings.Count(code, "DO NOT EDIT") != 1 || !strings.Contains(code, "// Clone clones\n") {
+		t.Errorf("Expected one header and the method comments, got:\n%s", code)
+	}
+
+	if code, err := Combine("", ""); code != "" || err != nil {
+		t.Errorf("Expected no code combining empty files, got %q, %v", code, err)
+	}
+}
@@ -0,0 +1,157 @@
+// Package selection chooses the structs of a package the code generators generate
+// methods for, so that request DTOs and helper structs living next to the models can be
+// left out of clone.go and diff.go.
+//
+// Structs are chosen by name pattern, by an explicit list of type names, or by being
+// tracked: annotated with @track or declaring a TableName method. The structs the chosen
+// ones depend on through their fields are always chosen too, since the generated methods
+// of a struct call the methods of its nested structs.
+package selection
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Selector chooses structs. The zero value chooses every struct.
+type Selector struct {
+	Include     *regexp.Regexp // Only structs whose name matches, if set
+	Exclude     *regexp.Regexp // No structs whose name matches, if set
+	Types       []string       // Only the named structs, if set
+	TrackedOnly bool           // Only structs annotated with @track or declaring TableName
+}
+
+// Struct describes a struct that can be chosen
+type Struct struct {
+	Name         string
+	Tracked      bool     // Whether the struct is annotated with @track or declares TableName
+	Dependencies []string // Names of the types its fields refer to
+}
+
+// matches reports whether a struct is chosen by itself, regardless of its dependents
+func (s Selector) matches(structInfo Struct, types map[string]bool) bool {
+	if len(types) > 0 && !types[structInfo.Name] {
+		return false
+	}
+	if s.Include != nil && !s.Include.MatchString(structInfo.Name) {
+		return false
+	}
+	if s.Exclude != nil && s.Exclude.MatchString(structInfo.Name) {
+		return false
+	}
+	return !s.TrackedOnly || structInfo.Tracked
+}
+
+// Select returns the names of the chosen structs, including the structs they depend on.
+// It fails if Types names a struct that is not among structs.
+func (s Selector) Select(structs []Struct) (map[string]bool, error) {
+	byName := make(map[string]Struct, len(structs))
+	for _, structInfo := range structs {
+		byName[structInfo.Name] = structInfo
+	}
+
+	types := make(map[string]bool, len(s.Types))
+	for _, name := range s.Types {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("no struct named %s", name)
+		}
+		types[name] = true
+	}
+
+	// Choose the matching structs, then the structs they depend on transitively
+	selected := make(map[string]bool)
+	var queue []string
+	for _, structInfo := range structs {
+		if s.matches(structInfo, types) {
+			selected[structInfo.Name] = true
+			queue = append(queue, structInfo.Name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dependency := range byName[name].Dependencies {
+			if _, ok := byName[dependency]; ok && !selected[dependency] {
+				selected[dependency] = true
+				queue = append(queue, dependency)
+			}
+		}
+	}
+
+	return selected, nil
+}
+
+// identifierPattern matches identifiers, qualified by a package name or not
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?`)
+
+// References returns the unqualified type names a type expression refers to, such as
+// Item and Tag for "map[string][]*Item" and "JSONField[Tag]"
+func References(typeStr string) []string {
+	var names []string
+	for _, name := range identifierPattern.FindAllString(typeStr, -1) {
+		if !strings.Contains(name, ".") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// TrackedTypes returns the names of the structs of file annotated with @track and of the
+// types file declares a TableName method for
+func TrackedTypes(file *ast.File) []string {
+	var names []string
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if _, isStruct := typeSpec.Type.(*ast.StructType); isStruct &&
+					(HasAnnotation(decl.Doc, "@track") || HasAnnotation(typeSpec.Doc, "@track")) {
+					names = append(names, typeSpec.Name.Name)
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Name.Name == "TableName" && decl.Recv != nil && len(decl.Recv.List) == 1 {
+				if name := receiverName(decl.Recv.List[0].Type); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// receiverName returns the name of the type of a method receiver, such as Account for
+// "*Account" and Page for "Page[T]"
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// HasAnnotation reports whether a comment group contains the given annotation, such as
+// @track, as a word of its own, so that @tracked or user@track.io do not count
+func HasAnnotation(commentGroup *ast.CommentGroup, annotation string) bool {
+	if commentGroup == nil {
+		return false
+	}
+	return slices.Contains(strings.Fields(commentGroup.Text()), annotation)
+}
@@ -0,0 +1,135 @@
+package selection
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var testStructs = []Struct{
+	{Name: "Service", Tracked: true, Dependencies: []string{"ServiceSettings", "uint"}},
+	{Name: "ServiceSettings", Dependencies: []string{"Schedule"}},
+	{Name: "Schedule"},
+	{Name: "Account", Tracked: true},
+	{Name: "CreateServiceRequest", Dependencies: []string{"ServiceSettings"}},
+	{Name: "pagination"},
+}
+
+func selectedNames(t *testing.T, selector Selector) []string {
+	t.Helper()
+
+	selected, err := selector.Select(testStructs)
+	if err != nil {
+		t.Fatalf("Error selecting structs: %v", err)
+	}
+	var names []string
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector Selector
+		expected []string
+	}{
+		{"all", Selector{}, []string{"Account", "CreateServiceRequest", "Schedule", "Service", "ServiceSettings", "pagination"}},
+		{"types", Selector{Types: []string{"Service"}}, []string{"Schedule", "Service", "ServiceSettings"}},
+		{"include", Selector{Include: regexp.MustCompile(`^Acc`)}, []string{"Account"}},
+		{"exclude", Selector{Exclude: regexp.MustCompile(`Request$|^[a-z]`)}, []string{"Account", "Schedule", "Service", "ServiceSettings"}},
+		{"tracked", Selector{TrackedOnly: true}, []string{"Account", "Schedule", "Service", "ServiceSettings"}},
+		{"excluded dependency", Selector{Types: []string{"Service"}, Exclude: regexp.MustCompile(`Settings`)}, []string{"Schedule", "Service", "ServiceSettings"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if names := selectedNames(t, test.selector); !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestSelectUnknownType(t *testing.T) {
+	if _, err := (Selector{Types: []string{"Missing"}}).Select(testStructs); err == nil {
+		t.Error("Expected an error for a type that is not a struct of the package")
+	}
+}
+
+func TestReferences(t *testing.T) {
+	references := References("map[string][]*Item")
+	if !reflect.DeepEqual(references, []string{"map", "string", "Item"}) {
+		t.Errorf("Unexpected references %v", references)
+	}
+
+	references = References("datatypes.JSONType[Tag]")
+	if !reflect.DeepEqual(references, []string{"Tag"}) {
+		t.Errorf("Expected qualified names to be skipped, got %v", references)
+	}
+}
+
+func TestTrackedTypes(t *testing.T) {
+	source := `package models
+
+// @track
+type Service struct{}
+
+type Account struct{}
+
+func (Account) TableName() string { return "accounts" }
+
+type Page[T any] struct{}
+
+func (*Page[T]) TableName() string { return "pages" }
+
+type CreateAccountRequest struct{}
+
+func (CreateAccountRequest) Validate() error { return nil }
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "models.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Error parsing source: %v", err)
+	}
+
+	tracked := TrackedTypes(file)
+	if !reflect.DeepEqual(tracked, []string{"Service", "Account", "Page"}) {
+		t.Errorf("Expected tracked types [Service Account Page], got %v", tracked)
+	}
+}
+
+func TestHasAnnotation(t *testing.T) {
+	source := `package models
+
+// Service is tracked @track
+type Service struct{}
+
+/* @track */
+type Account struct{}
+
+// @tracked is not @track. Contact admin@track.io
+type Session struct{}
+
+// Notes on @trackers
+type Notes struct{}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "models.go", source, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Error parsing source: %v", err)
+	}
+
+	expected := map[string]bool{"Service": true, "Account": true, "Session": false, "Notes": false}
+	for _, decl := range file.Decls {
+		genDecl := decl.(*ast.GenDecl)
+		name := genDecl.Specs[0].(*ast.TypeSpec).Name.Name
+		if tracked := HasAnnotation(genDecl.Doc, "@track"); tracked != expected[name] {
+			t.Errorf("Expected HasAnnotation of %s to be %v", name, expected[name])
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Package tracker provides a GORM plugin that turns the generated Clone and Diff
+// methods into automatic tracked updates.
+//
+// Every model loaded through a *gorm.DB with the plugin registered is snapshotted
+// with its generated Clone method. A later db.Save(model) then issues an UPDATE
+// containing only the columns reported by the generated Diff method instead of
+// writing every column:
+//
+//	db.Use(tracker.New())
+//
+//	var service models.Service
+//	db.First(&service, "id = ?", id)
+//	service.Name = "renamed"
+//	db.Save(&service) // UPDATE "services" SET "name"=...,"updated_at"=... WHERE "id" = ...
+//
+// Models that do not implement the generated methods, or that were never loaded
+// through the plugin, keep GORM's regular Save behaviour.
+//
+// Models with a version field, tagged gorm:"version" or named with WithVersionField,
+// are locked optimistically: a tracked Save increments the version and only updates
+// the row if it still has the version of the snapshot, failing with ErrStaleObject
+// otherwise:
+//
+//	type Service struct {
+//		ID      uuid.UUID
+//		Name    string
+//		Version int `gorm:"version"`
+//	}
+//
+//	db.Save(&service) // UPDATE "services" SET "name"=...,"version"="version" + 1 WHERE "services"."version" = 3 AND ...
+package tracker
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// pluginName is the name the plugin is registered under
+	pluginName = "gorm-tracked-updates"
+
+	// trackedKey marks statements whose Save was rewritten into a diff update
+	trackedKey = "gorm-tracked-updates:tracked"
+	// unchangedKey marks statements whose model had no changes to write
+	unchangedKey = "gorm-tracked-updates:unchanged"
+)
+
+// Plugin is a gorm.Plugin that snapshots loaded models and saves only their diff
+type Plugin struct {
+	snapshots     *snapshotStore
+	versionFields []string // Names of the version fields of models, see WithVersionField
+}
+
+// New creates a new tracked updates plugin
+func New(options ...Option) *Plugin {
+	p := &Plugin{
+		snapshots: newSnapshotStore(),
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// Name returns the plugin name, implementing gorm.Plugin
+func (p *Plugin) Name() string {
+	return pluginName
+}
+
+// Initialize registers the plugin callbacks, implementing gorm.Plugin
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().After("gorm:after_query").
+		Register("tracker:snapshot_query", p.snapshotResult); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Create().After("gorm:after_create").
+		Register("tracker:snapshot_create", p.snapshotResult); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Update().Before("gorm:update").
+		Register("tracker:diff", p.applyDiff); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Update().After("gorm:update").
+		Register("tracker:report_unchanged", p.reportUnchanged); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Update().After("gorm:update").Before("gorm:after_update").
+		Register("tracker:check_version", p.checkVersion); err != nil {
+		return err
+	}
+
+	return db.Callback().Update().After("gorm:after_update").
+		Register("tracker:snapshot_update", p.refreshSnapshot)
+}
+
+// Track snapshots model so that the next Save writes only its changes.
+// It returns false if model does not implement the generated Clone and Diff methods.
+func (p *Plugin) Track(model interface{}) bool {
+	return p.track(reflect.ValueOf(model))
+}
+
+// Forget drops the snapshot of model so that the next Save writes every column
+func (p *Plugin) Forget(model interface{}) {
+	ptr := reflect.ValueOf(model)
+	if ptr.Kind() == reflect.Ptr && !ptr.IsNil() {
+		p.snapshots.Delete(ptr)
+	}
+}
+
+// Diff returns the changes of model since it was loaded or last saved.
+// The second return value is false if model is not tracked.
+func (p *Plugin) Diff(model interface{}) (map[string]interface{}, bool) {
+	ptr := reflect.ValueOf(model)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return nil, false
+	}
+
+	tt := lookupTrackedType(ptr.Type())
+	if tt == nil {
+		return nil, false
+	}
+
+	snapshot, ok := p.snapshots.Get(ptr)
+	if !ok {
+		return nil, false
+	}
+
+	return tt.Diff(ptr, snapshot), true
+}
+
+// Snapshot returns the snapshot of model, the state it had when it was loaded or last
+// saved. The second return value is false if model is not tracked.
+func (p *Plugin) Snapshot(model interface{}) (interface{}, bool) {
+	ptr := reflect.ValueOf(model)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return nil, false
+	}
+
+	snapshot, ok := p.snapshots.Get(ptr)
+	if !ok {
+		return nil, false
+	}
+	return snapshot.Interface(), true
+}
+
+// IsTrackedUpdate reports whether the update statement of db was rewritten into an
+// update of the model's diff. It is meant for callbacks registered after "gorm:update",
+// which run before the snapshot is refreshed.
+func IsTrackedUpdate(db *gorm.DB) bool {
+	_, tracked := db.InstanceGet(trackedKey)
+	return tracked
+}
+
+// track stores a snapshot of the model pointed to by ptr
+func (p *Plugin) track(ptr reflect.Value) bool {
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return false
+	}
+
+	tt := lookupTrackedType(ptr.Type())
+	if tt == nil {
+		return false
+	}
+
+	p.snapshots.Put(ptr, tt.Clone(ptr))
+	return true
+}
+
+// snapshotResult snapshots every model a query or create statement populated
+func (p *Plugin) snapshotResult(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			elem := value.Index(i)
+			if elem.Kind() == reflect.Struct && elem.CanAddr() {
+				elem = elem.Addr()
+			}
+			p.track(elem)
+		}
+	case reflect.Struct:
+		if value.CanAddr() {
+			p.track(value.Addr())
+		}
+	}
+}
+
+// applyDiff rewrites a Save of a tracked model into an update of its diff
+func (p *Plugin) applyDiff(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+
+	ptr := reflect.ValueOf(stmt.Dest)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct || !isSave(stmt) {
+		return
+	}
+
+	tt := lookupTrackedType(ptr.Type())
+	if tt == nil {
+		return
+	}
+
+	snapshot, ok := p.snapshots.Get(ptr)
+	if !ok {
+		return
+	}
+
+	diff := tt.Diff(ptr, snapshot)
+	stmt.Dest = diff
+	stmt.Selects = nil
+	db.InstanceSet(trackedKey, true)
+
+	if len(diff) == 0 {
+		// Nothing changed: omit every column so no UPDATE is issued at all,
+		// not even one that only bumps the auto update time
+		stmt.Omits = append(stmt.Omits, "*")
+		db.InstanceSet(unchangedKey, true)
+		return
+	}
+
+	p.lockVersion(db, diff, snapshot)
+}
+
+// reportUnchanged reports an unchanged tracked model as one matched row, so Save
+// does not fall back to upserting every column
+func (p *Plugin) reportUnchanged(db *gorm.DB) {
+	if _, ok := db.InstanceGet(unchangedKey); ok && db.Error == nil {
+		db.RowsAffected = 1
+	}
+}
+
+// refreshSnapshot re-snapshots a model after its diff was written successfully
+func (p *Plugin) refreshSnapshot(db *gorm.DB) {
+	if _, ok := db.InstanceGet(trackedKey); !ok || db.Error != nil {
+		return
+	}
+
+	p.track(reflect.ValueOf(db.Statement.Model))
+}
+
+// isSave reports whether stmt is a full-model update as issued by db.Save.
+// stmt.Dest must already be known to be a pointer, so comparing it is safe.
+func isSave(stmt *gorm.Statement) bool {
+	return len(stmt.Selects) == 1 && stmt.Selects[0] == "*" && stmt.Dest == stmt.Model
+}
@@ -0,0 +1,302 @@
+package tracker
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Test model with Clone/Diff methods (simulating generated code)
+type TestAccount struct {
+	ID        uint
+	Name      string
+	Email     string
+	Balance   int
+	UpdatedAt time.Time
+}
+
+func (original *TestAccount) Clone() *TestAccount {
+	if original == nil {
+		return nil
+	}
+	clone := *original
+	return &clone
+}
+
+func (new *TestAccount) Diff(old *TestAccount) map[string]interface{} {
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+	if new.ID != old.ID {
+		diff["ID"] = new.ID
+	}
+	if new.Name != old.Name {
+		diff["Name"] = new.Name
+	}
+	if new.Email != old.Email {
+		diff["Email"] = new.Email
+	}
+	if new.Balance != old.Balance {
+		diff["Balance"] = new.Balance
+	}
+	if !new.UpdatedAt.Equal(old.UpdatedAt) {
+		diff["UpdatedAt"] = new.UpdatedAt
+	}
+	return diff
+}
+
+// Test model without generated methods
+type TestNote struct {
+	ID   uint
+	Text string
+}
+
+// sqlRecorder is a logger that records every executed statement
+type sqlRecorder struct {
+	logger.Interface
+	mu         sync.Mutex
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, sql)
+}
+
+func (r *sqlRecorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = nil
+}
+
+func (r *sqlRecorder) find(prefix string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []string
+	for _, statement := range r.statements {
+		if strings.HasPrefix(statement, prefix) {
+			found = append(found, statement)
+		}
+	}
+	return found
+}
+
+func setupDB(t *testing.T) (*gorm.DB, *Plugin, *sqlRecorder) {
+	t.Helper()
+
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: recorder})
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+
+	plugin := New()
+	if err := db.Use(plugin); err != nil {
+		t.Fatalf("Error registering plugin: %v", err)
+	}
+
+	if err := db.AutoMigrate(&TestAccount{}, &TestNote{}); err != nil {
+		t.Fatalf("Error migrating: %v", err)
+	}
+
+	return db, plugin, recorder
+}
+
+func TestSaveWritesOnlyDiff(t *testing.T) {
+	db, _, recorder := setupDB(t)
+
+	db.Create(&TestAccount{Name: "John", Email: "john@example.com", Balance: 10})
+
+	var account TestAccount
+	if err := db.First(&account).Error; err != nil {
+		t.Fatalf("Error loading account: %v", err)
+	}
+
+	// A concurrent writer changes a column this instance does not touch
+	db.Exec("UPDATE test_accounts SET balance = 99 WHERE id = ?", account.ID)
+
+	recorder.reset()
+	account.Name = "Jane"
+	if err := db.Save(&account).Error; err != nil {
+		t.Fatalf("Error saving account: %v", err)
+	}
+
+	updates := recorder.find("UPDATE")
+	if len(updates) != 1 {
+		t.Fatalf("Expected exactly one UPDATE, got %v", recorder.statements)
+	}
+	if !strings.Contains(updates[0], "`name`=\"Jane\"") {
+		t.Errorf("Expected UPDATE to set name, got %s", updates[0])
+	}
+	if strings.Contains(updates[0], "`email`") || strings.Contains(updates[0], "`balance`") {
+		t.Errorf("Expected UPDATE to skip unchanged columns, got %s", updates[0])
+	}
+
+	var stored TestAccount
+	db.First(&stored, account.ID)
+	if stored.Name != "Jane" || stored.Balance != 99 {
+		t.Errorf("Expected name Jane and untouched balance 99, got %+v", stored)
+	}
+}
+
+func TestSaveUnchangedIssuesNoStatement(t *testing.T) {
+	db, _, recorder := setupDB(t)
+
+	db.Create(&TestAccount{Name: "John"})
+
+	var account TestAccount
+	db.First(&account)
+
+	recorder.reset()
+	result := db.Save(&account)
+	if result.Error != nil {
+		t.Fatalf("Error saving account: %v", result.Error)
+	}
+
+	if statements := append(recorder.find("UPDATE"), recorder.find("INSERT")...); len(statements) != 0 {
+		t.Errorf("Expected no statements for unchanged model, got %v", statements)
+	}
+}
+
+func TestSaveRefreshesSnapshot(t *testing.T) {
+	db, plugin, recorder := setupDB(t)
+
+	db.Create(&TestAccount{Name: "John", Email: "john@example.com"})
+
+	var account TestAccount
+	db.First(&account)
+
+	account.Name = "Jane"
+	db.Save(&account)
+
+	if diff, ok := plugin.Diff(&account); !ok || len(diff) != 0 {
+		t.Errorf("Expected empty diff after save, got %v (tracked: %v)", diff, ok)
+	}
+
+	recorder.reset()
+	account.Email = "jane@example.com"
+	db.Save(&account)
+
+	updates := recorder.find("UPDATE")
+	if len(updates) != 1 || strings.Contains(updates[0], "`name`") {
+		t.Errorf("Expected second save to only write email, got %v", updates)
+	}
+}
+
+func TestFindTracksEveryElement(t *testing.T) {
+	db, plugin, _ := setupDB(t)
+
+	db.Create(&[]TestAccount{{Name: "A"}, {Name: "B"}})
+
+	var accounts []TestAccount
+	db.Find(&accounts)
+
+	var pointers []*TestAccount
+	db.Find(&pointers)
+
+	for i := range accounts {
+		if _, ok := plugin.Diff(&accounts[i]); !ok {
+			t.Errorf("Expected accounts[%d] to be tracked", i)
+		}
+	}
+	for i, account := range pointers {
+		if _, ok := plugin.Diff(account); !ok {
+			t.Errorf("Expected pointers[%d] to be tracked", i)
+		}
+	}
+}
+
+func TestUntrackedModelsKeepRegularSave(t *testing.T) {
+	db, plugin, recorder := setupDB(t)
+
+	db.Create(&TestAccount{Name: "John", Email: "john@example.com"})
+	db.Create(&TestNote{Text: "hello"})
+
+	// Built by hand, never loaded: regular Save writes every column
+	account := TestAccount{ID: 1, Name: "Jane"}
+	recorder.reset()
+	db.Save(&account)
+	if updates := recorder.find("UPDATE"); len(updates) != 1 || !strings.Contains(updates[0], "`email`") {
+		t.Errorf("Expected full update for untracked model, got %v", updates)
+	}
+
+	// Models without generated methods are ignored
+	var note TestNote
+	db.First(&note)
+	if plugin.Track(&note) {
+		t.Error("Expected model without Clone/Diff not to be trackable")
+	}
+	note.Text = "changed"
+	if err := db.Save(&note).Error; err != nil {
+		t.Fatalf("Error saving note: %v", err)
+	}
+
+	// Forgotten models fall back to a regular save as well
+	var loaded TestAccount
+	db.First(&loaded)
+	plugin.Forget(&loaded)
+	if _, ok := plugin.Diff(&loaded); ok {
+		t.Error("Expected forgotten model not to be tracked")
+	}
+}
+
+func TestSnapshotIsStateBeforeSave(t *testing.T) {
+	db, plugin, _ := setupDB(t)
+
+	db.Create(&TestAccount{Name: "John"})
+
+	var account TestAccount
+	db.First(&account)
+	account.Name = "Jane"
+
+	snapshot, ok := plugin.Snapshot(&account)
+	if !ok {
+		t.Fatal("Expected loaded account to have a snapshot")
+	}
+	if snapshot.(*TestAccount).Name != "John" {
+		t.Errorf("Expected snapshot with the loaded name, got %+v", snapshot)
+	}
+
+	var tracked bool
+	db.Callback().Update().After("gorm:update").Register("test:tracked", func(db *gorm.DB) {
+		tracked = IsTrackedUpdate(db)
+	})
+	db.Save(&account)
+	if !tracked {
+		t.Error("Expected Save of a loaded account to be a tracked update")
+	}
+
+	if _, ok := plugin.Snapshot(&TestNote{}); ok {
+		t.Error("Expected untracked model to have no snapshot")
+	}
+}
+
+func TestSnapshotOfEmbeddedStructIsNotShared(t *testing.T) {
+	type TestAudited struct {
+		TestAccount
+		Reviewer string
+	}
+
+	store := newSnapshotStore()
+	model := &TestAudited{TestAccount: TestAccount{ID: 1, Name: "Alice"}}
+	store.Put(reflect.ValueOf(model), reflect.ValueOf(&TestAudited{}))
+
+	// The embedded account shares the model's address
+	if _, ok := store.Get(reflect.ValueOf(&model.TestAccount)); ok {
+		t.Error("Expected no snapshot for the embedded struct")
+	}
+	if snapshot, ok := store.Get(reflect.ValueOf(model)); !ok || snapshot.Type() != reflect.TypeOf(model) {
+		t.Errorf("Expected the snapshot of the model, got %v", snapshot)
+	}
+}
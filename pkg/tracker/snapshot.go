@@ -0,0 +1,130 @@
+package tracker
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// trackedType holds the generated Clone and Diff methods of a model type
+type trackedType struct {
+	clone reflect.Value // func(*T) *T
+	diff  reflect.Value // func(*T, *T) map[string]interface{}
+}
+
+var (
+	diffMapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	// trackedTypes caches the method lookup per pointer type; nil means the type is not tracked
+	trackedTypes sync.Map
+)
+
+// lookupTrackedType returns the Clone/Diff methods for ptrType when it implements
+// the methods generated by clonegen and diffgen
+func lookupTrackedType(ptrType reflect.Type) *trackedType {
+	if cached, ok := trackedTypes.Load(ptrType); ok {
+		return cached.(*trackedType)
+	}
+
+	var tt *trackedType
+	if ptrType.Kind() == reflect.Ptr && ptrType.Elem().Kind() == reflect.Struct {
+		clone, hasClone := ptrType.MethodByName("Clone")
+		diff, hasDiff := ptrType.MethodByName("Diff")
+		if hasClone && hasDiff &&
+			clone.Type.NumIn() == 1 && clone.Type.NumOut() == 1 && clone.Type.Out(0) == ptrType &&
+			diff.Type.NumIn() == 2 && diff.Type.In(1) == ptrType &&
+			diff.Type.NumOut() == 1 && diff.Type.Out(0) == diffMapType {
+			tt = &trackedType{clone: clone.Func, diff: diff.Func}
+		}
+	}
+
+	trackedTypes.Store(ptrType, tt)
+	return tt
+}
+
+// Clone returns a snapshot of the model pointed to by ptr
+func (tt *trackedType) Clone(ptr reflect.Value) reflect.Value {
+	return tt.clone.Call([]reflect.Value{ptr})[0]
+}
+
+// Diff returns the changes of the model pointed to by ptr relative to snapshot
+func (tt *trackedType) Diff(ptr, snapshot reflect.Value) map[string]interface{} {
+	diff, _ := tt.diff.Call([]reflect.Value{ptr, snapshot})[0].Interface().(map[string]interface{})
+	return diff
+}
+
+// snapshot is the state of a model as it was last loaded from or written to the database
+type snapshot struct {
+	addr  uintptr            // address of the model
+	owner weak.Pointer[byte] // identifies the model the snapshot belongs to
+	typ   reflect.Type       // type of the model, which a struct embedded at its start shares the address with
+	value reflect.Value      // pointer to the cloned model
+}
+
+// snapshotStore keeps one snapshot per live model. Snapshots are keyed by the
+// model's address and dropped once the model is garbage collected, so loading
+// models through a tracked *gorm.DB never leaks memory. A model and a struct
+// embedded at its start share an address, so snapshots also record their type.
+type snapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[uintptr]*snapshot
+}
+
+func newSnapshotStore() *snapshotStore {
+	return &snapshotStore{snapshots: make(map[uintptr]*snapshot)}
+}
+
+// Put stores value as the snapshot of the model pointed to by ptr
+func (s *snapshotStore) Put(ptr, value reflect.Value) {
+	addr := ptr.Pointer()
+	owner := (*byte)(ptr.UnsafePointer())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The same memory is being re-snapshotted, its cleanup is already registered
+	if existing, ok := s.snapshots[addr]; ok && existing.owner.Value() == owner {
+		existing.typ = ptr.Type().Elem()
+		existing.value = value
+		return
+	}
+
+	entry := &snapshot{addr: addr, owner: weak.Make(owner), typ: ptr.Type().Elem(), value: value}
+	s.snapshots[addr] = entry
+	runtime.AddCleanup(owner, s.release, entry)
+}
+
+// Get returns the snapshot of the model pointed to by ptr
+func (s *snapshotStore) Get(ptr reflect.Value) (reflect.Value, bool) {
+	addr := ptr.Pointer()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.snapshots[addr]
+	if !ok || entry.owner.Value() != (*byte)(ptr.UnsafePointer()) || entry.typ != ptr.Type().Elem() {
+		return reflect.Value{}, false
+	}
+	return entry.value, true
+}
+
+// Delete drops the snapshot of the model pointed to by ptr
+func (s *snapshotStore) Delete(ptr reflect.Value) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.snapshots, ptr.Pointer())
+}
+
+// release runs once the model owning entry has been garbage collected. The
+// address may already have been reused by a newer model, so only the matching
+// entry is removed.
+func (s *snapshotStore) release(entry *snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.snapshots[entry.addr] == entry {
+		delete(s.snapshots, entry.addr)
+	}
+}
@@ -0,0 +1,108 @@
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrStaleObject is returned by a tracked Save of a model with a version field when the
+// row was updated since the model was loaded, so its version no longer matches
+var ErrStaleObject = errors.New("tracker: stale object")
+
+// versionKey stores the version a tracked update is conditioned on
+const versionKey = "gorm-tracked-updates:version"
+
+// Option configures the plugin
+type Option func(*Plugin)
+
+// WithVersionField makes fields with the given Go or column name version fields, in
+// addition to fields tagged gorm:"version"
+func WithVersionField(name string) Option {
+	return func(p *Plugin) {
+		p.versionFields = append(p.versionFields, name)
+	}
+}
+
+// lockedVersion is the version field of a tracked update and the version it expects
+type lockedVersion struct {
+	field   *schema.Field
+	version int64
+}
+
+// versionField returns the version field of s, or nil if it has none
+func (p *Plugin) versionField(s *schema.Schema) *schema.Field {
+	for _, field := range s.Fields {
+		if _, ok := field.TagSettings["VERSION"]; ok && field.DBName != "" {
+			return field
+		}
+	}
+	for _, name := range p.versionFields {
+		if field := s.LookUpField(name); field != nil && field.DBName != "" {
+			return field
+		}
+	}
+	return nil
+}
+
+// lockVersion conditions the update of a tracked model with a version field on the
+// version of its snapshot, and increments the version in the same UPDATE
+func (p *Plugin) lockVersion(db *gorm.DB, diff map[string]interface{}, snapshot reflect.Value) {
+	stmt := db.Statement
+	field := p.versionField(stmt.Schema)
+	if field == nil {
+		return
+	}
+
+	current, _ := field.ValueOf(stmt.Context, snapshot.Elem())
+	version, ok := versionNumber(current)
+	if !ok {
+		db.AddError(fmt.Errorf("tracker: version field %s.%s must be an integer, got %T", stmt.Schema.Name, field.Name, current))
+		return
+	}
+
+	// Generated diffs are keyed by field name, so this replaces a version changed in the
+	// model: the database owns it
+	diff[field.Name] = gorm.Expr("? + 1", clause.Column{Name: field.DBName})
+
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: current},
+	}})
+	db.InstanceSet(versionKey, lockedVersion{field: field, version: version})
+}
+
+// checkVersion reports a version-locked update that matched no row as ErrStaleObject,
+// and sets the incremented version on the model of a successful one
+func (p *Plugin) checkVersion(db *gorm.DB) {
+	value, ok := db.InstanceGet(versionKey)
+	if !ok || db.Error != nil {
+		return
+	}
+	locked := value.(lockedVersion)
+
+	if db.RowsAffected == 0 {
+		db.AddError(fmt.Errorf("%w: %s was updated since version %d was loaded", ErrStaleObject, db.Statement.Table, locked.version))
+		return
+	}
+
+	if err := locked.field.Set(db.Statement.Context, db.Statement.ReflectValue, locked.version+1); err != nil {
+		db.AddError(err)
+	}
+}
+
+// versionNumber returns the integer value of a version field
+func versionNumber(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	default:
+		return 0, false
+	}
+}
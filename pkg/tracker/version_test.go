@@ -0,0 +1,188 @@
+package tracker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Test model with a version field tagged gorm:"version"
+type TestVersionedAccount struct {
+	ID      uint
+	Name    string
+	Version int `gorm:"version"`
+}
+
+func (original *TestVersionedAccount) Clone() *TestVersionedAccount {
+	if original == nil {
+		return nil
+	}
+	clone := *original
+	return &clone
+}
+
+func (new *TestVersionedAccount) Diff(old *TestVersionedAccount) map[string]interface{} {
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+	if new.Name != old.Name {
+		diff["Name"] = new.Name
+	}
+	if new.Version != old.Version {
+		diff["Version"] = new.Version
+	}
+	return diff
+}
+
+// Test model with a version field configured by name
+type TestRevisedNote struct {
+	ID       uint
+	Text     string
+	Revision uint
+}
+
+func (original *TestRevisedNote) Clone() *TestRevisedNote {
+	if original == nil {
+		return nil
+	}
+	clone := *original
+	return &clone
+}
+
+func (new *TestRevisedNote) Diff(old *TestRevisedNote) map[string]interface{} {
+	if new == nil || old == nil {
+		return nil
+	}
+
+	diff := make(map[string]interface{})
+	if new.Text != old.Text {
+		diff["Text"] = new.Text
+	}
+	return diff
+}
+
+func setupVersionedDB(t *testing.T, options ...Option) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: recorder})
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+
+	if err := db.Use(New(options...)); err != nil {
+		t.Fatalf("Error registering plugin: %v", err)
+	}
+
+	if err := db.AutoMigrate(&TestVersionedAccount{}, &TestRevisedNote{}); err != nil {
+		t.Fatalf("Error migrating: %v", err)
+	}
+
+	return db, recorder
+}
+
+func TestSaveIncrementsVersion(t *testing.T) {
+	db, recorder := setupVersionedDB(t)
+
+	db.Create(&TestVersionedAccount{Name: "John", Version: 1})
+
+	var account TestVersionedAccount
+	db.First(&account)
+
+	recorder.reset()
+	account.Name = "Jane"
+	if err := db.Save(&account).Error; err != nil {
+		t.Fatalf("Error saving account: %v", err)
+	}
+
+	updates := recorder.find("UPDATE")
+	if len(updates) != 1 || !strings.Contains(updates[0], "`version`=`version` + 1") ||
+		!strings.Contains(updates[0], "`test_versioned_accounts`.`version` = 1") {
+		t.Fatalf("Expected UPDATE incrementing and checking the version, got %v", updates)
+	}
+	if account.Version != 2 {
+		t.Errorf("Expected model version 2, got %d", account.Version)
+	}
+
+	var stored TestVersionedAccount
+	db.First(&stored, account.ID)
+	if stored.Version != 2 || stored.Name != "Jane" {
+		t.Errorf("Expected stored version 2 of Jane, got %+v", stored)
+	}
+
+	// The next save checks the incremented version
+	account.Name = "Joan"
+	if err := db.Save(&account).Error; err != nil {
+		t.Fatalf("Error saving account again: %v", err)
+	}
+	if account.Version != 3 {
+		t.Errorf("Expected model version 3, got %d", account.Version)
+	}
+
+	// Unchanged models are not written, so their version stays
+	recorder.reset()
+	db.Save(&account)
+	if updates := recorder.find("UPDATE"); len(updates) != 0 || account.Version != 3 {
+		t.Errorf("Expected unchanged save to keep version 3 without UPDATE, got %v", updates)
+	}
+}
+
+func TestSaveOfStaleObjectFails(t *testing.T) {
+	db, _ := setupVersionedDB(t)
+
+	db.Create(&TestVersionedAccount{Name: "John"})
+
+	var first, second TestVersionedAccount
+	db.First(&first)
+	db.First(&second)
+
+	first.Name = "Jane"
+	if err := db.Save(&first).Error; err != nil {
+		t.Fatalf("Error saving first copy: %v", err)
+	}
+
+	second.Name = "Joan"
+	err := db.Save(&second).Error
+	if !errors.Is(err, ErrStaleObject) {
+		t.Fatalf("Expected ErrStaleObject, got %v", err)
+	}
+	if second.Version != 0 {
+		t.Errorf("Expected stale model to keep version 0, got %d", second.Version)
+	}
+
+	// The stale save neither overwrote nor upserted the row
+	var accounts []TestVersionedAccount
+	db.Find(&accounts)
+	if len(accounts) != 1 || accounts[0].Name != "Jane" || accounts[0].Version != 1 {
+		t.Errorf("Expected the single row of the first save, got %+v", accounts)
+	}
+}
+
+func TestVersionFieldByName(t *testing.T) {
+	db, _ := setupVersionedDB(t, WithVersionField("revision"))
+
+	db.Create(&TestRevisedNote{Text: "Draft"})
+
+	var first, second TestRevisedNote
+	db.First(&first)
+	db.First(&second)
+
+	first.Text = "Final"
+	if err := db.Save(&first).Error; err != nil {
+		t.Fatalf("Error saving note: %v", err)
+	}
+	if first.Revision != 1 {
+		t.Errorf("Expected revision 1, got %d", first.Revision)
+	}
+
+	second.Text = "Other"
+	if err := db.Save(&second).Error; !errors.Is(err, ErrStaleObject) {
+		t.Errorf("Expected ErrStaleObject, got %v", err)
+	}
+}
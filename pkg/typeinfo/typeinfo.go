@@ -0,0 +1,244 @@
+// Package typeinfo loads type information for the source files parsed by the code
+// generators, so that fields can be classified by their real underlying types instead
+// of by the spelling of their type expressions.
+//
+// Loading goes through golang.org/x/tools/go/packages and therefore needs the files to
+// belong to a Go module whose dependencies can be resolved. When that is not possible
+// the generators fall back to their syntactic classification.
+package typeinfo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadMode loads the syntax and types of the requested package, and the types of its
+// dependencies from export data
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo
+
+// position identifies a type expression by file and byte offset, so expressions parsed
+// by the generators with their own file sets can be matched with the loaded syntax
+type position struct {
+	file   string
+	offset int
+}
+
+// Loader loads and caches the type-checked packages of source files
+type Loader struct {
+	fieldTypes map[position]types.Type   // Types of struct field type expressions
+	loaded     map[string]*types.Package // Packages of the loaded files
+	failed     map[string]bool           // Directories whose (test) package failed to load
+}
+
+// NewLoader creates a new Loader
+func NewLoader() *Loader {
+	return &Loader{
+		fieldTypes: make(map[position]types.Type),
+		loaded:     make(map[string]*types.Package),
+		failed:     make(map[string]bool),
+	}
+}
+
+// Load type-checks the package containing filePath, unless it was loaded already.
+// Test files are loaded as part of their test package. A package that fails to load
+// is reported once and not retried.
+func (l *Loader) Load(filePath string) error {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+
+	tests := strings.HasSuffix(absPath, "_test.go")
+	dirKey := fmt.Sprintf("%s:%v", filepath.Dir(absPath), tests)
+	if l.loaded[absPath] != nil || l.failed[dirKey] {
+		return nil
+	}
+
+	if err := l.load(absPath, tests); err != nil {
+		l.failed[dirKey] = true
+		return err
+	}
+	return nil
+}
+
+// load loads the package compiling absPath and records its field types
+func (l *Loader) load(absPath string, tests bool) error {
+	cfg := &packages.Config{
+		Mode:  loadMode,
+		Dir:   filepath.Dir(absPath),
+		Tests: tests,
+	}
+	pkgs, err := packages.Load(cfg, "file="+absPath)
+	if err != nil {
+		return err
+	}
+
+	pkg := selectPackage(pkgs, absPath)
+	if pkg == nil || pkg.Types == nil || pkg.TypesInfo == nil {
+		return fmt.Errorf("no type information for %s", absPath)
+	}
+
+	// Type errors, for example in stale generated files, are tolerated: the
+	// declarations the generators read are still type-checked
+	for _, file := range pkg.Syntax {
+		name := pkg.Fset.Position(file.Pos()).Filename
+		l.loaded[name] = pkg.Types
+		l.collectFieldTypes(pkg, file, name)
+	}
+	if l.loaded[absPath] == nil {
+		return fmt.Errorf("%s is not part of the loaded package %s", absPath, pkg.ID)
+	}
+
+	return nil
+}
+
+// selectPackage returns the loaded package that compiles filePath, preferring the
+// package itself over its test variants
+func selectPackage(pkgs []*packages.Package, filePath string) *packages.Package {
+	var found *packages.Package
+	for _, pkg := range pkgs {
+		for _, file := range pkg.CompiledGoFiles {
+			if file != filePath {
+				continue
+			}
+			if found == nil || strings.Contains(found.ID, " [") && !strings.Contains(pkg.ID, " [") {
+				found = pkg
+			}
+		}
+	}
+	return found
+}
+
+// collectFieldTypes records the type of every struct field type expression in file
+func (l *Loader) collectFieldTypes(pkg *packages.Package, file *ast.File, name string) {
+	tokenFile := pkg.Fset.File(file.Pos())
+	if tokenFile == nil {
+		return
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		structType, ok := n.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		for _, field := range structType.Fields.List {
+			if t := pkg.TypesInfo.TypeOf(field.Type); t != nil && t != types.Typ[types.Invalid] {
+				l.fieldTypes[position{file: name, offset: tokenFile.Offset(field.Type.Pos())}] = t
+			}
+		}
+		return true
+	})
+}
+
+// Package returns the type-checked package of a loaded file, or nil if the package of the
+// file has no type information
+func (l *Loader) Package(filePath string) *types.Package {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil
+	}
+	return l.loaded[absPath]
+}
+
+// FieldType returns the type of a struct field type expression parsed with fset, or nil
+// if the package of its file has no type information
+func (l *Loader) FieldType(fset *token.FileSet, expr ast.Expr) types.Type {
+	if fset == nil || !expr.Pos().IsValid() {
+		return nil
+	}
+
+	pos := fset.Position(expr.Pos())
+	absPath, err := filepath.Abs(pos.Filename)
+	if err != nil {
+		return nil
+	}
+	return l.fieldTypes[position{file: absPath, offset: pos.Offset}]
+}
+
+// IsNamed reports whether t is the named type pkgPath.name
+func IsNamed(t types.Type, pkgPath, name string) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == pkgPath
+}
+
+// IsNamedPointer reports whether t is a pointer to the named type pkgPath.name
+func IsNamedPointer(t types.Type, pkgPath, name string) bool {
+	ptr, ok := types.Unalias(t).(*types.Pointer)
+	return ok && IsNamed(ptr.Elem(), pkgPath, name)
+}
+
+// Comparable reports whether values of t can be compared with ==. Interfaces are
+// reported as not comparable, since comparing them panics for non-comparable dynamic types.
+func Comparable(t types.Type) bool {
+	if types.IsInterface(t) {
+		return false
+	}
+	return types.Comparable(t)
+}
+
+// NullValue is the value field of a nullable type of database/sql
+type NullValue struct {
+	Field   string     // Name of the field, such as String for sql.NullString or V for sql.Null[T]
+	TypeStr string     // Type of the field as written, such as string or the T of sql.Null[T]
+	Type    types.Type // Type of the field, nil without type information
+}
+
+// sqlNullValues maps the nullable types of database/sql to the name and the type of their
+// value field. The value of the generic sql.Null[T] is of its type argument.
+var sqlNullValues = map[string][2]string{
+	"NullBool":    {"Bool", "bool"},
+	"NullByte":    {"Byte", "byte"},
+	"NullFloat64": {"Float64", "float64"},
+	"NullInt16":   {"Int16", "int16"},
+	"NullInt32":   {"Int32", "int32"},
+	"NullInt64":   {"Int64", "int64"},
+	"NullString":  {"String", "string"},
+	"NullTime":    {"Time", "time.Time"},
+	"Null":        {"V", ""},
+}
+
+// SQLNull reports whether t is a nullable type of database/sql, such as sql.NullString
+// or the generic sql.Null[T], returning its value field. typeStr is the type as written,
+// which is recognized by itself when t is nil. Pointers to nullable types are not.
+func SQLNull(t types.Type, typeStr string) (NullValue, bool) {
+	name, typeArg, _ := strings.Cut(typeStr, "[")
+	if t != nil {
+		named, ok := types.Unalias(t).(*types.Named)
+		if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "database/sql" {
+			return NullValue{}, false
+		}
+		name = named.Origin().Obj().Name()
+	} else if !strings.HasPrefix(name, "sql.") {
+		return NullValue{}, false
+	}
+
+	value, ok := sqlNullValues[strings.TrimPrefix(name, "sql.")]
+	if !ok {
+		return NullValue{}, false
+	}
+	null := NullValue{Field: value[0], TypeStr: value[1]}
+	if null.TypeStr == "" {
+		null.TypeStr = strings.TrimSuffix(typeArg, "]")
+	}
+	if t != nil {
+		if structType, ok := t.Underlying().(*types.Struct); ok {
+			for i := 0; i < structType.NumFields(); i++ {
+				if structType.Field(i).Name() == null.Field {
+					null.Type = structType.Field(i).Type()
+				}
+			}
+		}
+	}
+	return null, null.TypeStr != "" || null.Type != nil
+}
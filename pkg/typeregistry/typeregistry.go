@@ -0,0 +1,186 @@
+// Package typeregistry maps types that need special handling, such as decimal.Decimal,
+// pgtype.Numeric or big.Int, to the expressions the generated code compares and clones
+// their values with. Without an entry such types are compared with != or
+// reflect.DeepEqual and copied by assignment, which is wrong or slow for many of them.
+//
+// Types are registered by their fully qualified name, the import path of their package
+// and their name, such as github.com/shopspring/decimal.Decimal. Fields of a registered
+// type and of pointers to it are handled with its expressions.
+package typeregistry
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Type is how the generated code handles the values of a registered type. Its expressions
+// are text/template templates evaluated with Go expressions of values of the type, never
+// pointers to it: Equal with .New and .Old, such as {{.New}}.Equal({{.Old}}), and Clone
+// with .Value, such as {{.Value}}.Copy(). An empty expression keeps the default handling.
+type Type struct {
+	Equal   string   // Boolean expression reporting whether .New equals .Old
+	Clone   string   // Expression of an independent copy of .Value, of the same type
+	Imports []string // Import paths of the packages the expressions refer to
+}
+
+// entry is a registered type with its parsed expressions
+type entry struct {
+	typ   Type
+	equal *template.Template
+	clone *template.Template
+}
+
+// Registry holds the registered types. Registration is not safe for concurrent use, but
+// a populated registry can be shared by generators running in parallel.
+type Registry struct {
+	types map[string]entry
+}
+
+// New creates an empty Registry
+func New() *Registry {
+	return &Registry{types: make(map[string]entry)}
+}
+
+// Register adds the handling of the type with the given fully qualified name, replacing
+// any earlier registration. It fails if the name is not qualified or an expression is
+// not a valid template.
+func (r *Registry) Register(typeName string, t Type) error {
+	dot := strings.LastIndex(typeName, ".")
+	if dot <= 0 || dot == len(typeName)-1 || strings.ContainsAny(typeName, "*[] ") {
+		return fmt.Errorf("type %q is not of the form import/path.Name", typeName)
+	}
+	if t.Equal == "" && t.Clone == "" {
+		return fmt.Errorf("type %s sets neither an equal nor a clone expression", typeName)
+	}
+
+	e := entry{typ: t}
+	var err error
+	if t.Equal != "" {
+		if e.equal, err = parseExpr(t.Equal, "New", "Old"); err != nil {
+			return fmt.Errorf("equal expression of %s: %v", typeName, err)
+		}
+	}
+	if t.Clone != "" {
+		if e.clone, err = parseExpr(t.Clone, "Value"); err != nil {
+			return fmt.Errorf("clone expression of %s: %v", typeName, err)
+		}
+	}
+	r.types[typeName] = e
+	return nil
+}
+
+// parseExpr parses an expression template and checks that it evaluates with the given
+// values set
+func parseExpr(text string, names ...string) (*template.Template, error) {
+	tmpl, err := template.New("expr").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		values[name] = "x"
+	}
+	if err := tmpl.Execute(new(bytes.Buffer), values); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Lookup returns the registered handling of the type with the given fully qualified name
+func (r *Registry) Lookup(typeName string) (Type, bool) {
+	if r == nil {
+		return Type{}, false
+	}
+	e, ok := r.types[typeName]
+	return e.typ, ok
+}
+
+// Imports returns the import paths the expressions of the registered types refer to,
+// sorted and without duplicates
+func (r *Registry) Imports() []string {
+	if r == nil {
+		return nil
+	}
+	var imports []string
+	for _, e := range r.types {
+		for _, importPath := range e.typ.Imports {
+			if !slices.Contains(imports, importPath) {
+				imports = append(imports, importPath)
+			}
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// Equal returns the expression comparing the values newExpr and oldExpr of a registered
+// type, and false if the type has no equal expression
+func (r *Registry) Equal(typeName, newExpr, oldExpr string) (string, bool) {
+	if r == nil || r.types[typeName].equal == nil {
+		return "", false
+	}
+	return execute(r.types[typeName].equal, map[string]string{"New": newExpr, "Old": oldExpr}), true
+}
+
+// Clone returns the expression copying the value valueExpr of a registered type, and
+// false if the type has no clone expression
+func (r *Registry) Clone(typeName, valueExpr string) (string, bool) {
+	if r == nil || r.types[typeName].clone == nil {
+		return "", false
+	}
+	return execute(r.types[typeName].clone, map[string]string{"Value": valueExpr}), true
+}
+
+// execute evaluates an expression template checked by parseExpr, which cannot fail
+func execute(tmpl *template.Template, values map[string]string) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+// Name returns the fully qualified name of a field type, with or without pointer, by
+// which it is registered. The type-checked type t is used when known; otherwise typeStr,
+// the type as written, is resolved through imports, which maps the import paths of the
+// parsed file to the names it imports them under. Name returns an empty string for types
+// that are not named or whose package cannot be resolved.
+func Name(t types.Type, typeStr string, imports map[string]string) string {
+	if t == nil {
+		return resolveTypeString(typeStr, imports)
+	}
+
+	t = types.Unalias(t)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = types.Unalias(ptr.Elem())
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	return named.Obj().Pkg().Path() + "." + named.Obj().Name()
+}
+
+// resolveTypeString returns the fully qualified name of a type written as pkg.Name or
+// *pkg.Name, or an empty string if its package is not imported
+func resolveTypeString(typeStr string, imports map[string]string) string {
+	typeStr = strings.TrimPrefix(typeStr, "*")
+	if i := strings.Index(typeStr, "["); i > 0 {
+		typeStr = typeStr[:i]
+	}
+	pkgName, name, ok := strings.Cut(typeStr, ".")
+	if !ok {
+		return ""
+	}
+	for importPath, importName := range imports {
+		if importName == pkgName {
+			return importPath + "." + name
+		}
+	}
+	return ""
+}
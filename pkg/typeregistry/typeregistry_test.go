@@ -0,0 +1,99 @@
+package typeregistry
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	registry := New()
+	err := registry.Register("github.com/shopspring/decimal.Decimal", Type{
+		Equal:   "{{.New}}.Equal({{.Old}})",
+		Clone:   "{{.Value}}.Copy()",
+		Imports: []string{"github.com/shopspring/decimal"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register type: %v", err)
+	}
+	if err := registry.Register("math/big.Int", Type{Clone: "*new(big.Int).Set(&{{.Value}})", Imports: []string{"math/big"}}); err != nil {
+		t.Fatalf("Failed to register type: %v", err)
+	}
+
+	if expr, ok := registry.Equal("github.com/shopspring/decimal.Decimal", "new.Price", "old.Price"); !ok || expr != "new.Price.Equal(old.Price)" {
+		t.Errorf("Unexpected equal expression %q", expr)
+	}
+	if expr, ok := registry.Clone("math/big.Int", "original.Balance"); !ok || expr != "*new(big.Int).Set(&original.Balance)" {
+		t.Errorf("Unexpected clone expression %q", expr)
+	}
+	if _, ok := registry.Equal("math/big.Int", "a", "b"); ok {
+		t.Error("Expected no equal expression for a type registered with a clone expression only")
+	}
+	if _, ok := registry.Lookup("time.Time"); ok {
+		t.Error("Expected time.Time not to be registered")
+	}
+
+	expected := []string{"github.com/shopspring/decimal", "math/big"}
+	if imports := registry.Imports(); !reflect.DeepEqual(imports, expected) {
+		t.Errorf("Expected imports %v, got %v", expected, imports)
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	tests := []struct {
+		typeName string
+		typ      Type
+		expected string
+	}{
+		{"Decimal", Type{Equal: "{{.New}} == {{.Old}}"}, "not of the form import/path.Name"},
+		{"*math/big.Int", Type{Equal: "{{.New}} == {{.Old}}"}, "not of the form import/path.Name"},
+		{"math/big.Int", Type{}, "neither an equal nor a clone expression"},
+		{"math/big.Int", Type{Equal: "{{.New}.Cmp({{.Old}})"}, "equal expression of math/big.Int"},
+		{"math/big.Int", Type{Clone: "{{.New}}"}, "clone expression of math/big.Int"},
+	}
+	for _, test := range tests {
+		err := New().Register(test.typeName, test.typ)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected an error containing %q for %s, got %v", test.expected, test.typeName, err)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	pkg := types.NewPackage("github.com/shopspring/decimal", "decimal")
+	named := types.NewNamed(types.NewTypeName(0, pkg, "Decimal", nil), types.NewStruct(nil, nil), nil)
+	imports := map[string]string{"github.com/shopspring/decimal": "dec", "math/big": "big"}
+
+	tests := []struct {
+		t        types.Type
+		typeStr  string
+		expected string
+	}{
+		{named, "dec.Decimal", "github.com/shopspring/decimal.Decimal"},
+		{types.NewPointer(named), "*dec.Decimal", "github.com/shopspring/decimal.Decimal"},
+		{types.NewSlice(named), "[]dec.Decimal", ""},
+		{nil, "*big.Int", "math/big.Int"},
+		{nil, "dec.Decimal", "github.com/shopspring/decimal.Decimal"},
+		{nil, "decimal.Decimal", ""},
+		{nil, "Money", ""},
+	}
+	for _, test := range tests {
+		if name := Name(test.t, test.typeStr, imports); name != test.expected {
+			t.Errorf("Expected %s to be named %q, got %q", test.typeStr, test.expected, name)
+		}
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var registry *Registry
+	if _, ok := registry.Lookup("math/big.Int"); ok {
+		t.Error("Expected a nil registry to hold no types")
+	}
+	if _, ok := registry.Equal("math/big.Int", "a", "b"); ok {
+		t.Error("Expected a nil registry to hold no expressions")
+	}
+	if imports := registry.Imports(); imports != nil {
+		t.Errorf("Expected no imports, got %v", imports)
+	}
+}
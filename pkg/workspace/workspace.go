@@ -0,0 +1,211 @@
+// Package workspace supports generating code for many packages in one run. It resolves
+// package patterns such as ./internal/... to package directories, and records the structs
+// generated for each package so that fields of struct types from another package of the
+// run resolve to their generated methods.
+package workspace
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/genfile"
+)
+
+// Package is a package directory to generate code for
+type Package struct {
+	Dir  string // Absolute directory of the package
+	Path string // Import path of the package, empty outside of a Go module
+}
+
+// Resolve returns the packages matched by patterns, sorted by directory. A pattern is a
+// directory, or a directory followed by /... matching it and all its subdirectories with Go
+// source files. As with the go command, testdata and vendor directories, directories
+// starting with . or _ and nested modules are not matched by /... patterns.
+func Resolve(patterns []string) ([]Package, error) {
+	seen := make(map[string]bool)
+	var packages []Package
+	add := func(dir string) error {
+		if seen[dir] {
+			return nil
+		}
+		seen[dir] = true
+
+		path, err := importPath(dir)
+		if err != nil {
+			return err
+		}
+		packages = append(packages, Package{Dir: dir, Path: path})
+		return nil
+	}
+
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(pattern, "...")
+		if recursive {
+			root = strings.TrimSuffix(root, "/")
+			if root == "" {
+				root = "."
+			}
+		}
+		if strings.Contains(root, "...") {
+			return nil, fmt.Errorf("unsupported pattern %s: ... is only supported at the end", pattern)
+		}
+
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(absRoot); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("pattern %s: %s is not a directory", pattern, root)
+		}
+
+		if !recursive {
+			if err := add(absRoot); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		matched := false
+		err = filepath.WalkDir(absRoot, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() {
+				return nil
+			}
+			if path != absRoot && skipDir(path, entry.Name()) {
+				return filepath.SkipDir
+			}
+			if !hasSourceFiles(path) {
+				return nil
+			}
+			matched = true
+			return add(path)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			return nil, fmt.Errorf("pattern %s matched no packages", pattern)
+		}
+	}
+
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Dir < packages[j].Dir })
+	return packages, nil
+}
+
+// skipDir reports whether a /... pattern skips the directory at path
+func skipDir(path, name string) bool {
+	if name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+	// Nested modules are separate from the module of the pattern
+	_, err := os.Stat(filepath.Join(path, "go.mod"))
+	return err == nil
+}
+
+// hasSourceFiles reports whether dir holds Go source files that are neither tests nor
+// generated by gorm-gen
+func hasSourceFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") &&
+			!genfile.IsGenerated(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// importPath returns the import path of the package in dir from the module path declared
+// by the closest go.mod, or an empty string if dir is not in a module
+func importPath(dir string) (string, error) {
+	for moduleDir := dir; ; moduleDir = filepath.Dir(moduleDir) {
+		modulePath, err := readModulePath(filepath.Join(moduleDir, "go.mod"))
+		if err != nil {
+			return "", err
+		}
+		if modulePath != "" {
+			rel, err := filepath.Rel(moduleDir, dir)
+			if err != nil {
+				return "", err
+			}
+			if rel == "." {
+				return modulePath, nil
+			}
+			return modulePath + "/" + filepath.ToSlash(rel), nil
+		}
+		if filepath.Dir(moduleDir) == moduleDir {
+			return "", nil
+		}
+	}
+}
+
+// readModulePath returns the module path declared by a go.mod file, or an empty string if
+// the file does not exist
+func readModulePath(goModPath string) (string, error) {
+	file, err := os.Open(goModPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(path), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s declares no module path", goModPath)
+}
+
+// Structs records the structs methods are generated for, by the import path of their
+// package. It is safe for concurrent use.
+type Structs struct {
+	mu       sync.RWMutex
+	packages map[string]map[string]bool // Whether each struct is annotated with @jsonb, by name and import path
+}
+
+// NewStructs creates an empty record of generated structs
+func NewStructs() *Structs {
+	return &Structs{packages: make(map[string]map[string]bool)}
+}
+
+// Add records that methods are generated for the struct name of the package importPath
+func (s *Structs) Add(importPath, name string, jsonb bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.packages[importPath] == nil {
+		s.packages[importPath] = make(map[string]bool)
+	}
+	s.packages[importPath][name] = jsonb
+}
+
+// Package returns the structs methods are generated for in the package importPath, with
+// whether each is annotated with @jsonb
+func (s *Structs) Package(importPath string) map[string]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	structs := make(map[string]bool, len(s.packages[importPath]))
+	for name, jsonb := range s.packages[importPath] {
+		structs[name] = jsonb
+	}
+	return structs
+}
@@ -0,0 +1,111 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ikateclab/gorm-tracked-updates/pkg/genfile"
+)
+
+// writeFiles writes files relative to dir, creating their directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Error creating directory of %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing %s: %v", name, err)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":                        "module example.com/app\n\ngo 1.24\n",
+		"internal/billing/invoice.go":   "package billing\n",
+		"internal/common/settings.go":   "package common\n",
+		"internal/common/json/json.go":  "package json\n",
+		"internal/docs/README.md":       "docs\n",
+		"internal/generated/diff.go":    genfile.Header() + "package generated\n",
+		"internal/tests/models_test.go": "package tests\n",
+		"internal/testdata/fixture.go":  "package testdata\n",
+		"internal/_old/old.go":          "package old\n",
+		"internal/tools/go.mod":         "module example.com/tools\n",
+		"internal/tools/tools.go":       "package tools\n",
+		"cmd/app/main.go":               "package main\n",
+	})
+	t.Chdir(dir)
+
+	packages, err := Resolve([]string{"./internal/...", "cmd/app", "./internal/billing"})
+	if err != nil {
+		t.Fatalf("Failed to resolve packages: %v", err)
+	}
+
+	var paths []string
+	for _, pkg := range packages {
+		if !filepath.IsAbs(pkg.Dir) {
+			t.Errorf("Expected an absolute directory, got %s", pkg.Dir)
+		}
+		paths = append(paths, pkg.Path)
+	}
+	expected := []string{
+		"example.com/app/cmd/app",
+		"example.com/app/internal/billing",
+		"example.com/app/internal/common",
+		"example.com/app/internal/common/json",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected packages %v, got %v", expected, paths)
+	}
+}
+
+func TestResolveOutsideModule(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"models/models.go": "package models\n"})
+
+	packages, err := Resolve([]string{filepath.Join(dir, "...")})
+	if err != nil {
+		t.Fatalf("Failed to resolve packages: %v", err)
+	}
+	if len(packages) != 1 || packages[0].Dir != filepath.Join(dir, "models") || packages[0].Path != "" {
+		t.Errorf("Expected the models directory without import path, got %+v", packages)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"empty/README.md": "nothing\n"})
+
+	tests := map[string]string{
+		filepath.Join(dir, "missing"):               "is not a directory",
+		filepath.Join(dir, "empty", "..."):          "matched no packages",
+		filepath.Join(dir, "...", "models"):         "only supported at the end",
+		"github.com/ikateclab/gorm-tracked-updates": "is not a directory",
+	}
+	for pattern, expected := range tests {
+		_, err := Resolve([]string{pattern})
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error containing %q for %s, got %v", expected, pattern, err)
+		}
+	}
+}
+
+func TestStructs(t *testing.T) {
+	structs := NewStructs()
+	structs.Add("example.com/app/common", "Settings", true)
+	structs.Add("example.com/app/common", "Address", false)
+
+	expected := map[string]bool{"Settings": true, "Address": false}
+	if got := structs.Package("example.com/app/common"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected structs %v, got %v", expected, got)
+	}
+	if got := structs.Package("example.com/app/billing"); len(got) != 0 {
+		t.Errorf("Expected no structs for an unknown package, got %v", got)
+	}
+}